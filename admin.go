@@ -0,0 +1,223 @@
+package locache
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AdminOpts configure the admin API of the cache.
+type AdminOpts[Key comparable, Value any] struct {
+	// Authorize is called for every request, the error rejects it with 403 Forbidden.
+	// It is required: the API deletes and overwrites the entries, so it must not be
+	// reachable without authorization, see AdminBearerToken.
+	Authorize func(r *http.Request) error
+	// ParseKey converts the key of the request to the cache key, it is required
+	// for the keys which are not strings.
+	ParseKey func(key string) (Key, error)
+	// Tags returns the tags of the entry for the deletion by tag, nil disables it.
+	Tags func(key Key, value Value) []string
+	// SnapshotPath is the file written by POST /snapshot, empty disables it.
+	SnapshotPath string
+}
+
+// AdminHandler serves the admin API of the cache, so the operators fix poisoned entries
+// without redeploying. The paths are relative to the mount point of the handler:
+//
+//	GET    /stats                  the statistics (Stats)
+//	GET    /keys/{key}             the entry: the TTL and the value encoded by encoding/json
+//	DELETE /keys/{key}             deletes the key
+//	DELETE /keys?prefix={prefix}   deletes the string keys with the prefix
+//	DELETE /keys?tag={tag}         deletes the entries with the tag (AdminOpts.Tags)
+//	PUT    /keys/{key}/ttl?ttl=30s sets the remaining lifetime of the entry (Expire)
+//	POST   /flush                  removes all entries (Clear)
+//	POST   /purge                  removes the expired entries (Purge)
+//	POST   /snapshot               writes the snapshot to AdminOpts.SnapshotPath
+//
+// The keys in the paths are escaped with url.PathEscape.
+// Mount the handler with http.StripPrefix, e.g. mux.Handle("/cache/", http.StripPrefix("/cache", handler)).
+type AdminHandler[Key comparable, Value any] struct {
+	cache *Cache[Key, Value]
+	opts  AdminOpts[Key, Value]
+}
+
+// AdminEntry is the reply of GET /keys/{key}, the zero TTL means the entry never expires.
+type AdminEntry struct {
+	Key   string          `json:"key"`
+	TTL   string          `json:"ttl"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// AdminResult is the reply of the deletions and other actions.
+type AdminResult struct {
+	Deleted int `json:"deleted"`
+}
+
+// NewAdminHandler creates the handler, the keys of the requests are parsed by opts.ParseKey
+// or used as is when the cache keys are strings. It panics without opts.Authorize,
+// the handler behind another authorization layer sets the one which accepts every request.
+func NewAdminHandler[Key comparable, Value any](cache *Cache[Key, Value], opts AdminOpts[Key, Value]) *AdminHandler[Key, Value] {
+	if opts.Authorize == nil {
+		panic("locache: AdminOpts.Authorize is required")
+	}
+	if opts.ParseKey == nil {
+		opts.ParseKey = func(key string) (Key, error) {
+			k, ok := any(key).(Key)
+			if !ok {
+				return k, fmt.Errorf("key type %T requires AdminOpts.ParseKey", k)
+			}
+			return k, nil
+		}
+	}
+	return &AdminHandler[Key, Value]{cache: cache, opts: opts}
+}
+
+// AdminBearerToken returns AdminOpts.Authorize accepting the requests
+// with the "Authorization: Bearer <token>" header, e.g. sent by locachectl -token.
+func AdminBearerToken(token string) func(r *http.Request) error {
+	expected := []byte("Bearer " + token)
+	return func(r *http.Request) error {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			return errors.New("invalid token")
+		}
+		return nil
+	}
+}
+
+func (h *AdminHandler[Key, Value]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.opts.Authorize(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// The escaped path keeps the slashes of the keys apart from the separators.
+	path := strings.Trim(r.URL.EscapedPath(), "/")
+	switch {
+	case path == "stats" && r.Method == http.MethodGet:
+		writeJSON(w, h.cache.Stats())
+	case path == "flush" && r.Method == http.MethodPost:
+		deleted := h.cache.Len()
+		h.cache.Clear()
+		writeJSON(w, AdminResult{Deleted: deleted})
+	case path == "purge" && r.Method == http.MethodPost:
+		before := h.cache.Len()
+		if err := h.cache.PurgeContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, AdminResult{Deleted: before - h.cache.Len()})
+	case path == "snapshot" && r.Method == http.MethodPost:
+		h.snapshot(w)
+	case path == "keys" && r.Method == http.MethodDelete:
+		h.deleteMatching(w, r)
+	case strings.HasPrefix(path, "keys/"):
+		h.serveKey(w, r, strings.TrimPrefix(path, "keys/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *AdminHandler[Key, Value]) serveKey(w http.ResponseWriter, r *http.Request, path string) {
+	escaped, isTTL := strings.CutSuffix(path, "/ttl")
+	raw, err := url.PathUnescape(escaped)
+	if err != nil {
+		http.Error(w, "invalid key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := h.opts.ParseKey(raw)
+	if err != nil {
+		http.Error(w, "invalid key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case isTTL && r.Method == http.MethodPut:
+		ttl, err := time.ParseDuration(r.URL.Query().Get("ttl"))
+		if err != nil {
+			http.Error(w, "invalid ttl: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !h.cache.Expire(key, ttl) {
+			http.Error(w, "key not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case !isTTL && r.Method == http.MethodGet:
+		h.inspect(w, raw, key)
+	case !isTTL && r.Method == http.MethodDelete:
+		deleted := 0
+		if _, ok := h.cache.Pop(key); ok {
+			deleted = 1
+		}
+		writeJSON(w, AdminResult{Deleted: deleted})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *AdminHandler[Key, Value]) inspect(w http.ResponseWriter, raw string, key Key) {
+	val, ok := h.cache.Peek(key)
+	if !ok {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+	ttl, _ := h.cache.TTL(key)
+
+	entry := AdminEntry{Key: raw, TTL: ttl.String()}
+	// The values which can't be encoded are omitted.
+	if data, err := json.Marshal(val); err == nil {
+		entry.Value = data
+	}
+	writeJSON(w, entry)
+}
+
+func (h *AdminHandler[Key, Value]) deleteMatching(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var match func(key Key, value Value) bool
+	switch {
+	case query.Has("prefix"):
+		prefix := query.Get("prefix")
+		match = func(key Key, _ Value) bool {
+			s, ok := any(key).(string)
+			return ok && strings.HasPrefix(s, prefix)
+		}
+	case query.Has("tag") && h.opts.Tags != nil:
+		tag := query.Get("tag")
+		match = func(key Key, value Value) bool {
+			for _, t := range h.opts.Tags(key, value) {
+				if t == tag {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		http.Error(w, "prefix or tag is required", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, AdminResult{Deleted: h.cache.DelFunc(match)})
+}
+
+func (h *AdminHandler[Key, Value]) snapshot(w http.ResponseWriter) {
+	if h.opts.SnapshotPath == "" {
+		http.Error(w, "snapshots are disabled", http.StatusNotFound)
+		return
+	}
+	if err := h.cache.SnapshotFile(h.opts.SnapshotPath); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
@@ -0,0 +1,153 @@
+package locache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func adminRequest(t *testing.T, handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// allowAll is AdminOpts.Authorize of the tests which accepts every request.
+func allowAll(*http.Request) error {
+	return nil
+}
+
+func TestAdminHandler(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, *snapshotUser](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("user/1", &snapshotUser{ID: 1, Name: "John"})
+	cache.Set("user/2", &snapshotUser{ID: 2, Name: "Jane"})
+	cache.Set("order/1", &snapshotUser{ID: 3, Name: "Jack"})
+
+	mux := http.NewServeMux()
+	mux.Handle("/cache/", http.StripPrefix("/cache", NewAdminHandler(cache, AdminOpts[string, *snapshotUser]{Authorize: allowAll})))
+
+	rec := adminRequest(t, mux, http.MethodGet, "/cache/keys/"+url.PathEscape("user/1"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"key":"user/1","ttl":"1m0s","value":{"ID":1,"Name":"John"}}`, rec.Body.String())
+
+	rec = adminRequest(t, mux, http.MethodPut, "/cache/keys/"+url.PathEscape("user/1")+"/ttl?ttl=10s")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	ttl, ok := cache.TTL("user/1")
+	require.True(t, ok)
+	require.Equal(t, 10*time.Second, ttl)
+
+	rec = adminRequest(t, mux, http.MethodDelete, "/cache/keys?prefix=user/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"deleted":2}`, rec.Body.String())
+	require.Equal(t, []string{"order/1"}, cache.Keys())
+
+	rec = adminRequest(t, mux, http.MethodDelete, "/cache/keys/"+url.PathEscape("order/1"))
+	require.JSONEq(t, `{"deleted":1}`, rec.Body.String())
+
+	rec = adminRequest(t, mux, http.MethodGet, "/cache/keys/"+url.PathEscape("order/1"))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = adminRequest(t, mux, http.MethodPut, "/cache/keys/missing/ttl?ttl=10s")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = adminRequest(t, mux, http.MethodPut, "/cache/keys/missing/ttl?ttl=never")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = adminRequest(t, mux, http.MethodPost, "/cache/keys/missing")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = adminRequest(t, mux, http.MethodDelete, "/cache/keys")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAdminHandler_Actions(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set(1, "val1")
+	cache.SetWithTTL(2, "val2", time.Second)
+	cache.Set(3, "val3")
+
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	handler := NewAdminHandler(cache, AdminOpts[int, string]{
+		ParseKey:     strconv.Atoi,
+		Tags:         func(_ int, value string) []string { return []string{value} },
+		SnapshotPath: path,
+		Authorize:    AdminBearerToken("secret"),
+	})
+
+	rec := adminRequest(t, handler, http.MethodGet, "/stats")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats CacheStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	require.Equal(t, 3, stats.Entries)
+
+	clock.Advance(2 * time.Second)
+	rec = adminRequest(t, handler, http.MethodPost, "/purge")
+	require.JSONEq(t, `{"deleted":1}`, rec.Body.String())
+
+	rec = adminRequest(t, handler, http.MethodDelete, "/keys?tag=val3")
+	require.JSONEq(t, `{"deleted":1}`, rec.Body.String())
+
+	rec = adminRequest(t, handler, http.MethodGet, "/keys/not-int")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = adminRequest(t, handler, http.MethodPost, "/snapshot")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.FileExists(t, path)
+
+	rec = adminRequest(t, handler, http.MethodPost, "/flush")
+	require.JSONEq(t, `{"deleted":1}`, rec.Body.String())
+	require.Equal(t, 0, cache.Len())
+
+	req := httptest.NewRequest(http.MethodPost, "/flush", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Equal(t, "invalid token", strings.TrimSpace(rec.Body.String()))
+
+	rec = adminRequest(t, handler, http.MethodGet, "/unknown")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCache_DelFunc(t *testing.T) {
+	cache := New[string, int]()
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	deleted := cache.DelFunc(func(_ string, value int) bool { return value%2 == 0 })
+	require.Equal(t, 5, deleted)
+	require.Equal(t, 5, cache.Len())
+
+	_, ok := cache.Get("key2")
+	require.False(t, ok)
+}
+
+func TestAdminHandler_ParseKey(t *testing.T) {
+	handler := NewAdminHandler(New[int, string](), AdminOpts[int, string]{Authorize: allowAll})
+
+	rec := adminRequest(t, handler, http.MethodGet, "/keys/1")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "requires AdminOpts.ParseKey")
+}
+
+func TestNewAdminHandler_Authorize(t *testing.T) {
+	require.PanicsWithValue(t, "locache: AdminOpts.Authorize is required", func() {
+		NewAdminHandler(New[string, string](), AdminOpts[string, string]{})
+	})
+}
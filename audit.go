@@ -0,0 +1,73 @@
+package locache
+
+import "time"
+
+// AuditRecord is a mutation of the key recorded by the audit log (WithAudit).
+// The Reason is given by SetWithReason and DelWithReason, the removals made
+// by the cache have the reason "expired" or "capacity".
+type AuditRecord[Key comparable] struct {
+	Time   time.Time
+	Key    Key
+	Op     ChangeKind
+	Reason string
+}
+
+// auditRing keeps the last records, the oldest one is overwritten when the ring is full.
+type auditRing[Key comparable] struct {
+	records []AuditRecord[Key]
+	next    int
+	full    bool
+}
+
+func newAuditRing[Key comparable](size int) *auditRing[Key] {
+	return &auditRing[Key]{records: make([]AuditRecord[Key], size)}
+}
+
+func (r *auditRing[Key]) add(record AuditRecord[Key]) {
+	r.records[r.next] = record
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// list returns a copy of the records from the oldest one.
+func (r *auditRing[Key]) list() []AuditRecord[Key] {
+	if !r.full {
+		return append([]AuditRecord[Key](nil), r.records[:r.next]...)
+	}
+
+	records := make([]AuditRecord[Key], 0, len(r.records))
+	records = append(records, r.records[r.next:]...)
+	return append(records, r.records[:r.next]...)
+}
+
+// SetWithReason works like Set, and records the reason of the write in the audit log.
+func (c *Cache[Key, Value]) SetWithReason(key Key, value Value, reason string) {
+	c.set(key, value, c.ttl, reason, true)
+}
+
+// DelWithReason works like Del, and records the reason of the deletion in the audit log.
+func (c *Cache[Key, Value]) DelWithReason(key Key, reason string) {
+	c.del(key, reason, true)
+}
+
+// AuditLog returns the last mutations from the oldest one, it returns nil
+// when the audit log is not enabled with WithAudit.
+func (c *Cache[Key, Value]) AuditLog() []AuditRecord[Key] {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if c.auditLog == nil {
+		return nil
+	}
+	return c.auditLog.list()
+}
+
+// audit records the mutation, it must be called under the write lock.
+func (c *Cache[Key, Value]) audit(op ChangeKind, key Key, reason string) {
+	if c.auditLog != nil {
+		c.auditLog.add(AuditRecord[Key]{Time: c.clock.Now(), Key: key, Op: op, Reason: reason})
+	}
+}
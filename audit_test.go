@@ -0,0 +1,52 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_AuditLog(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock), WithMaxEntries(2), WithAudit(4))
+	require.Empty(t, cache.AuditLog())
+
+	start := clock.Now()
+	cache.Set("key0", "value0")
+	cache.SetWithReason("key1", "value1", "import")
+	cache.DelWithReason("key0", "user request")
+	cache.Set("key2", "value2")
+
+	require.Equal(t, []AuditRecord[string]{
+		{Time: start, Key: "key0", Op: ChangeSet},
+		{Time: start, Key: "key1", Op: ChangeSet, Reason: "import"},
+		{Time: start, Key: "key0", Op: ChangeDelete, Reason: "user request"},
+		{Time: start, Key: "key2", Op: ChangeSet},
+	}, cache.AuditLog())
+
+	// The oldest records are overwritten, the evicted entry is recorded before the new one.
+	cache.Set("key3", "value3")
+	require.Equal(t, []AuditRecord[string]{
+		{Time: start, Key: "key0", Op: ChangeDelete, Reason: "user request"},
+		{Time: start, Key: "key2", Op: ChangeSet},
+		{Time: start, Key: "key1", Op: ChangeDelete, Reason: "capacity"},
+		{Time: start, Key: "key3", Op: ChangeSet},
+	}, cache.AuditLog())
+
+	clock.Advance(2 * time.Minute)
+	cache.Purge()
+
+	records := cache.AuditLog()
+	require.Len(t, records, 4)
+	require.ElementsMatch(t, []AuditRecord[string]{
+		{Time: start.Add(2 * time.Minute), Key: "key2", Op: ChangeExpire, Reason: "expired"},
+		{Time: start.Add(2 * time.Minute), Key: "key3", Op: ChangeExpire, Reason: "expired"},
+	}, records[2:])
+}
+
+func TestCache_AuditLog_Disabled(t *testing.T) {
+	cache := New[string, string]()
+	cache.Set("key0", "value0")
+	require.Nil(t, cache.AuditLog())
+}
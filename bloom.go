@@ -0,0 +1,74 @@
+package locache
+
+import "math"
+
+// bloomFilter is a minimal bloom filter working on precomputed 64-bit hashes.
+type bloomFilter struct {
+	bits   []uint64
+	mask   uint64
+	hashes int
+}
+
+// newBloomFilter creates a filter sized for the number of items and false positive rate.
+func newBloomFilter(items int, falsePositiveRate float64) *bloomFilter {
+	if items < 1 {
+		items = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	bitsCount := -float64(items) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)
+	hashes := int(math.Ceil(math.Ln2 * bitsCount / float64(items)))
+	if hashes < 1 {
+		hashes = 1
+	}
+
+	words := nextPowerOfTwo(uint64(math.Ceil(bitsCount / 64)))
+
+	return &bloomFilter{
+		bits:   make([]uint64, words),
+		mask:   words*64 - 1,
+		hashes: hashes,
+	}
+}
+
+// Add puts the hash into the filter and reports whether it was already there.
+func (f *bloomFilter) Add(hash uint64) bool {
+	found := true
+	h1, h2 := hash, hash>>32|hash<<32
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) & f.mask
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			found = false
+			f.bits[word] |= mask
+		}
+	}
+	return found
+}
+
+func (f *bloomFilter) Contains(hash uint64) bool {
+	h1, h2 := hash, hash>>32|hash<<32
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) & f.mask
+		if f.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *bloomFilter) Reset() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+func nextPowerOfTwo(v uint64) uint64 {
+	p := uint64(1)
+	for p < v {
+		p <<= 1
+	}
+	return p
+}
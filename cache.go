@@ -4,210 +4,1151 @@ import (
 	"container/list"
 	"context"
 	"fmt"
+	"math/rand"
+	"os"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var now = time.Now
+var random = rand.Float64
 
 type Item[Key comparable, Value any] struct {
-	mtx sync.Mutex
+	mtx itemLock
 	key Key
 	val Value
 	exp time.Time
 	set bool
+	ttl time.Duration
+
+	// idle is the time the entry expires at when it is not accessed.
+	idle time.Time
+
+	// delta is the duration of the last refresh, it is used by the early refresh.
+	delta time.Duration
+
+	onExpire func(Key, Value)
+
+	// err is the last refresh error cached until errExp.
+	err    error
+	errExp time.Time
+
+	cost int64
+
+	// version changes on every store of the value.
+	version uint64
+
+	// heapIdx is the position in the expiry heap plus one, zero means the item is not in the heap.
+	heapIdx int
+}
+
+func (i *Item[Key, Value]) IsExpired(at time.Time) bool {
+	exp := i.ExpiresAt()
+	return !exp.IsZero() && !at.Before(exp)
+}
+
+// ExpiresAt returns the time the entry expires at, taking into account
+// both TTL and idle timeout. The zero time means the entry never expires.
+func (i *Item[Key, Value]) ExpiresAt() time.Time {
+	if i.idle.IsZero() || (!i.exp.IsZero() && i.exp.Before(i.idle)) {
+		return i.exp
+	}
+	return i.idle
 }
 
-func (i *Item[Key, Value]) IsExpired() bool {
-	return i.exp.Before(now())
+func (i *Item[Key, Value]) IsValid(at time.Time) bool {
+	return i.set && !i.IsExpired(at)
 }
 
-func (i *Item[Key, Value]) IsValid() bool {
-	return i.set && !i.IsExpired()
+func (i *Item[Key, Value]) cachedError(at time.Time) error {
+	if i.err != nil && at.Before(i.errExp) {
+		return i.err
+	}
+	return nil
 }
 
 type Cache[Key comparable, Value any] struct {
-	ttl time.Duration
-	mtx sync.RWMutex
-	mtr Metrics
+	ttl   time.Duration
+	mtx   sync.RWMutex
+	mtr   Metrics
+	clock Clock
+
+	// timed is false for NopMetrics, so hot paths skip reading the time.
+	timed        bool
+	requestClock Clock
+	stats        *statsMetrics
+
+	items    *list.List
+	index    map[Key]*list.Element
+	expiries expiryHeap[Key, Value]
+
+	maxEntries int
+	maxCost    int64
+	cost       int64
+	version    uint64
+	weigher    Weigher[Key, Value]
+	onEvict    func(Key, Value, RemovalReason)
+	onSet      func(Key, Value)
+	onDelete   func(Key)
+	policy     EvictionPolicy[Key]
+	admission  *tinyLFU[Key]
+	doorkeeper *doorkeeper[Key]
+
+	// customPolicy is set by WithEvictionPolicy, the built-in modes use the order of items,
+	// fifo keeps the order of insertion.
+	customPolicy bool
+	fifo         bool
+	trackAccess  bool
+	sliding      bool
+	maxIdle      time.Duration
+	errorTTL     time.Duration
+
+	refreshTimeout time.Duration
+	retryPolicy    RetryPolicy
+
+	refreshWaitTimeout time.Duration
+	refreshSem         chan struct{}
+
+	purgeBatchSize  int
+	purgeTimeBudget time.Duration
+	lazyExpiration  bool
+	expiryPerWrite  int
+
+	earlyRefreshBeta float64
+
+	logger *cacheLogger
+	tracer Tracer
+
+	purgeLabels   pprof.LabelSet
+	refreshLabels pprof.LabelSet
+
+	keyCodec   Codec[Key]
+	valueCodec Codec[Value]
 
-	items *list.List
-	index map[Key]*list.Element
+	closeSnapshotPath    string
+	closeSnapshotTimeout time.Duration
+
+	// spill keeps the evicted entries (WithSpill), spilled are the markers of the entries written to it.
+	// The operations of the store are serialized by spillMtx, spillGen numbers the spilled entries.
+	spill    Store
+	spillMtx sync.Mutex
+	spillGen uint64
+	spilled  map[Key]spillMarker
+
+	// bus publishes the keys written or deleted by the cache (WithInvalidation),
+	// origin is the id of the cache in the messages. invalidating is set under the write lock
+	// while the key of another replica is deleted or a restored entry is stored, so it is not published.
+	bus          Bus
+	origin       string
+	invalidating bool
+
+	// wal is the write-ahead log opened by OpenWAL, it is written under the write lock.
+	wal     *os.File
+	walPath string
+
+	auditLog *auditRing[Key]
+	// auditReason is the reason of the running mutation, it is set under the write lock.
+	auditReason string
+
+	watchers map[Key]map[*keyWatcher[Value]]struct{}
+
+	eventsBuffer int
+	eventsOnce   sync.Once
+	events       atomic.Pointer[eventStream[Key, Value]]
+
+	// callbacks are collected under the write lock and called after it is released.
+	callbacks []func()
+
+	// ctx is cancelled by Close to stop the background work tracked by work.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	work     sync.WaitGroup
+	closeMtx sync.RWMutex
+	closed   atomic.Bool
+
+	unregisterPurge func()
 }
 
-func New[Key comparable, Value any](
-	ttl time.Duration,
-	mtr Metrics,
-) *Cache[Key, Value] {
-	return &Cache[Key, Value]{
-		ttl: ttl,
-		mtr: mtr,
+func New[Key comparable, Value any](opts ...Option) *Cache[Key, Value] {
+	o := newOptions(opts)
+
+	var weigher Weigher[Key, Value]
+	if o.weigher != nil {
+		var ok bool
+		if weigher, ok = o.weigher.(Weigher[Key, Value]); !ok {
+			panic(fmt.Sprintf("locache: weigher type %T does not match cache types", o.weigher))
+		}
+	}
+
+	var onEvict func(Key, Value, RemovalReason)
+	if o.onEvict != nil {
+		var ok bool
+		if onEvict, ok = o.onEvict.(func(Key, Value, RemovalReason)); !ok {
+			panic(fmt.Sprintf("locache: on evict type %T does not match cache types", o.onEvict))
+		}
+	}
+
+	var onSet func(Key, Value)
+	if o.onSet != nil {
+		var ok bool
+		if onSet, ok = o.onSet.(func(Key, Value)); !ok {
+			panic(fmt.Sprintf("locache: on set type %T does not match cache types", o.onSet))
+		}
+	}
+
+	var onDelete func(Key)
+	if o.onDelete != nil {
+		var ok bool
+		if onDelete, ok = o.onDelete.(func(Key)); !ok {
+			panic(fmt.Sprintf("locache: on delete type %T does not match cache key type", o.onDelete))
+		}
+	}
+
+	var keyCodec Codec[Key] = GobCodec[Key]{}
+	if o.keyCodec != nil {
+		var ok bool
+		if keyCodec, ok = o.keyCodec.(Codec[Key]); !ok {
+			panic(fmt.Sprintf("locache: key codec type %T does not match cache key type", o.keyCodec))
+		}
+	}
+
+	var valueCodec Codec[Value] = GobCodec[Value]{}
+	if o.valueCodec != nil {
+		var ok bool
+		if valueCodec, ok = o.valueCodec.(Codec[Value]); !ok {
+			panic(fmt.Sprintf("locache: codec type %T does not match cache value type", o.valueCodec))
+		}
+	}
+
+	var auditLog *auditRing[Key]
+	if o.auditSize > 0 {
+		auditLog = newAuditRing[Key](o.auditSize)
+	}
+
+	var policy EvictionPolicy[Key]
+	if o.policy != nil {
+		var ok bool
+		if policy, ok = o.policy.(EvictionPolicy[Key]); !ok {
+			panic(fmt.Sprintf("locache: eviction policy type %T does not match cache key type", o.policy))
+		}
+	}
+	limited := o.maxEntries > 0 || o.maxCost > 0
+	builtinPolicy := policy == nil && limited
+
+	// FIFO ignores access, so Get can skip taking the write lock.
+	isFIFO := builtinPolicy && o.evictionMode == FIFO
+
+	var admission *tinyLFU[Key]
+	if (policy != nil || builtinPolicy) && o.tinyLFUSamples > 0 {
+		admission = newTinyLFU[Key](o.tinyLFUSamples)
+	}
+
+	var doorkeeper *doorkeeper[Key]
+	if o.doorkeeperKeys > 0 {
+		doorkeeper = newDoorkeeper[Key](o.doorkeeperKeys, o.doorkeeperWindow, o.clock)
+	}
+
+	var refreshSem chan struct{}
+	if o.maxConcurrentRefreshes > 0 {
+		refreshSem = make(chan struct{}, o.maxConcurrentRefreshes)
+	}
+
+	expiryPerWrite := o.expiryPerWrite
+	if o.lazyExpiration && expiryPerWrite == 0 {
+		expiryPerWrite = lazyExpiryBatch
+	}
+
+	ctx, cancel := context.WithCancel(o.ctx)
+
+	stats := &statsMetrics{Metrics: o.mtr, clock: o.clock}
+
+	c := &Cache[Key, Value]{
+		ttl:   o.ttl,
+		mtr:   stats,
+		clock: o.clock,
+		timed: !isNopMetrics(o.mtr),
+		stats: stats,
+
+		requestClock: requestClock(o.clock),
 
 		items: list.New(),
 		index: make(map[Key]*list.Element),
+
+		maxEntries: o.maxEntries,
+		maxCost:    o.maxCost,
+		weigher:    weigher,
+		onEvict:    onEvict,
+		onSet:      onSet,
+		onDelete:   onDelete,
+		policy:     policy,
+		admission:  admission,
+		doorkeeper: doorkeeper,
+
+		customPolicy: policy != nil,
+		fifo:         isFIFO,
+		trackAccess:  (policy != nil || builtinPolicy) && !isFIFO,
+		sliding:      o.sliding,
+		maxIdle:      o.maxIdle,
+		errorTTL:     o.errorTTL,
+
+		refreshTimeout: o.refreshTimeout,
+		retryPolicy:    o.retryPolicy,
+
+		refreshWaitTimeout: o.refreshWaitTimeout,
+		refreshSem:         refreshSem,
+
+		purgeBatchSize:  o.purgeBatchSize,
+		purgeTimeBudget: o.purgeTimeBudget,
+		lazyExpiration:  o.lazyExpiration,
+		expiryPerWrite:  expiryPerWrite,
+
+		earlyRefreshBeta: o.earlyRefreshBeta,
+
+		eventsBuffer: o.eventsBuffer,
+
+		auditLog: auditLog,
+
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+
+		closeSnapshotPath:    o.closeSnapshotPath,
+		closeSnapshotTimeout: o.closeSnapshotTimeout,
+
+		spill: o.spill,
+		bus:   o.bus,
+
+		logger: newCacheLogger(o.logger, o.loggerOpts),
+		tracer: o.tracer,
+
+		purgeLabels:   profilerLabels(o.name, MethodPurge),
+		refreshLabels: profilerLabels(o.name, MethodGetOrRefresh),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if builtinPolicy {
+		c.policy = newItemsPolicy(c, o.evictionMode)
+	}
+	if o.purgeInterval > 0 {
+		c.SchedulePurge(o.ctx, o.purgeInterval)
 	}
+	if o.purgeScheduler != nil {
+		c.unregisterPurge = o.purgeScheduler.Register(c)
+	}
+	if c.bus != nil {
+		c.origin = newOrigin()
+		c.subscribeInvalidations()
+	}
+	return c
 }
 
 func (c *Cache[Key, Value]) Get(key Key) (Value, bool) {
-	startTime := now()
-	defer c.mtr.ObserveRequest(MethodGet, startTime)
+	val, _, ok := c.get(key)
+	return val, ok
+}
+
+// GetWithExpiry works like Get, but also returns the expiration time of the entry.
+// The zero time means the entry never expires.
+func (c *Cache[Key, Value]) GetWithExpiry(key Key) (Value, time.Time, bool) {
+	return c.get(key)
+}
+
+// TTL returns the remaining lifetime of a valid entry without affecting
+// the eviction policy or hit statistics. Zero means the entry never expires.
+func (c *Cache[Key, Value]) TTL(key Key) (time.Duration, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodTTL, c.requestClock.Now())
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	element, found := c.index[key]
+	if !found {
+		return 0, false
+	}
+
+	item := c.getItem(element)
+	if !item.IsValid(c.clock.Now()) {
+		return 0, false
+	}
+
+	exp := item.ExpiresAt()
+	if exp.IsZero() {
+		return 0, true
+	}
+	return exp.Sub(c.clock.Now()), true
+}
+
+// GetStale works like Get, but also returns values of expired entries
+// which are not purged yet. The second result reports whether the value is expired.
+func (c *Cache[Key, Value]) GetStale(key Key) (Value, bool, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodGetStale, c.requestClock.Now())
+	}
 
 	var val Value
 
+	c.mtx.RLock()
+	element, found := c.index[key]
+	if !found || !c.getItem(element).set {
+		c.mtx.RUnlock()
+		c.mtr.IncMisses(MethodGetStale)
+		return val, false, false
+	}
+
+	item := c.getItem(element)
+	val, exp, expired := item.val, item.ExpiresAt(), item.IsExpired(c.clock.Now())
+	c.mtx.RUnlock()
+
+	if expired {
+		c.mtr.IncMisses(MethodGetStale)
+		return val, true, true
+	}
+
+	c.onHit(key, element, exp)
+	c.mtr.IncHits(MethodGetStale)
+	return val, false, true
+}
+
+// Peek returns the value of a valid entry without affecting the eviction policy,
+// the expiration of the entry or the metrics.
+func (c *Cache[Key, Value]) Peek(key Key) (Value, bool) {
 	c.mtx.RLock()
 	defer c.mtx.RUnlock()
 
+	var val Value
+
 	element, found := c.index[key]
 	if !found {
-		c.mtr.IncMisses(MethodGet)
 		return val, false
 	}
 
-	if item := c.getItem(element); item.IsValid() {
-		c.mtr.IncHits(MethodGet)
-		return item.val, true
+	item := c.getItem(element)
+	if !item.IsValid(c.clock.Now()) {
+		return val, false
+	}
+	return item.val, true
+}
+
+func (c *Cache[Key, Value]) get(key Key) (Value, time.Time, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodGet, c.requestClock.Now())
+	}
+
+	var val Value
+
+	if c.admission != nil {
+		c.admission.Record(key)
 	}
 
-	c.mtr.IncMisses(MethodGet)
-	return val, false
+	c.mtx.RLock()
+	element, found := c.index[key]
+	if !found {
+		c.mtx.RUnlock()
+
+		if c.spill != nil {
+			if val, exp, ok := c.promote(key); ok {
+				c.mtr.IncHits(MethodGet)
+				return val, exp, true
+			}
+		}
+
+		c.mtr.IncMisses(MethodGet)
+		return val, time.Time{}, false
+	}
+
+	item := c.getItem(element)
+	if !item.IsValid(c.clock.Now()) {
+		c.mtx.RUnlock()
+		c.mtr.IncMisses(MethodGet)
+
+		if c.lazyExpiration {
+			c.expireLazily(key, element)
+		}
+		return val, time.Time{}, false
+	}
+
+	val, exp := item.val, item.ExpiresAt()
+	c.mtx.RUnlock()
+
+	exp = c.onHit(key, element, exp)
+
+	c.mtr.IncHits(MethodGet)
+	return val, exp, true
 }
 
 func (c *Cache[Key, Value]) Set(key Key, value Value) {
-	startTime := now()
-	defer c.mtr.ObserveRequest(MethodSet, startTime)
+	c.set(key, value, c.ttl, "", true)
+}
+
+// SetWithTTL stores the value with its own TTL instead of the cache default.
+// A ttl <= 0 means the entry never expires.
+func (c *Cache[Key, Value]) SetWithTTL(key Key, value Value, ttl time.Duration) {
+	c.set(key, value, ttl, "", true)
+}
+
+// SetForever stores the value which never expires, regardless of the cache TTL.
+func (c *Cache[Key, Value]) SetForever(key Key, value Value) {
+	c.set(key, value, 0, "", true)
+}
+
+// set stores the value, publish is false for the writes restored from a snapshot,
+// an export or the write-ahead log which other replicas don't have to invalidate.
+func (c *Cache[Key, Value]) set(key Key, value Value, ttl time.Duration, reason string, publish bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodSet, c.requestClock.Now())
+	}
+
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+
+	cost := c.weigh(key, value)
 
 	c.mtx.Lock()
-	defer c.mtx.Unlock()
+	defer c.unlock()
 
-	if element, found := c.index[key]; found {
-		item := c.getItem(element)
-		item.set = true
-		item.val = value
-		item.exp = now().Add(c.ttl)
+	c.auditReason, c.invalidating = reason, !publish
+	c.store(MethodSet, key, value, cost, ttl)
+	c.auditReason, c.invalidating = "", false
 
-		c.items.MoveToBack(element)
-		return
+	if c.expiryPerWrite > 0 {
+		c.removeExpired(c.expiryPerWrite)
+	}
+}
+
+// store sets the value of the key under the write lock.
+// It reports whether the value was stored or rejected by the admission.
+func (c *Cache[Key, Value]) store(method string, key Key, value Value, cost int64, ttl time.Duration) bool {
+	if c.closed.Load() {
+		return false
 	}
 
-	c.index[key] = c.items.PushBack(&Item[Key, Value]{
-		set: true,
-		key: key,
-		val: value,
-		exp: now().Add(c.ttl),
-	})
+	element, found := c.index[key]
+	if !found {
+		if !c.allowedByDoorkeeper(key) || !c.admit(key, 1, cost) {
+			return false
+		}
+		element = c.items.PushBack(&Item[Key, Value]{mtx: newItemLock(), key: key})
+		c.index[key] = element
+	}
+
+	c.setItemValue(method, element, value, cost, ttl)
+	return true
+}
+
+// fill stores the value read by Get from another level, the spill store or L2 of Tiered,
+// with the cache TTL. The read is not a write: it is not published to the invalidation bus
+// and doesn't fire OnSet, the events and the audit.
+func (c *Cache[Key, Value]) fill(key Key, value Value) {
+	cost := c.weigh(key, value)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	c.store(MethodGet, key, value, cost, c.ttl)
 }
 
 func (c *Cache[Key, Value]) Del(key Key) {
-	startTime := now()
-	defer c.mtr.ObserveRequest(MethodDel, startTime)
+	c.del(key, "", true)
+}
+
+// del deletes the key, publish is false for the deletions replayed from the write-ahead log.
+func (c *Cache[Key, Value]) del(key Key, reason string, publish bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodDel, c.requestClock.Now())
+	}
 
 	c.mtx.Lock()
-	defer c.mtx.Unlock()
+	defer c.unlock()
 
 	if element, found := c.index[key]; found {
-		c.items.Remove(element)
-		delete(c.index, key)
+		c.auditReason = reason
+		c.deleteElement(element)
+		c.auditReason = ""
+		c.incRemoved(MethodDel)
+	}
+	c.unspill(key)
+	// Other replicas may have the key missing here.
+	if publish {
+		c.publishInvalidation(key)
+	}
+
+	if c.expiryPerWrite > 0 {
+		c.removeExpired(c.expiryPerWrite)
+	}
+}
+
+// DelFunc deletes the valid entries matched by fn and returns their number, e.g. the keys
+// with a prefix. fn is called under the write lock and must not call the cache.
+func (c *Cache[Key, Value]) DelFunc(fn func(key Key, value Value) bool) int {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodDel, c.requestClock.Now())
+	}
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	deleted := 0
+	for element := c.items.Front(); element != nil; {
+		next := element.Next()
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) && fn(item.key, item.val) {
+			key := item.key
+			c.deleteElement(element)
+			c.incRemoved(MethodDel)
+			c.publishInvalidation(key)
+			deleted++
+		}
+		element = next
+	}
+	return deleted
+}
+
+// Touch resets the expiration of a valid entry to the default TTL from now
+// without rewriting the value. It reports whether the entry was found.
+func (c *Cache[Key, Value]) Touch(key Key) bool {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodTouch, c.requestClock.Now())
+	}
+
+	return c.setExpiration(key, c.ttl, c.expiration(c.ttl))
+}
+
+// Expire sets the remaining lifetime of a valid entry without rewriting the value.
+// A d <= 0 expires the entry immediately. It reports whether the entry was found.
+func (c *Cache[Key, Value]) Expire(key Key, d time.Duration) bool {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodExpire, c.requestClock.Now())
 	}
+
+	return c.setExpiration(key, d, c.clock.Now().Add(d))
 }
 
-func (c *Cache[Key, Value]) getOrCreateElement(key Key) *list.Element {
+func (c *Cache[Key, Value]) setExpiration(key Key, ttl time.Duration, exp time.Time) bool {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
 	element, found := c.index[key]
 	if !found {
-		element = c.items.PushBack(&Item[Key, Value]{
-			key: key,
-			exp: now().Add(c.ttl),
-		})
-		c.index[key] = element
+		return false
 	}
 
-	return element
+	item := c.getItem(element)
+	if !item.IsValid(c.clock.Now()) {
+		return false
+	}
+
+	item.exp = exp
+	item.ttl = ttl
+	c.expiries.update(item)
+	c.logSet(item)
+	return true
 }
 
-func (c *Cache[Key, Value]) GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error) {
-	startTime := now()
-	defer c.mtr.ObserveRequest(MethodGetOrRefresh, startTime)
+// OnExpire registers the callback called when the current value of the key leaves
+// the cache: it expires and is purged, is deleted, evicted or replaced.
+// The callback is called outside the cache lock. It reports whether a valid entry was found.
+func (c *Cache[Key, Value]) OnExpire(key Key, callback func(Key, Value)) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
 
-	element := c.getOrCreateElement(key)
+	element, found := c.index[key]
+	if !found {
+		return false
+	}
 
 	item := c.getItem(element)
-	item.mtx.Lock()
+	if !item.IsValid(c.clock.Now()) {
+		return false
+	}
 
-	if item.IsValid() {
-		c.mtr.IncHits(MethodGetOrRefresh)
+	item.onExpire = callback
+	return true
+}
 
-		val := item.val
-		item.mtx.Unlock()
+// Len returns the number of entries in the cache,
+// including expired entries which are not purged yet.
+func (c *Cache[Key, Value]) Len() int {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.items.Len()
+}
 
-		return val, nil
+// Keys returns a snapshot of the keys of valid entries.
+func (c *Cache[Key, Value]) Keys() []Key {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	keys := make([]Key, 0, c.items.Len())
+	for element := c.items.Front(); element != nil; element = element.Next() {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			keys = append(keys, item.key)
+		}
 	}
+	return keys
+}
 
-	val, err := refresh()
-	if err != nil {
-		c.mtr.IncErrors(MethodGetOrRefresh)
-		item.mtx.Unlock()
+// Range calls fn for each valid entry until fn returns false.
+// It iterates over a snapshot, so fn may safely call other cache methods.
+func (c *Cache[Key, Value]) Range(fn func(Key, Value) bool) {
+	c.mtx.RLock()
+	items := make([]Item[Key, Value], 0, c.items.Len())
+	for element := c.items.Front(); element != nil; element = element.Next() {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			items = append(items, Item[Key, Value]{key: item.key, val: item.val})
+		}
+	}
+	c.mtx.RUnlock()
 
-		var emptyVal Value
-		return emptyVal, fmt.Errorf("refresh val: %w", err)
+	for i := range items {
+		if !fn(items[i].key, items[i].val) {
+			return
+		}
 	}
+}
 
-	item.set = true
-	item.val = val
-	item.exp = now().Add(c.ttl)
-	item.mtx.Unlock()
+// Clear removes all entries from the cache. The storage is swapped under the write lock,
+// so Clear blocks other operations for O(1) time, and the OnExpire and OnEvict callbacks
+// of the removed entries are called after the lock is released.
+// The entries of a custom eviction policy (WithEvictionPolicy) are removed one by one,
+// as the policy can't be recreated.
+func (c *Cache[Key, Value]) Clear() {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodClear, c.requestClock.Now())
+	}
 
 	c.mtx.Lock()
-	c.items.MoveToBack(element)
-	c.mtx.Unlock()
+	c.logClear()
+	c.clearSpilled()
 
-	return val, nil
-}
+	if c.customPolicy {
+		for element := c.items.Front(); element != nil; {
+			remove := element
+			element = element.Next()
+			c.removeElement(remove, RemovalManual)
+		}
 
-func (c *Cache[Key, Value]) SchedulePurge(ctx context.Context, purgeInterval time.Duration) chan struct{} {
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(purgeInterval):
-				c.Purge()
-			}
+		c.setItemsCount()
+		c.unlock()
+		return
+	}
+
+	// The detached items are not reachable through the index anymore,
+	// so nobody changes them after the swap.
+	items, index := c.items, c.index
+	c.items = list.New()
+	c.index = make(map[Key]*list.Element)
+	c.expiries = nil
+	c.cost = 0
+
+	var emptyVal Value
+	for key := range c.watchers {
+		if element, found := index[key]; found && c.getItem(element).set {
+			c.notifyWatchers(key, emptyVal, true)
+		}
+	}
+
+	c.setItemsCount()
+	c.unlock()
+
+	for element := items.Front(); element != nil; element = element.Next() {
+		item := c.getItem(element)
+		if !item.set {
+			continue
+		}
+		if item.onExpire != nil {
+			item.onExpire(item.key, item.val)
 		}
-	}()
-	return done
+		if c.onEvict != nil {
+			c.onEvict(item.key, item.val, RemovalManual)
+		}
+	}
 }
 
+// SchedulePurge purges expired entries every purgeInterval until ctx is done,
+// the returned stop function is called or the cache is closed.
+// The stop function waits for the running purge to finish.
+func (c *Cache[Key, Value]) SchedulePurge(ctx context.Context, purgeInterval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := c.schedule(ctx, purgeInterval, c.Purge)
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Purge removes expired entries in batches limited by WithPurgeBatchSize
+// and WithPurgeTimeBudget, the write lock is released between batches.
 func (c *Cache[Key, Value]) Purge() {
-	startTime := now()
+	_ = c.PurgeContext(context.Background())
+}
+
+// PurgeContext works like Purge, but stops between batches when the context is done
+// and returns the context error. The remaining expired entries are left to the next purge.
+// The purge runs with the pprof labels of the cache.
+func (c *Cache[Key, Value]) PurgeContext(ctx context.Context) (err error) {
+	pprof.Do(ctx, c.purgeLabels, func(ctx context.Context) {
+		err = c.purge(ctx)
+	})
+	return err
+}
+
+func (c *Cache[Key, Value]) purge(ctx context.Context) error {
+	startTime := c.requestClock.Now()
 	defer c.mtr.ObserveRequest(MethodPurge, startTime)
 
+	// Items locked by a refresh are skipped and returned to the heap after the pass.
+	var locked []*Item[Key, Value]
+	removed := 0
+
+	var err error
+	for more := true; more; {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		more = c.purgeBatch(&locked, &removed)
+	}
+
+	duration := c.requestClock.Now().Sub(startTime)
+	if mtr, ok := c.mtr.(PurgeMetrics); ok {
+		mtr.ObservePurge(removed+len(locked), removed, duration)
+	}
+	c.logger.purged(removed+len(locked), removed, duration)
+	c.incExpired(removed)
+
 	c.mtx.Lock()
-	defer c.mtx.Unlock()
+	defer c.unlock()
 
-	for element := c.items.Front(); element != nil; {
-		item := c.getItem(element)
-		if !item.mtx.TryLock() {
-			element = element.Next()
+	for _, item := range locked {
+		if element, found := c.index[item.key]; found && c.getItem(element) == item {
+			c.expiries.update(item)
+		}
+	}
+
+	c.setItemsCount()
+	return err
+}
+
+// purgeBatch removes expired entries under one write lock until the batch limits
+// are reached. It reports whether expired entries may remain.
+func (c *Cache[Key, Value]) purgeBatch(locked *[]*Item[Key, Value], removed *int) bool {
+	c.mtx.Lock()
+	defer c.unlock()
+
+	batchStart := c.requestClock.Now()
+	for processed := 0; c.purgeBatchSize <= 0 || processed < c.purgeBatchSize; processed++ {
+		// At least one entry is processed per batch to make progress.
+		if processed > 0 && c.purgeTimeBudget > 0 && c.requestClock.Now().Sub(batchStart) >= c.purgeTimeBudget {
+			return true
+		}
+
+		item, ok := c.expiries.popExpired(c.clock.Now())
+		if !ok {
+			return false
+		}
+		element, found := c.index[item.key]
+		if !found || c.getItem(element) != item {
 			continue
 		}
-		if item.exp.Before(now()) {
-			remove := element
-			element = element.Next()
-			c.items.Remove(remove)
-			delete(c.index, item.key)
-		} else {
-			element = element.Next()
+		if !item.mtx.TryLock() {
+			*locked = append(*locked, item)
+			continue
 		}
+		c.removeElement(element, RemovalExpired)
 		item.mtx.Unlock()
+		*removed++
 	}
+	return true
+}
+
+// setItemValue stores the value into the element which is already in the index,
+// evicting other entries when the new cost does not fit into the cache.
+// The element is moved to the back of the items unless the order is FIFO.
+func (c *Cache[Key, Value]) setItemValue(
+	method string,
+	element *list.Element,
+	value Value,
+	cost int64,
+	ttl time.Duration,
+) {
+	item := c.getItem(element)
+	if !c.fifo {
+		c.items.MoveToBack(element)
+	}
+
+	c.cost -= item.cost
+	item.cost = 0
+
+	c.expireItem(item, RemovalReplaced)
+
+	if c.policy != nil {
+		// A new key is registered after the eviction,
+		// so policies like MRU don't choose it as a victim.
+		if item.set {
+			c.policy.OnSet(item.key)
+		}
+		c.makeRoom(method, item.key, cost)
+		if !item.set {
+			c.policy.OnSet(item.key)
+		}
+	}
+
+	c.cost += cost
 
+	c.version++
+
+	item.set = true
+	item.err = nil
+	item.val = value
+	item.version = c.version
+	item.exp = c.expiration(ttl)
+	item.idle = c.expiration(c.maxIdle)
+	c.expiries.update(item)
+	item.ttl = ttl
+	item.cost = cost
+
+	c.stored(method, item)
+}
+
+// stored notifies the watchers, the events stream and the OnSet hook about the stored value,
+// it must be called under the write lock.
+func (c *Cache[Key, Value]) stored(method string, item *Item[Key, Value]) {
+	c.logSet(item)
+	c.unspill(item.key)
+	c.notifyWatchers(item.key, item.val, false)
+
+	// The values read from another level by Get (fill) are not writes.
+	if method == MethodGet {
+		return
+	}
+	if method != MethodGetOrRefresh {
+		c.publishInvalidation(item.key)
+	}
+
+	if method == MethodGetOrRefresh {
+		c.emit(ChangeRefresh, item.key, item.val)
+		c.audit(ChangeRefresh, item.key, c.auditReason)
+	} else {
+		c.emit(ChangeSet, item.key, item.val)
+		c.audit(ChangeSet, item.key, c.auditReason)
+	}
+
+	if onSet := c.onSet; onSet != nil {
+		key, val := item.key, item.val
+		c.callbacks = append(c.callbacks, func() { onSet(key, val) })
+	}
+}
+
+// onHit updates the eviction policy, extends the sliding expiration and the idle
+// timeout of the hit entry. It returns the expiration time of the entry.
+func (c *Cache[Key, Value]) onHit(key Key, element *list.Element, exp time.Time) time.Time {
+	if !c.trackAccess && !c.sliding && c.maxIdle <= 0 {
+		return exp
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.index[key] != element {
+		return exp
+	}
+	return c.hitLocked(key, element)
+}
+
+// hitLocked is onHit for callers holding the write lock.
+func (c *Cache[Key, Value]) hitLocked(key Key, element *list.Element) time.Time {
+	item := c.getItem(element)
+	if c.trackAccess {
+		c.policy.OnGet(key)
+	}
+
+	if item.IsValid(c.clock.Now()) {
+		if c.sliding {
+			item.exp = c.expiration(item.ttl)
+		}
+		item.idle = c.expiration(c.maxIdle)
+		c.expiries.update(item)
+	}
+	return item.ExpiresAt()
+}
+
+func (c *Cache[Key, Value]) weigh(key Key, value Value) int64 {
+	if c.weigher == nil {
+		return 1
+	}
+	return c.weigher(key, value)
+}
+
+// admit reports whether a new entry for the key should be stored. When storing it
+// requires an eviction, the admission filter compares the key with the victim.
+func (c *Cache[Key, Value]) admit(key Key, entries int, cost int64) bool {
+	if c.admission == nil || !c.overflows(entries, cost) {
+		return true
+	}
+
+	victim, ok := c.policy.Victim()
+	if !ok {
+		return true
+	}
+	return c.admission.Admit(key, victim)
+}
+
+func (c *Cache[Key, Value]) allowedByDoorkeeper(key Key) bool {
+	return c.doorkeeper == nil || c.doorkeeper.Allow(key)
+}
+
+func (c *Cache[Key, Value]) overflows(entries int, cost int64) bool {
+	return (c.maxEntries > 0 && c.items.Len()+entries > c.maxEntries) ||
+		(c.maxCost > 0 && c.cost+cost > c.maxCost)
+}
+
+// removePlaceholder removes the element created for the key if it still has no value.
+func (c *Cache[Key, Value]) removePlaceholder(key Key, element *list.Element) {
+	c.mtx.Lock()
+	defer c.unlock()
+
+	if c.index[key] == element && !c.getItem(element).set {
+		c.removeElement(element, RemovalManual)
+	}
+}
+
+// removeElement removes the entry, the reason is passed to the OnEvict callback.
+func (c *Cache[Key, Value]) removeElement(element *list.Element, reason RemovalReason) {
+	item := c.getItem(element)
+
+	c.items.Remove(element)
+	delete(c.index, item.key)
+	c.expiries.remove(item)
+	c.cost -= item.cost
+	c.expireItem(item, reason)
+
+	if item.set {
+		var emptyVal Value
+		c.notifyWatchers(item.key, emptyVal, true)
+	}
+	if reason == RemovalExpired && item.set {
+		c.emit(ChangeExpire, item.key, item.val)
+		c.audit(ChangeExpire, item.key, reason.String())
+	}
+	if reason == RemovalCapacity && item.set {
+		c.audit(ChangeDelete, item.key, reason.String())
+		if c.spill != nil {
+			c.spillItem(item)
+		}
+	}
+
+	if c.policy != nil {
+		c.policy.OnDelete(item.key)
+	}
+}
+
+// deleteElement removes the entry deleted explicitly and schedules the OnDelete callback.
+func (c *Cache[Key, Value]) deleteElement(element *list.Element) {
+	item := c.getItem(element)
+	c.removeElement(element, RemovalManual)
+
+	if !item.set {
+		return
+	}
+
+	c.logDel(item.key)
+	c.emit(ChangeDelete, item.key, item.val)
+	c.audit(ChangeDelete, item.key, c.auditReason)
+	if onDelete := c.onDelete; onDelete != nil {
+		key := item.key
+		c.callbacks = append(c.callbacks, func() { onDelete(key) })
+	}
+}
+
+// makeRoom evicts entries chosen by the eviction policy until the given cost
+// fits into the cache limits. It stops when the policy chooses the kept key.
+func (c *Cache[Key, Value]) makeRoom(method string, keep Key, cost int64) {
+	for c.overflows(0, cost) {
+		key, ok := c.policy.Victim()
+		if !ok || key == keep {
+			return
+		}
+
+		element, found := c.index[key]
+		if !found {
+			c.policy.OnDelete(key)
+			continue
+		}
+
+		c.removeElement(element, RemovalCapacity)
+		c.mtr.IncEvictions(method)
+		c.logEviction(key, method)
+	}
+}
+
+// logEviction schedules the eviction log, it must be called under the write lock.
+func (c *Cache[Key, Value]) logEviction(key Key, method string) {
+	if logger := c.logger; logger != nil && logger.enabled(logger.evictionLevel) {
+		c.callbacks = append(c.callbacks, func() { logger.evicted(key, method) })
+	}
+}
+
+// setItemsCount reports the accumulated cost and the items count, it must be called under the lock.
+func (c *Cache[Key, Value]) setItemsCount() {
+	if mtr, ok := c.mtr.(CostMetrics); ok {
+		mtr.SetCost(c.cost)
+	}
 	c.mtr.SetItemsCount(c.items.Len())
 }
 
+func (c *Cache[Key, Value]) incExpired(count int) {
+	if mtr, ok := c.mtr.(RemovalMetrics); ok && count > 0 {
+		mtr.IncExpired(count)
+	}
+}
+
+func (c *Cache[Key, Value]) incRemoved(method string) {
+	if mtr, ok := c.mtr.(RemovalMetrics); ok {
+		mtr.IncRemoved(method)
+	}
+}
+
+// expireItem schedules the expiration and the eviction callbacks of the item value, if any.
+func (c *Cache[Key, Value]) expireItem(item *Item[Key, Value], reason RemovalReason) {
+	if !item.set {
+		return
+	}
+
+	key, val := item.key, item.val
+	if callback := item.onExpire; callback != nil {
+		item.onExpire = nil
+		c.callbacks = append(c.callbacks, func() { callback(key, val) })
+	}
+	if onEvict := c.onEvict; onEvict != nil {
+		c.callbacks = append(c.callbacks, func() { onEvict(key, val, reason) })
+	}
+}
+
+// unlock releases the write lock and calls the collected callbacks.
+func (c *Cache[Key, Value]) unlock() {
+	callbacks := c.callbacks
+	c.callbacks = nil
+	c.mtx.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// expiration returns the expiration time for the ttl,
+// the zero time means the entry never expires.
+func (c *Cache[Key, Value]) expiration(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return c.clock.Now().Add(ttl)
+}
+
 func (c *Cache[Key, Value]) getItem(element *list.Element) *Item[Key, Value] {
 	return element.Value.(*Item[Key, Value]) //nolint:forcetypeassert
 }
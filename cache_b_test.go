@@ -68,8 +68,8 @@ func BenchmarkCache_Get(b *testing.B) {
 			}
 
 			ctx, cancel := context.WithCancel(context.Background())
-			cache := New[string, string](tc.itemsTTL, NewNopMetrics())
-			purgeDone := cache.SchedulePurge(ctx, tc.purgeInterval)
+			cache := New[string, string](WithTTL(tc.itemsTTL))
+			stopPurge := cache.SchedulePurge(ctx, tc.purgeInterval)
 
 			schedule(ctx, tc.readInterval, func() { cache.Get(bullets[rand.Intn(tc.bulletsCount)]) })
 			schedule(ctx, tc.writeInterval, func() { cache.Set(bullets[rand.Intn(tc.bulletsCount)], fmt.Sprintf("val %d", time.Now().UnixNano())) })
@@ -80,7 +80,7 @@ func BenchmarkCache_Get(b *testing.B) {
 			}
 
 			cancel()
-			<-purgeDone
+			stopPurge()
 		})
 	}
 }
@@ -144,8 +144,8 @@ func BenchmarkCache_GetOrRefresh(b *testing.B) {
 			}
 
 			ctx, cancel := context.WithCancel(context.Background())
-			cache := New[string, string](tc.itemsTTL, NewNopMetrics())
-			purgeDone := cache.SchedulePurge(ctx, tc.purgeInterval)
+			cache := New[string, string](WithTTL(tc.itemsTTL))
+			stopPurge := cache.SchedulePurge(ctx, tc.purgeInterval)
 
 			schedule(ctx, tc.readInterval, func() { cache.Get(bullets[rand.Intn(tc.bulletsCount)]) })
 			schedule(ctx, tc.writeInterval, func() { cache.Set(bullets[rand.Intn(tc.bulletsCount)], fmt.Sprintf("val %d", time.Now().UnixNano())) })
@@ -160,7 +160,41 @@ func BenchmarkCache_GetOrRefresh(b *testing.B) {
 			}
 
 			cancel()
-			<-purgeDone
+			stopPurge()
+		})
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	const keysCount = 10_000
+
+	keys := make([]string, 0, keysCount)
+	for i := 0; i < keysCount; i++ {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+	}
+
+	benchCases := map[string]Cacher[string, string]{
+		"cache":   New[string, string](WithTTL(time.Minute)),
+		"sharded": NewSharded[string, string](0, WithTTL(time.Minute)),
+
+		"read_mostly": NewReadMostly[string, string](WithTTL(time.Minute)),
+		"dense":       NewDense[string, string](WithTTL(time.Minute)),
+	}
+
+	for name, cache := range benchCases {
+		cache := cache
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				rnd := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+				for pb.Next() {
+					key := keys[rnd.Intn(keysCount)]
+					if rnd.Intn(10) == 0 {
+						cache.Set(key, key)
+					} else {
+						cache.Get(key)
+					}
+				}
+			})
 		})
 	}
 }
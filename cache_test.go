@@ -29,7 +29,7 @@ func requireKeyNotExists(t *testing.T, cache *testCache, key string) {
 }
 
 func TestCache_Get_KeyNotExists(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	requireKeyNotExists(t, cache, "key0")
 }
 
@@ -43,7 +43,7 @@ func requireCacheItems(t *testing.T, cache *testCache, expected []string) {
 }
 
 func TestCache_Get_KeyExists(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	cache.Set("key0", "value0")
 	cache.Set("key1", "value1")
 	cache.Set("key2", "value2")
@@ -54,13 +54,13 @@ func TestCache_Get_KeyExists(t *testing.T) {
 }
 
 func TestCache_Set_KeyNotExists(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	cache.Set("key0", "value0")
 	requireKeyExists(t, cache, "key0", "value0")
 }
 
 func TestCache_Set_KeyExists(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	cache.Set("key0", "value0")
 	cache.Set("key1", "value1")
 	cache.Set("key2", "value2")
@@ -83,13 +83,13 @@ func TestCache_Set_KeyExists(t *testing.T) {
 }
 
 func TestCache_Del_KeyNotExists(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	cache.Del("key0")
 	requireKeyNotExists(t, cache, "key0")
 }
 
 func TestCache_Del_KeyExists(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	cache.Set("key0", "value0")
 	cache.Set("key1", "value1")
 	cache.Set("key2", "value2")
@@ -118,7 +118,7 @@ func TestCache_Del_KeyExists(t *testing.T) {
 
 func TestCache_GetOrRefresh_KeyNotExists(t *testing.T) {
 	calls := atomic.Int32{}
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 
 	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
 		calls.Add(1)
@@ -132,7 +132,7 @@ func TestCache_GetOrRefresh_KeyNotExists(t *testing.T) {
 }
 
 func TestCache_GetOrRefresh_KeyExistsAndValid(t *testing.T) {
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	cache.Set("key0", "value0")
 	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
 		panic("should never be called")
@@ -145,10 +145,11 @@ func TestCache_GetOrRefresh_KeyExistsAndValid(t *testing.T) {
 
 func TestCache_GetOrRefresh_KeyExistsAndNotValid(t *testing.T) {
 	calls := atomic.Int32{}
-	cache := New[string, string](0, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Nanosecond))
 	cache.Set("key0", "value0")
 	// For testing purpose only
 	cache.ttl = time.Second
+	time.Sleep(time.Nanosecond)
 
 	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
 		calls.Add(1)
@@ -165,7 +166,7 @@ func TestCache_GetOrRefresh_RefreshFailed(t *testing.T) {
 	var originErr = fmt.Errorf("some error")
 
 	calls := atomic.Int32{}
-	cache := New[string, string](time.Second, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Second))
 	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
 		calls.Add(1)
 		return "", originErr
@@ -174,13 +175,14 @@ func TestCache_GetOrRefresh_RefreshFailed(t *testing.T) {
 	require.Equal(t, int32(1), calls.Load())
 	require.ErrorIs(t, err, originErr)
 	require.Empty(t, actual)
+	require.Equal(t, 0, cache.Len())
 }
 
 func TestCache_GetOrRefresh_RefreshFailed_Concurrent(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
 	calls := atomic.Int32{}
-	cache := New[string, string](time.Second, NewNopMetrics())
-	done := cache.SchedulePurge(ctx, time.Millisecond)
+	cache := New[string, string](WithTTL(time.Second))
+	stop := cache.SchedulePurge(context.Background(), time.Millisecond)
+	defer stop()
 
 	wg := sync.WaitGroup{}
 	wg.Add(3)
@@ -237,17 +239,13 @@ func TestCache_GetOrRefresh_RefreshFailed_Concurrent(t *testing.T) {
 	mtx1.Unlock()
 	wg.Wait()
 	require.Equal(t, int32(2), calls.Load())
-
-	cancel()
-	<-done
 }
 
 func TestCache_GetOrRefresh_RefreshLongerThanTTL(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-
 	calls := atomic.Int32{}
-	cache := New[string, string](10*time.Millisecond, NewNopMetrics())
-	done := cache.SchedulePurge(ctx, time.Millisecond)
+	cache := New[string, string](WithTTL(10 * time.Millisecond))
+	stop := cache.SchedulePurge(context.Background(), time.Millisecond)
+	defer stop()
 
 	val, err := cache.GetOrRefresh("key0", func() (string, error) {
 		time.Sleep(15 * time.Millisecond)
@@ -258,12 +256,10 @@ func TestCache_GetOrRefresh_RefreshLongerThanTTL(t *testing.T) {
 	require.Equal(t, "value0", val)
 
 	requireKeyExists(t, cache, "key0", "value0")
-	cancel()
-	<-done
 }
 
 func TestCache_Purge_Manually(t *testing.T) {
-	cache := New[string, string](time.Nanosecond, NewNopMetrics())
+	cache := New[string, string](WithTTL(time.Nanosecond))
 	cache.Set("key0", "value0")
 	cache.Set("key1", "value1")
 	cache.Set("key2", "value2")
@@ -292,3 +288,723 @@ func TestCache_Purge_Manually(t *testing.T) {
 	requireKeyExists(t, cache, "key4", "value4")
 	requireCacheItems(t, cache, []string{"value3", "value4"})
 }
+
+func TestCache_Set_MaxEntries(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithMaxEntries(2))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	requireKeyNotExists(t, cache, "key0")
+	requireKeyExists(t, cache, "key1", "value1")
+	requireKeyExists(t, cache, "key2", "value2")
+	requireCacheItems(t, cache, []string{"value1", "value2"})
+}
+
+func TestCache_Get_MaxEntries_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithMaxEntries(2))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	requireKeyExists(t, cache, "key0", "value0")
+
+	cache.Set("key2", "value2")
+	requireKeyNotExists(t, cache, "key1")
+	requireCacheItems(t, cache, []string{"value0", "value2"})
+}
+
+type lifoPolicy struct {
+	keys []string
+}
+
+func (p *lifoPolicy) OnGet(_ string) {}
+
+func (p *lifoPolicy) OnSet(key string) {
+	p.OnDelete(key)
+	p.keys = append(p.keys, key)
+}
+
+func (p *lifoPolicy) OnDelete(key string) {
+	for i, k := range p.keys {
+		if k == key {
+			p.keys = append(p.keys[:i], p.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *lifoPolicy) Victim() (string, bool) {
+	if len(p.keys) == 0 {
+		return "", false
+	}
+	return p.keys[len(p.keys)-1], true
+}
+
+func TestCache_Set_EvictionPolicy(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second),
+		WithMaxEntries(2),
+		WithEvictionPolicy[string](&lifoPolicy{}),
+	)
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	requireKeyNotExists(t, cache, "key1")
+	requireCacheItems(t, cache, []string{"value0", "value2"})
+
+	cache.Set("key0", "updated0")
+	requireCacheItems(t, cache, []string{"value2", "updated0"})
+}
+
+func TestCache_GetOrRefresh_MaxEntries(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithMaxEntries(1))
+	cache.Set("key0", "value0")
+
+	actual, err := cache.GetOrRefresh("key1", func() (string, error) {
+		return "value1", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+
+	requireKeyNotExists(t, cache, "key0")
+	requireCacheItems(t, cache, []string{"value1"})
+}
+
+func TestCache_Set_MaxCost(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second),
+		WithMaxCost(10),
+		WithWeigher(func(_ string, value string) int64 {
+			return int64(len(value))
+		}),
+	)
+	cache.Set("key0", "aaaa")
+	cache.Set("key1", "bbbb")
+	requireCacheItems(t, cache, []string{"aaaa", "bbbb"})
+
+	cache.Set("key2", "cccc")
+	requireKeyNotExists(t, cache, "key0")
+	requireCacheItems(t, cache, []string{"bbbb", "cccc"})
+
+	cache.Set("key1", "b")
+	cache.Set("key3", "ddddd")
+	requireCacheItems(t, cache, []string{"cccc", "b", "ddddd"})
+	require.Equal(t, int64(10), cache.cost)
+
+	cache.Del("key2")
+	require.Equal(t, int64(6), cache.cost)
+}
+
+func TestNew_WeigherTypeMismatch(t *testing.T) {
+	require.Panics(t, func() {
+		New[string, string](WithTTL(time.Second), WithWeigher(func(_ int, _ string) int64 {
+			return 1
+		}))
+	})
+}
+
+func TestCache_SetWithTTL(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second))
+	cache.SetWithTTL("key0", "value0", time.Nanosecond)
+	cache.SetWithTTL("key1", "value1", time.Minute)
+	cache.Set("key2", "value2")
+
+	time.Sleep(time.Nanosecond)
+
+	requireKeyNotExists(t, cache, "key0")
+	requireKeyExists(t, cache, "key1", "value1")
+	requireKeyExists(t, cache, "key2", "value2")
+
+	cache.Purge()
+	requireCacheItems(t, cache, []string{"value1", "value2"})
+}
+
+func TestCache_GetOrRefreshWithTTL(t *testing.T) {
+	calls := atomic.Int32{}
+	cache := New[string, string](WithTTL(time.Minute))
+	refresh := func() (string, error) {
+		calls.Add(1)
+		return "value0", nil
+	}
+
+	actual, err := cache.GetOrRefreshWithTTL("key0", time.Nanosecond, refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+
+	time.Sleep(time.Nanosecond)
+	requireKeyNotExists(t, cache, "key0")
+
+	actual, err = cache.GetOrRefreshWithTTL("key0", time.Minute, refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+	require.Equal(t, int32(2), calls.Load())
+
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_SlidingExpiration(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithSlidingExpiration(), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	clock.Advance(40 * time.Second)
+	requireKeyExists(t, cache, "key0", "value0")
+
+	actual, err := cache.GetOrRefresh("key1", func() (string, error) {
+		panic("should never be called")
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+
+	clock.Advance(40 * time.Second)
+	requireKeyExists(t, cache, "key0", "value0")
+	requireKeyExists(t, cache, "key1", "value1")
+
+	clock.Advance(2 * time.Minute)
+	requireKeyNotExists(t, cache, "key0")
+	requireKeyNotExists(t, cache, "key1")
+}
+
+func TestCache_SetForever(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.SetForever("key0", "value0")
+	cache.SetWithTTL("key1", "value1", 0)
+	cache.Set("key2", "value2")
+
+	clock.Advance(time.Hour)
+	cache.Purge()
+
+	requireKeyExists(t, cache, "key0", "value0")
+	requireKeyExists(t, cache, "key1", "value1")
+	requireKeyNotExists(t, cache, "key2")
+}
+
+func TestCache_ZeroTTL_NeverExpires(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithSlidingExpiration(), WithClock(clock))
+	cache.Set("key0", "value0")
+
+	clock.Advance(time.Hour)
+	requireKeyExists(t, cache, "key0", "value0")
+	cache.Purge()
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_Touch(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	clock.Advance(40 * time.Second)
+	require.True(t, cache.Touch("key0"))
+	require.False(t, cache.Touch("unknown"))
+
+	clock.Advance(40 * time.Second)
+	requireKeyExists(t, cache, "key0", "value0")
+	requireKeyNotExists(t, cache, "key1")
+	require.False(t, cache.Touch("key1"))
+}
+
+func TestCache_Expire(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.SetForever("key2", "value2")
+
+	require.True(t, cache.Expire("key0", time.Hour))
+	require.True(t, cache.Expire("key1", 0))
+	require.True(t, cache.Expire("key2", time.Second))
+	require.False(t, cache.Expire("unknown", time.Hour))
+
+	requireKeyNotExists(t, cache, "key1")
+	requireKeyExists(t, cache, "key2", "value2")
+
+	clock.Advance(30 * time.Minute)
+	requireKeyExists(t, cache, "key0", "value0")
+	requireKeyNotExists(t, cache, "key2")
+}
+
+func TestCache_GetWithExpiry(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.SetForever("key1", "value1")
+
+	value, exp, ok := cache.GetWithExpiry("key0")
+	require.True(t, ok)
+	require.Equal(t, "value0", value)
+	require.Equal(t, clock.Now().Add(time.Minute), exp)
+
+	value, exp, ok = cache.GetWithExpiry("key1")
+	require.True(t, ok)
+	require.Equal(t, "value1", value)
+	require.True(t, exp.IsZero())
+
+	_, _, ok = cache.GetWithExpiry("unknown")
+	require.False(t, ok)
+}
+
+func TestCache_TTL(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.SetForever("key1", "value1")
+
+	clock.Advance(20 * time.Second)
+
+	ttl, ok := cache.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 40*time.Second, ttl)
+
+	ttl, ok = cache.TTL("key1")
+	require.True(t, ok)
+	require.Zero(t, ttl)
+
+	clock.Advance(time.Minute)
+	_, ok = cache.TTL("key0")
+	require.False(t, ok)
+
+	_, ok = cache.TTL("unknown")
+	require.False(t, ok)
+}
+
+func TestCache_GetOrRefresh_EarlyRefresh(t *testing.T) {
+	defer func(origin func() float64) { random = origin }(random)
+
+	clock := newFakeClock()
+	random = func() float64 { return 0.5 }
+
+	calls := atomic.Int32{}
+	cache := New[string, string](WithTTL(time.Minute), WithEarlyRefresh(1), WithClock(clock))
+	refresh := func() (string, error) {
+		// The refresh takes 10 seconds.
+		clock.Advance(10 * time.Second)
+		return fmt.Sprintf("value%d", calls.Add(1)), nil
+	}
+
+	actual, err := cache.GetOrRefresh("key0", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+
+	// Far from the expiration: -10s * ln(0.5) is about 7 seconds.
+	clock.Advance(30 * time.Second)
+	actual, err = cache.GetOrRefresh("key0", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+
+	// Close to the expiration.
+	clock.Advance(25 * time.Second)
+	actual, err = cache.GetOrRefresh("key0", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value2", actual)
+}
+
+func TestCache_GetOrRefresh_EarlyRefreshFailed(t *testing.T) {
+	defer func(origin func() float64) { random = origin }(random)
+
+	clock := newFakeClock()
+	random = func() float64 { return 0.5 }
+
+	cache := New[string, string](WithTTL(time.Minute), WithEarlyRefresh(1), WithClock(clock))
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		clock.Advance(10 * time.Second)
+		return "value0", nil
+	})
+	require.NoError(t, err)
+
+	clock.Advance(55 * time.Second)
+	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+		return "", fmt.Errorf("some error")
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+}
+
+func TestCache_MaxIdle(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithMaxIdle(20*time.Second), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	ttl, ok := cache.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 20*time.Second, ttl)
+
+	clock.Advance(15 * time.Second)
+	requireKeyExists(t, cache, "key0", "value0")
+
+	clock.Advance(15 * time.Second)
+	requireKeyExists(t, cache, "key0", "value0")
+	requireKeyNotExists(t, cache, "key1")
+
+	cache.Purge()
+	requireCacheItems(t, cache, []string{"value0"})
+
+	// TTL still applies to the accessed entry.
+	for i := 0; i < 3; i++ {
+		clock.Advance(15 * time.Second)
+		_, _ = cache.Get("key0")
+	}
+	requireKeyNotExists(t, cache, "key0")
+}
+
+type expiredEntry struct {
+	key, value string
+}
+
+func TestCache_OnExpire(t *testing.T) {
+	clock := newFakeClock()
+
+	var expired []expiredEntry
+	callback := func(key, value string) {
+		expired = append(expired, expiredEntry{key: key, value: value})
+	}
+
+	cache := New[string, string](WithTTL(time.Minute), WithMaxEntries(3), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.SetForever("key2", "value2")
+	cache.Set("key3", "value3")
+
+	require.True(t, cache.OnExpire("key1", callback))
+	require.True(t, cache.OnExpire("key2", callback))
+	require.True(t, cache.OnExpire("key3", callback))
+	require.False(t, cache.OnExpire("key0", callback))
+	require.False(t, cache.OnExpire("unknown", callback))
+
+	cache.Del("key2")
+	require.Equal(t, []expiredEntry{{"key2", "value2"}}, expired)
+
+	cache.Set("key3", "updated3")
+	require.Equal(t, []expiredEntry{{"key2", "value2"}, {"key3", "value3"}}, expired)
+
+	clock.Advance(2 * time.Minute)
+	cache.Purge()
+	require.Equal(t, []expiredEntry{{"key2", "value2"}, {"key3", "value3"}, {"key1", "value1"}}, expired)
+	requireCacheItems(t, cache, []string{})
+}
+
+func TestCache_OnExpire_CalledOutsideLock(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+
+	called := false
+	require.True(t, cache.OnExpire("key0", func(key, _ string) {
+		called = true
+		cache.Set(key, "restored")
+	}))
+
+	cache.Del("key0")
+	require.True(t, called)
+	requireKeyExists(t, cache, "key0", "restored")
+}
+
+type evictedEntry struct {
+	key, value string
+	reason     RemovalReason
+}
+
+func TestCache_OnEvict(t *testing.T) {
+	clock := newFakeClock()
+
+	var evicted []evictedEntry
+	cache := New[string, string](WithTTL(time.Minute), WithMaxEntries(3), WithClock(clock), WithOnEvict(
+		func(key, value string, reason RemovalReason) {
+			evicted = append(evicted, evictedEntry{key: key, value: value, reason: reason})
+		},
+	))
+
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.SetForever("key2", "value2")
+
+	cache.Set("key1", "updated1")
+	cache.Del("key2")
+	cache.Set("key3", "value3")
+	cache.Set("key4", "value4")
+	require.Equal(t, []evictedEntry{
+		{"key1", "value1", RemovalReplaced},
+		{"key2", "value2", RemovalManual},
+		{"key0", "value0", RemovalCapacity},
+	}, evicted)
+
+	evicted = nil
+	clock.Advance(2 * time.Minute)
+	cache.Purge()
+	require.ElementsMatch(t, []evictedEntry{
+		{"key1", "updated1", RemovalExpired},
+		{"key3", "value3", RemovalExpired},
+		{"key4", "value4", RemovalExpired},
+	}, evicted)
+
+	evicted = nil
+	cache.Set("key5", "value5")
+	cache.Clear()
+	require.Equal(t, []evictedEntry{{"key5", "value5", RemovalManual}}, evicted)
+}
+
+func TestCache_OnEvict_CalledOutsideLock(t *testing.T) {
+	var cache *Cache[string, string]
+	cache = New[string, string](WithTTL(time.Minute), WithOnEvict(func(key, _ string, reason RemovalReason) {
+		if reason == RemovalManual {
+			cache.Set(key, "restored")
+		}
+	}))
+	cache.Set("key0", "value0")
+
+	cache.Del("key0")
+	requireKeyExists(t, cache, "key0", "restored")
+}
+
+func TestCache_OnSetOnDelete(t *testing.T) {
+	var cache *Cache[string, string]
+	var mutations []string
+
+	cache = New[string, string](
+		WithTTL(time.Minute),
+		WithOnSet(func(key, value string) {
+			// The hooks are called outside the locks, so they may use the cache.
+			stored, ok := cache.Peek(key)
+			require.True(t, ok)
+			require.Equal(t, value, stored)
+			mutations = append(mutations, "set "+key+"="+value)
+		}),
+		WithOnDelete(func(key string) {
+			mutations = append(mutations, "del "+key)
+		}),
+	)
+
+	cache.Set("key0", "value0")
+	_, err := cache.GetOrRefresh("key1", func() (string, error) { return "value1", nil })
+	require.NoError(t, err)
+	_, ok := cache.Update("key0", func(old string, _ bool) (string, bool) { return old + "+", true })
+	require.True(t, ok)
+	cache.Del("key0")
+	cache.Del("unknown")
+	_, ok = cache.Pop("key1")
+	require.True(t, ok)
+
+	_, err = cache.GetOrRefresh("key2", func() (string, error) { return "", fmt.Errorf("some error") })
+	require.Error(t, err)
+
+	require.Equal(t, []string{
+		"set key0=value0",
+		"set key1=value1",
+		"set key0=value0+",
+		"del key0",
+		"del key1",
+	}, mutations)
+}
+
+func TestCache_LenKeysClear(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	require.Equal(t, 0, cache.Len())
+	require.Empty(t, cache.Keys())
+
+	cache.Set("key0", "value0")
+	cache.SetWithTTL("key1", "value1", time.Second)
+	cache.Set("key2", "value2")
+
+	clock.Advance(2 * time.Second)
+	require.Equal(t, 3, cache.Len())
+	require.Equal(t, []string{"key0", "key2"}, cache.Keys())
+
+	expired := 0
+	require.True(t, cache.OnExpire("key0", func(_, _ string) { expired++ }))
+
+	cache.Clear()
+	require.Equal(t, 0, cache.Len())
+	require.Empty(t, cache.Keys())
+	require.Equal(t, 1, expired)
+	requireKeyNotExists(t, cache, "key0")
+
+	cache.Set("key0", "value0")
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_Range(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.SetWithTTL("key1", "value1", time.Second)
+	cache.Set("key2", "value2")
+
+	clock.Advance(2 * time.Second)
+
+	actual := map[string]string{}
+	cache.Range(func(key, value string) bool {
+		actual[key] = value
+		cache.Del(key)
+		return true
+	})
+	require.Equal(t, map[string]string{"key0": "value0", "key2": "value2"}, actual)
+	require.Empty(t, cache.Keys())
+
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	calls := 0
+	cache.Range(func(string, string) bool {
+		calls++
+		return false
+	})
+	require.Equal(t, 1, calls)
+}
+
+func TestCache_Peek(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithMaxEntries(2), WithSlidingExpiration(), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	clock.Advance(30 * time.Second)
+
+	actual, ok := cache.Peek("key0")
+	require.True(t, ok)
+	require.Equal(t, "value0", actual)
+
+	ttl, ok := cache.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, ttl)
+
+	cache.Set("key2", "value2")
+	require.NotContains(t, cache.index, "key0")
+
+	clock.Advance(time.Minute)
+	_, ok = cache.Peek("key1")
+	require.False(t, ok)
+
+	_, ok = cache.Peek("unknown")
+	require.False(t, ok)
+}
+
+func TestCache_GetStale(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+
+	actual, expired, ok := cache.GetStale("key0")
+	require.True(t, ok)
+	require.False(t, expired)
+	require.Equal(t, "value0", actual)
+
+	clock.Advance(2 * time.Minute)
+
+	actual, expired, ok = cache.GetStale("key0")
+	require.True(t, ok)
+	require.True(t, expired)
+	require.Equal(t, "value0", actual)
+	requireKeyNotExists(t, cache, "key0")
+
+	cache.Purge()
+	_, _, ok = cache.GetStale("key0")
+	require.False(t, ok)
+
+	_, _, ok = cache.GetStale("unknown")
+	require.False(t, ok)
+}
+
+func TestNew_Defaults(t *testing.T) {
+	cache := New[string, string]()
+	require.Zero(t, cache.ttl)
+	require.IsType(t, &NopMetrics{}, cache.stats.Metrics)
+
+	cache.Set("key0", "value0")
+	ttl, ok := cache.TTL("key0")
+	require.True(t, ok)
+	require.Zero(t, ttl)
+}
+
+func TestNew_WithPurgeInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache := New[string, string](
+		WithContext(ctx),
+		WithTTL(time.Millisecond),
+		WithPurgeInterval(time.Millisecond),
+	)
+	cache.Set("key0", "value0")
+
+	require.Eventually(t, func() bool { return cache.Len() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestCache_Clear_Swap(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute), WithMaxEntries(2))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	expired := 0
+	require.True(t, cache.OnExpire("key0", func(_, _ string) {
+		// The callback is called outside the lock.
+		cache.Set("key2", "value2")
+		expired++
+	}))
+
+	cache.Clear()
+	require.Equal(t, 1, expired)
+	require.Equal(t, []string{"key2"}, cache.Keys())
+
+	cache.Set("key3", "value3")
+	cache.Set("key4", "value4")
+	require.Equal(t, []string{"key3", "key4"}, cache.Keys())
+}
+
+func TestCache_Clear_DiscardsRefresh(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "value0", actual)
+	}()
+
+	<-started
+	cache.Clear()
+	close(release)
+	<-done
+
+	requireKeyNotExists(t, cache, "key0")
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCache_NopMetrics_NoAllocs(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+
+	refresh := func() (string, error) { return "value0", nil }
+
+	require.Zero(t, testing.AllocsPerRun(100, func() { cache.Get("key0") }))
+	require.Zero(t, testing.AllocsPerRun(100, func() { cache.Set("key0", "value0") }))
+	require.Zero(t, testing.AllocsPerRun(100, func() { _, _ = cache.GetOrRefresh("key0", refresh) }))
+}
+
+func TestIsNopMetrics(t *testing.T) {
+	require.True(t, isNopMetrics(NewNopMetrics()))
+	require.True(t, isNopMetrics(&shardMetrics{Metrics: NewNopMetrics()}))
+	require.False(t, isNopMetrics(NewDefaultMetrics("nop_test")))
+}
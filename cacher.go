@@ -0,0 +1,20 @@
+package locache
+
+// Cacher is the common interface of the cache implementations,
+// consumers can depend on it to swap implementations, e.g. in tests.
+type Cacher[Key comparable, Value any] interface {
+	Get(key Key) (Value, bool)
+	Set(key Key, value Value)
+	Del(key Key)
+	GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error)
+	Purge()
+}
+
+var (
+	_ Cacher[string, any] = (*Cache[string, any])(nil)
+	_ Cacher[string, any] = (*Sharded[string, any])(nil)
+	_ Cacher[string, any] = (*NopCache[string, any])(nil)
+	_ Cacher[string, any] = (*RecordingCache[string, any])(nil)
+	_ Cacher[string, any] = (*ReadMostly[string, any])(nil)
+	_ Cacher[string, any] = (*Dense[string, any])(nil)
+)
@@ -0,0 +1,100 @@
+package locache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time of the cache. It is used for expiration
+// of entries and for scheduling of the background work.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+const defaultCoarseResolution = time.Millisecond
+
+// CoarseClock is a Clock which returns a cached time updated by a ticker,
+// so Now costs an atomic load instead of reading the system time.
+// The time returned by Now lags behind the real time up to the resolution.
+//
+// Passed to WithClock it is used for the expiration of entries and for the request
+// timestamps passed to Metrics.ObserveRequest. DefaultMetrics.WithClock, MetricsV2Adapter.WithClock
+// and StatsDOpts.Clock make the metrics measure the request time with the same clock.
+type CoarseClock struct {
+	nanos  atomic.Int64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewCoarseClock starts a clock updated every resolution, 1ms by default,
+// until the context is done or Stop is called.
+func NewCoarseClock(ctx context.Context, resolution time.Duration) *CoarseClock {
+	return newCoarseClock(ctx, resolution, systemClock{})
+}
+
+// newCoarseClock caches the time of the source clock.
+func newCoarseClock(ctx context.Context, resolution time.Duration, source Clock) *CoarseClock {
+	if resolution <= 0 {
+		resolution = defaultCoarseResolution
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	c := &CoarseClock{cancel: cancel, done: make(chan struct{})}
+	c.nanos.Store(source.Now().UnixNano())
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(resolution)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.nanos.Store(source.Now().UnixNano())
+			}
+		}
+	}()
+
+	return c
+}
+
+func (c *CoarseClock) Now() time.Time {
+	return time.Unix(0, c.nanos.Load())
+}
+
+func (c *CoarseClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Stop stops updating the clock and waits for the ticker goroutine,
+// Now keeps returning the last time.
+func (c *CoarseClock) Stop() {
+	c.cancel()
+	<-c.done
+}
+
+// requestClock returns the clock of the request timestamps: the coarse clock
+// is reused to avoid reading the system time, other clocks measure the time
+// of the cache and not of the requests.
+func requestClock(clock Clock) Clock {
+	if coarse, ok := clock.(*CoarseClock); ok {
+		return coarse
+	}
+	return systemClock{}
+}
@@ -0,0 +1,151 @@
+package locache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+type fakeClock struct {
+	mtx     sync.Mutex
+	current time.Time
+	timers  []fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{current: time.Now()}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return f.current
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	ch := make(chan time.Time, 1)
+	f.timers = append(f.timers, fakeTimer{at: f.current.Add(d), ch: ch})
+	return ch
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.current = f.current.Add(d)
+
+	timers := f.timers[:0]
+	for _, timer := range f.timers {
+		if timer.at.After(f.current) {
+			timers = append(timers, timer)
+			continue
+		}
+		timer.ch <- f.current
+	}
+	f.timers = timers
+}
+
+func (f *fakeClock) waiters() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return len(f.timers)
+}
+
+func TestCache_WithClock(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+
+	clock.Advance(30 * time.Second)
+	ttl, ok := cache.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, ttl)
+
+	clock.Advance(30 * time.Second)
+	requireKeyNotExists(t, cache, "key0")
+}
+
+func TestCache_SchedulePurge_WithClock(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	stop := cache.SchedulePurge(context.Background(), time.Second)
+	defer stop()
+
+	cache.Set("key0", "value0")
+	clock.Advance(time.Minute)
+	require.Equal(t, 1, cache.Len())
+
+	require.Eventually(t, func() bool { return clock.waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return cache.Len() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestCache_SchedulePurge_Stop(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+
+	stop1 := cache.SchedulePurge(context.Background(), time.Second)
+	stop2 := cache.SchedulePurge(context.Background(), time.Second)
+	defer stop2()
+
+	require.Eventually(t, func() bool { return clock.waiters() == 2 }, time.Second, time.Millisecond)
+	stop1()
+	stop1()
+
+	cache.Set("key0", "value0")
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return cache.Len() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestCoarseClock(t *testing.T) {
+	source := newFakeClock()
+
+	clock := newCoarseClock(context.Background(), time.Millisecond, source)
+	defer clock.Stop()
+	require.True(t, clock.Now().Equal(source.Now()))
+
+	source.Advance(time.Minute)
+	require.Eventually(t, func() bool { return clock.Now().Equal(source.Now()) }, time.Second, time.Millisecond)
+}
+
+func TestCoarseClock_Stop(t *testing.T) {
+	clock := NewCoarseClock(context.Background(), time.Millisecond)
+	clock.Stop()
+
+	stopped := clock.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.True(t, clock.Now().Equal(stopped))
+}
+
+func TestCache_WithCoarseClock(t *testing.T) {
+	clock := NewCoarseClock(context.Background(), time.Millisecond)
+	defer clock.Stop()
+
+	cache := New[string, string](WithTTL(20*time.Millisecond), WithClock(clock))
+	require.Equal(t, clock, cache.requestClock)
+
+	cache.Set("key0", "value0")
+	requireKeyExists(t, cache, "key0", "value0")
+	require.Eventually(t, func() bool {
+		_, ok := cache.Get("key0")
+		return !ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestRequestClock(t *testing.T) {
+	require.Equal(t, systemClock{}, requestClock(systemClock{}))
+	require.Equal(t, systemClock{}, requestClock(newFakeClock()))
+}
@@ -0,0 +1,97 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrClosed = errors.New("cache closed")
+
+// Close stops the background work of the cache, waits for in-flight refreshes
+// and removes all entries calling their OnExpire callbacks. The closed cache
+// rejects writes and refreshes. Close returns ErrClosed when it is called again.
+// With WithSnapshotOnClose the entries are written to the snapshot file before
+// they are removed, the error of the snapshot is returned after the cache is closed.
+func (c *Cache[Key, Value]) Close() error {
+	c.closeMtx.Lock()
+	if c.closed.Load() {
+		c.closeMtx.Unlock()
+		return ErrClosed
+	}
+	c.closed.Store(true)
+	c.closeMtx.Unlock()
+
+	if c.unregisterPurge != nil {
+		c.unregisterPurge()
+	}
+
+	c.cancel()
+	c.work.Wait()
+
+	var err error
+	if c.closeSnapshotPath != "" {
+		err = c.snapshotOnClose()
+	}
+	err = errors.Join(err, c.closeWAL())
+
+	c.Clear()
+	c.closeEvents()
+	c.closeWatchers()
+
+	return err
+}
+
+// snapshotOnClose writes the final snapshot limited by the snapshot timeout, if any.
+func (c *Cache[Key, Value]) snapshotOnClose() error {
+	ctx := context.Background()
+	if c.closeSnapshotTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.closeSnapshotTimeout)
+		defer cancel()
+	}
+
+	if err := c.snapshotFile(ctx, c.closeSnapshotPath); err != nil {
+		return fmt.Errorf("snapshot on close: %w", err)
+	}
+	return nil
+}
+
+// beginWork registers the work which Close has to wait for,
+// it returns false when the cache is closed.
+func (c *Cache[Key, Value]) beginWork() bool {
+	c.closeMtx.RLock()
+	defer c.closeMtx.RUnlock()
+
+	if c.closed.Load() {
+		return false
+	}
+	c.work.Add(1)
+	return true
+}
+
+// schedule calls fn every interval until ctx is done or the cache is closed.
+func (c *Cache[Key, Value]) schedule(ctx context.Context, interval time.Duration, fn func()) chan struct{} {
+	done := make(chan struct{})
+	if !c.beginWork() {
+		close(done)
+		return done
+	}
+
+	go func() {
+		defer c.work.Done()
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.ctx.Done():
+				return
+			case <-c.clock.After(interval):
+				fn()
+			}
+		}
+	}()
+	return done
+}
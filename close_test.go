@@ -0,0 +1,91 @@
+package locache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Close(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+
+	expired := 0
+	require.True(t, cache.OnExpire("key0", func(_, _ string) { expired++ }))
+
+	stop := cache.SchedulePurge(context.Background(), time.Millisecond)
+
+	require.NoError(t, cache.Close())
+	stop()
+	require.Equal(t, 1, expired)
+	require.Equal(t, 0, cache.Len())
+
+	cache.Set("key0", "value0")
+	requireKeyNotExists(t, cache, "key0")
+
+	_, ok := cache.Update("key0", func(string, bool) (string, bool) { return "value0", true })
+	require.False(t, ok)
+	requireKeyNotExists(t, cache, "key0")
+	require.Equal(t, 0, cache.Len())
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		return "value0", nil
+	})
+	require.ErrorIs(t, err, ErrClosed)
+
+	cache.SchedulePurge(context.Background(), time.Millisecond)()
+	require.ErrorIs(t, cache.Close(), ErrClosed)
+}
+
+func TestCache_Close_WaitsForRefresh(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+	}()
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		require.NoError(t, cache.Close())
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the refresh finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	<-closed
+	wg.Wait()
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestSharded_Close(t *testing.T) {
+	cache := NewSharded[int, int](4, WithTTL(time.Minute))
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+
+	stop := cache.SchedulePurge(context.Background(), time.Millisecond)
+	require.NoError(t, cache.Close())
+	stop()
+
+	require.Equal(t, 0, cache.Len())
+	require.ErrorIs(t, cache.Close(), ErrClosed)
+}
@@ -0,0 +1,174 @@
+// Command locachectl manages a cache over its HTTP admin API (locache.NewAdminHandler):
+//
+//	locachectl [flags] stats                  prints the statistics
+//	locachectl [flags] get <key>              prints the TTL and the value of the key
+//	locachectl [flags] del <key>...           deletes the keys
+//	locachectl [flags] del-prefix <prefix>    deletes the keys with the prefix
+//	locachectl [flags] del-tag <tag>          deletes the entries with the tag
+//	locachectl [flags] ttl <key> <duration>   sets the remaining lifetime of the key
+//	locachectl [flags] purge                  removes the expired entries
+//	locachectl [flags] flush                  removes all entries
+//	locachectl [flags] snapshot               writes the snapshot configured on the server
+//
+// The address of the admin API is the -addr flag or the LOCACHE_ADDR environment variable,
+// the -token flag or the LOCACHE_TOKEN environment variable is sent as the bearer token
+// checked by locache.AdminOpts.Authorize.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atkhx/locache"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "locachectl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("locachectl", flag.ContinueOnError)
+	addr := flags.String("addr", envOr("LOCACHE_ADDR", "http://localhost:8080"), "address of the admin API")
+	token := flags.String("token", os.Getenv("LOCACHE_TOKEN"), "bearer token of the admin API")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of the request")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("command is required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	c := &client{addr: strings.TrimSuffix(*addr, "/"), token: *token, http: http.DefaultClient}
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	switch {
+	case command == "stats" && len(args) == 0:
+		return c.print(ctx, stdout, http.MethodGet, "/stats")
+	case command == "get" && len(args) == 1:
+		return c.print(ctx, stdout, http.MethodGet, "/keys/"+url.PathEscape(args[0]))
+	case command == "del" && len(args) > 0:
+		deleted := 0
+		for _, key := range args {
+			n, err := c.deleted(ctx, http.MethodDelete, "/keys/"+url.PathEscape(key))
+			if err != nil {
+				return err
+			}
+			deleted += n
+		}
+		fmt.Fprintln(stdout, "deleted:", deleted)
+		return nil
+	case command == "del-prefix" && len(args) == 1:
+		return c.printDeleted(ctx, stdout, http.MethodDelete, "/keys?prefix="+url.QueryEscape(args[0]))
+	case command == "del-tag" && len(args) == 1:
+		return c.printDeleted(ctx, stdout, http.MethodDelete, "/keys?tag="+url.QueryEscape(args[0]))
+	case command == "ttl" && len(args) == 2:
+		if _, err := time.ParseDuration(args[1]); err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+		_, err := c.do(ctx, http.MethodPut, "/keys/"+url.PathEscape(args[0])+"/ttl?ttl="+url.QueryEscape(args[1]))
+		return err
+	case command == "purge" && len(args) == 0:
+		return c.printDeleted(ctx, stdout, http.MethodPost, "/purge")
+	case command == "flush" && len(args) == 0:
+		return c.printDeleted(ctx, stdout, http.MethodPost, "/flush")
+	case command == "snapshot" && len(args) == 0:
+		_, err := c.do(ctx, http.MethodPost, "/snapshot")
+		return err
+	}
+	return fmt.Errorf("unknown command or wrong arguments: %s", strings.Join(flags.Args(), " "))
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// client sends the requests to the admin API.
+type client struct {
+	addr  string
+	token string
+	http  *http.Client
+}
+
+func (c *client) do(ctx context.Context, method, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
+
+// print writes the indented JSON reply.
+func (c *client) print(ctx context.Context, stdout io.Writer, method, path string) error {
+	body, err := c.do(ctx, method, path)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(stdout)
+	return err
+}
+
+func (c *client) deleted(ctx context.Context, method, path string) (int, error) {
+	body, err := c.do(ctx, method, path)
+	if err != nil {
+		return 0, err
+	}
+
+	var result locache.AdminResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	return result.Deleted, nil
+}
+
+func (c *client) printDeleted(ctx context.Context, stdout io.Writer, method, path string) error {
+	deleted, err := c.deleted(ctx, method, path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "deleted:", deleted)
+	return nil
+}
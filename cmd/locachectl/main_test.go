@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+	"github.com/atkhx/locache/locachetest"
+)
+
+func TestRun(t *testing.T) {
+	cache := locache.New[string, string](
+		locache.WithTTL(time.Minute),
+		locache.WithClock(locachetest.NewClock(time.Now())),
+	)
+	cache.Set("user/1", "John")
+	cache.Set("user/2", "Jane")
+	cache.Set("order/1", "Jack")
+	cache.Set("order/2", "Jill")
+
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	mux := http.NewServeMux()
+	mux.Handle("/cache/", http.StripPrefix("/cache", locache.NewAdminHandler(cache, locache.AdminOpts[string, string]{
+		Tags:         func(_ string, value string) []string { return []string{value} },
+		SnapshotPath: path,
+		Authorize:    locache.AdminBearerToken("secret"),
+	})))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	locachectl := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		args = append([]string{"-addr", server.URL + "/cache/", "-token", "secret"}, args...)
+		err := run(context.Background(), args, &stdout)
+		return stdout.String(), err
+	}
+
+	out, err := locachectl("get", "user/1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"key":"user/1","ttl":"1m0s","value":"John"}`, out)
+
+	_, err = locachectl("ttl", "user/1", "10s")
+	require.NoError(t, err)
+	ttl, _ := cache.TTL("user/1")
+	require.Equal(t, 10*time.Second, ttl)
+
+	out, err = locachectl("stats")
+	require.NoError(t, err)
+	require.Contains(t, out, `"Entries": 4`)
+
+	out, err = locachectl("snapshot")
+	require.NoError(t, err)
+	require.Empty(t, out)
+	require.FileExists(t, path)
+
+	out, err = locachectl("del", "user/1", "missing")
+	require.NoError(t, err)
+	require.Equal(t, "deleted: 1\n", out)
+
+	out, err = locachectl("del-tag", "Jill")
+	require.NoError(t, err)
+	require.Equal(t, "deleted: 1\n", out)
+
+	out, err = locachectl("del-prefix", "order/")
+	require.NoError(t, err)
+	require.Equal(t, "deleted: 1\n", out)
+
+	out, err = locachectl("purge")
+	require.NoError(t, err)
+	require.Equal(t, "deleted: 0\n", out)
+
+	out, err = locachectl("flush")
+	require.NoError(t, err)
+	require.Equal(t, "deleted: 1\n", out)
+
+	_, err = locachectl("get", "user/2")
+	require.EqualError(t, err, "404 Not Found: key not found")
+
+	_, err = locachectl("ttl", "user/2", "soon")
+	require.ErrorContains(t, err, "invalid ttl")
+
+	_, err = locachectl("get")
+	require.EqualError(t, err, "unknown command or wrong arguments: get")
+
+	err = run(context.Background(), []string{"-addr", server.URL + "/cache", "stats"}, &bytes.Buffer{})
+	require.EqualError(t, err, "403 Forbidden: invalid token")
+}
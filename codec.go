@@ -0,0 +1,96 @@
+package locache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxFrameSize limits the size of a decoded frame, so a corrupted length
+// doesn't allocate gigabytes.
+const maxFrameSize = 1 << 30
+
+var errFrameTooLarge = errors.New("frame too large")
+
+// Codec converts keys or values to bytes and back for the snapshots and other
+// serialization features. GobCodec and JSONCodec are provided, msgpack or protobuf
+// codecs are implemented the same way on top of their libraries.
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// GobCodec encodes with encoding/gob, it is the default codec.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, fmt.Errorf("gob encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		return v, fmt.Errorf("gob decode: %w", err)
+	}
+	return v, nil
+}
+
+// JSONCodec encodes with encoding/json.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(v T) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("json encode: %w", err)
+	}
+	return data, nil
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("json decode: %w", err)
+	}
+	return v, nil
+}
+
+// appendFrame appends the length of the data as uvarint and the data.
+func appendFrame(buf, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// readFrame reads the frame written by appendFrame, it returns io.EOF
+// only when the reader ends before the frame.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxFrameSize {
+		return nil, errFrameTooLarge
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, noEOF(err)
+	}
+	return data, nil
+}
+
+// noEOF converts io.EOF in the middle of a record to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package locache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs(t *testing.T) {
+	for name, codec := range map[string]Codec[snapshotUser]{
+		"gob":  GobCodec[snapshotUser]{},
+		"json": JSONCodec[snapshotUser]{},
+	} {
+		codec := codec
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Encode(snapshotUser{ID: 1, Name: "John"})
+			require.NoError(t, err)
+
+			user, err := codec.Decode(data)
+			require.NoError(t, err)
+			require.Equal(t, snapshotUser{ID: 1, Name: "John"}, user)
+
+			_, err = codec.Decode([]byte("garbage"))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCache_Snapshot_WithCodec(t *testing.T) {
+	opts := []Option{
+		WithTTL(time.Minute),
+		WithKeyCodec[int](JSONCodec[int]{}),
+		WithCodec[snapshotUser](JSONCodec[snapshotUser]{}),
+	}
+
+	cache := New[int, snapshotUser](opts...)
+	cache.Set(1, snapshotUser{ID: 1, Name: "John"})
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+	require.Contains(t, buf.String(), `{"ID":1,"Name":"John"}`)
+
+	restored := New[int, snapshotUser](opts...)
+	require.NoError(t, restored.Restore(&buf))
+
+	user, ok := restored.Get(1)
+	require.True(t, ok)
+	require.Equal(t, snapshotUser{ID: 1, Name: "John"}, user)
+}
+
+func TestCache_Restore_Truncated(t *testing.T) {
+	cache := New[string, string]()
+	cache.Set("key0", "value0")
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-1])
+	require.ErrorContains(t, New[string, string]().Restore(truncated), "unexpected EOF")
+}
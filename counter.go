@@ -0,0 +1,56 @@
+package locache
+
+// Number is a constraint for values of counter caches.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Increment atomically adds the delta to the value of the key. The existing entry keeps
+// its expiration and cost, a missing or expired entry is created with the delta as value
+// and the default TTL. It returns the resulting value and whether it is stored.
+func Increment[Key comparable, Value Number](c *Cache[Key, Value], key Key, delta Value) (Value, bool) {
+	return addValue(c, MethodIncrement, key, delta, func(val Value) Value { return val + delta })
+}
+
+// Decrement works like Increment, but subtracts the delta.
+func Decrement[Key comparable, Value Number](c *Cache[Key, Value], key Key, delta Value) (Value, bool) {
+	var zero Value
+	return addValue(c, MethodDecrement, key, zero-delta, func(val Value) Value { return val - delta })
+}
+
+func addValue[Key comparable, Value Number](
+	c *Cache[Key, Value],
+	method string,
+	key Key,
+	initial Value,
+	add func(Value) Value,
+) (Value, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(method, c.requestClock.Now())
+	}
+
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+
+	cost := c.weigh(key, initial)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	if element, found := c.index[key]; found {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			c.hitLocked(key, element)
+			c.version++
+
+			item.val = add(item.val)
+			item.version = c.version
+			c.stored(method, item)
+			return item.val, true
+		}
+	}
+
+	return initial, c.store(method, key, initial, cost, c.ttl)
+}
@@ -0,0 +1,79 @@
+package locache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrement(t *testing.T) {
+	clock := newFakeClock()
+
+	cache := New[string, int](WithTTL(time.Minute), WithClock(clock))
+
+	actual, ok := Increment(cache, "key0", 2)
+	require.True(t, ok)
+	require.Equal(t, 2, actual)
+
+	clock.Advance(30 * time.Second)
+
+	actual, ok = Increment(cache, "key0", 3)
+	require.True(t, ok)
+	require.Equal(t, 5, actual)
+
+	actual, ok = Decrement(cache, "key0", 1)
+	require.True(t, ok)
+	require.Equal(t, 4, actual)
+
+	ttl, ok := cache.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, ttl)
+
+	clock.Advance(time.Minute)
+
+	actual, ok = Decrement(cache, "key0", 1)
+	require.True(t, ok)
+	require.Equal(t, -1, actual)
+}
+
+func TestIncrement_Unsigned(t *testing.T) {
+	cache := New[string, uint](WithTTL(time.Minute))
+
+	Increment(cache, "key0", 5)
+	actual, ok := Decrement(cache, "key0", 2)
+	require.True(t, ok)
+	require.Equal(t, uint(3), actual)
+}
+
+func TestIncrement_Concurrent(t *testing.T) {
+	cache := New[string, int64](WithTTL(time.Minute))
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				Increment(cache, "counter", 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	actual, ok := cache.Get("counter")
+	require.True(t, ok)
+	require.Equal(t, int64(1000), actual)
+}
+
+func TestIncrement_OnSet(t *testing.T) {
+	var values []int64
+	cache := New[string, int64](WithOnSet(func(_ string, value int64) { values = append(values, value) }))
+
+	Increment(cache, "counter", 2)
+	Increment(cache, "counter", 3)
+	Decrement(cache, "counter", 1)
+
+	require.Equal(t, []int64{2, 5, 4}, values)
+}
@@ -0,0 +1,202 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultDataLoaderWait is the window of a batch when DataLoaderOpts.Wait is not set.
+const defaultDataLoaderWait = time.Millisecond
+
+// ErrNotFound is returned by DataLoader for the keys missing in the result of the batch loader.
+var ErrNotFound = errors.New("not found")
+
+// BatchLoader loads the values of the keys missing in the cache with one call,
+// the keys missing in the result are not found.
+type BatchLoader[Key comparable, Value any] func(ctx context.Context, keys []Key) (map[Key]Value, error)
+
+// DataLoaderOpts configure the batches of DataLoader.
+type DataLoaderOpts struct {
+	// Wait is the window collecting the keys of a batch, 1ms by default.
+	Wait time.Duration
+	// MaxBatch loads the batch as soon as it has this many keys, zero means no limit.
+	MaxBatch int
+}
+
+// DataLoader collects the missing keys requested within the window into one call
+// of the batch loader and stores the loaded values in the cache (the dataloader pattern).
+type DataLoader[Key comparable, Value any] struct {
+	cache  *Cache[Key, Value]
+	loader BatchLoader[Key, Value]
+	opts   DataLoaderOpts
+
+	mtx sync.Mutex
+	// pending collects the keys until the window is over.
+	pending *dataBatch[Key, Value]
+	// loading is the batch of every key which is being collected or loaded.
+	loading map[Key]*dataBatch[Key, Value]
+}
+
+type dataBatch[Key comparable, Value any] struct {
+	ctx  context.Context
+	keys []Key
+	done chan struct{}
+
+	values map[Key]Value
+	err    error
+}
+
+func NewDataLoader[Key comparable, Value any](
+	cache *Cache[Key, Value],
+	loader BatchLoader[Key, Value],
+	opts DataLoaderOpts,
+) *DataLoader[Key, Value] {
+	if opts.Wait <= 0 {
+		opts.Wait = defaultDataLoaderWait
+	}
+
+	return &DataLoader[Key, Value]{
+		cache:   cache,
+		loader:  loader,
+		opts:    opts,
+		loading: make(map[Key]*dataBatch[Key, Value]),
+	}
+}
+
+// Load returns the cached value of the key or waits for the batch loading it.
+// The batch is loaded with the context of its first key without its cancellation.
+func (l *DataLoader[Key, Value]) Load(ctx context.Context, key Key) (Value, error) {
+	if val, ok := l.cache.Get(key); ok {
+		return val, nil
+	}
+
+	var emptyVal Value
+	batch := l.enqueue(ctx, key)
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return emptyVal, ctx.Err()
+	}
+
+	if batch.err != nil {
+		return emptyVal, batch.err
+	}
+	if val, ok := batch.values[key]; ok {
+		return val, nil
+	}
+	return emptyVal, ErrNotFound
+}
+
+// LoadMany returns the values of the keys found in the cache or loaded by the batches,
+// the keys which are not found are missing in the result. The keys of the failed batches
+// are missing as well, the errors of the batches are joined into the returned error.
+func (l *DataLoader[Key, Value]) LoadMany(ctx context.Context, keys []Key) (map[Key]Value, error) {
+	values := make(map[Key]Value, len(keys))
+	batches := make(map[Key]*dataBatch[Key, Value])
+	for _, key := range keys {
+		if val, ok := l.cache.Get(key); ok {
+			values[key] = val
+			continue
+		}
+		batches[key] = l.enqueue(ctx, key)
+	}
+
+	var errs []error
+	failed := make(map[*dataBatch[Key, Value]]bool)
+	for key, batch := range batches {
+		select {
+		case <-batch.done:
+		case <-ctx.Done():
+			return values, errors.Join(append(errs, ctx.Err())...)
+		}
+
+		if batch.err != nil {
+			if !failed[batch] {
+				failed[batch] = true
+				errs = append(errs, batch.err)
+			}
+			continue
+		}
+		if val, ok := batch.values[key]; ok {
+			values[key] = val
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Cache returns the underlying cache, e.g. to invalidate or to prime it.
+func (l *DataLoader[Key, Value]) Cache() *Cache[Key, Value] {
+	return l.cache
+}
+
+// enqueue returns the batch of the key, the key is added to the pending batch
+// unless it is being loaded already.
+func (l *DataLoader[Key, Value]) enqueue(ctx context.Context, key Key) *dataBatch[Key, Value] {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if batch, found := l.loading[key]; found {
+		return batch
+	}
+
+	if l.pending == nil {
+		batch := &dataBatch[Key, Value]{ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+		l.pending = batch
+
+		timer := l.cache.clock.After(l.opts.Wait)
+		go func() {
+			<-timer
+			l.dispatch(batch)
+		}()
+	}
+
+	batch := l.pending
+	batch.keys = append(batch.keys, key)
+	l.loading[key] = batch
+
+	if l.opts.MaxBatch > 0 && len(batch.keys) >= l.opts.MaxBatch {
+		l.pending = nil
+		go l.load(batch)
+	}
+	return batch
+}
+
+// dispatch loads the batch at the end of its window unless it was loaded being full.
+func (l *DataLoader[Key, Value]) dispatch(batch *dataBatch[Key, Value]) {
+	l.mtx.Lock()
+	if l.pending != batch {
+		l.mtx.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mtx.Unlock()
+
+	l.load(batch)
+}
+
+func (l *DataLoader[Key, Value]) load(batch *dataBatch[Key, Value]) {
+	defer func() {
+		if p := recover(); p != nil {
+			batch.values, batch.err = nil, fmt.Errorf("batch loader panicked: %v", p)
+		}
+
+		l.mtx.Lock()
+		for _, key := range batch.keys {
+			delete(l.loading, key)
+		}
+		l.mtx.Unlock()
+
+		close(batch.done)
+	}()
+
+	batch.values, batch.err = l.loader(batch.ctx, batch.keys)
+	if batch.err == nil {
+		for key, val := range batch.values {
+			l.cache.Set(key, val)
+		}
+	}
+}
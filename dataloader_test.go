@@ -0,0 +1,150 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBatchLoader returns the squares of the keys less than 10 and records the batches.
+type recordingBatchLoader struct {
+	mtx     sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recordingBatchLoader) load(_ context.Context, keys []int) (map[int]int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	batch := append([]int(nil), keys...)
+	sort.Ints(batch)
+	r.batches = append(r.batches, batch)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	values := make(map[int]int, len(keys))
+	for _, key := range keys {
+		if key < 10 {
+			values[key] = key * key
+		}
+	}
+	return values, nil
+}
+
+func (r *recordingBatchLoader) loaded() [][]int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return append([][]int(nil), r.batches...)
+}
+
+func pendingKeys[Key comparable, Value any](l *DataLoader[Key, Value]) int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return len(l.loading)
+}
+
+func TestDataLoader(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, int](WithTTL(time.Minute), WithClock(clock))
+	loader := &recordingBatchLoader{}
+	dataLoader := NewDataLoader(cache, loader.load, DataLoaderOpts{Wait: 10 * time.Millisecond})
+
+	ctx := context.Background()
+	keys := []int{1, 2, 2, 10}
+	values := make([]int, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i, key int) {
+			defer wg.Done()
+			values[i], errs[i] = dataLoader.Load(ctx, key)
+		}(i, key)
+	}
+
+	require.Eventually(t, func() bool { return pendingKeys(dataLoader) == 3 }, time.Second, time.Millisecond)
+	clock.Advance(10 * time.Millisecond)
+	wg.Wait()
+
+	require.Equal(t, []int{1, 4, 4, 0}, values)
+	require.Equal(t, []error{nil, nil, nil, ErrNotFound}, errs)
+	require.Equal(t, [][]int{{1, 2, 10}}, loader.loaded())
+	require.Equal(t, 0, pendingKeys(dataLoader))
+
+	val, err := dataLoader.Load(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, 4, val)
+	require.Len(t, loader.loaded(), 1)
+}
+
+func TestDataLoader_MaxBatch(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, int](WithClock(clock))
+	cache.Set(5, 25)
+
+	loader := &recordingBatchLoader{}
+	dataLoader := NewDataLoader(cache, loader.load, DataLoaderOpts{MaxBatch: 2})
+
+	// The full batches are loaded without waiting for the end of the window.
+	values, err := dataLoader.LoadMany(context.Background(), []int{1, 2, 3, 4, 5})
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{1: 1, 2: 4, 3: 9, 4: 16, 5: 25}, values)
+
+	batches := loader.loaded()
+	sort.Slice(batches, func(i, j int) bool { return batches[i][0] < batches[j][0] })
+	require.Equal(t, [][]int{{1, 2}, {3, 4}}, batches)
+}
+
+func TestDataLoader_Errors(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, int](WithClock(clock))
+	loader := &recordingBatchLoader{err: fmt.Errorf("backend failed")}
+	dataLoader := NewDataLoader(cache, loader.load, DataLoaderOpts{MaxBatch: 1})
+
+	_, err := dataLoader.Load(context.Background(), 1)
+	require.ErrorContains(t, err, "backend failed")
+	require.Equal(t, 0, cache.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = NewDataLoader(cache, loader.load, DataLoaderOpts{}).Load(ctx, 1)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDataLoader_Panic(t *testing.T) {
+	cache := New[int, int]()
+	dataLoader := NewDataLoader(cache, func(context.Context, []int) (map[int]int, error) {
+		panic("backend failed")
+	}, DataLoaderOpts{MaxBatch: 1})
+
+	_, err := dataLoader.Load(context.Background(), 1)
+	require.EqualError(t, err, "batch loader panicked: backend failed")
+	require.Equal(t, 0, pendingKeys(dataLoader))
+}
+
+func TestDataLoader_LoadMany_Errors(t *testing.T) {
+	cache := New[int, int]()
+	dataLoader := NewDataLoader(cache, func(_ context.Context, keys []int) (map[int]int, error) {
+		if keys[0]%2 == 1 {
+			return nil, fmt.Errorf("key %d failed", keys[0])
+		}
+		return map[int]int{keys[0]: keys[0]}, nil
+	}, DataLoaderOpts{MaxBatch: 1})
+
+	// The values of the successful batches are returned with the errors of the failed ones.
+	values, err := dataLoader.LoadMany(context.Background(), []int{1, 2, 3, 4})
+	require.Equal(t, map[int]int{2: 2, 4: 4}, values)
+	require.ErrorContains(t, err, "key 1 failed")
+	require.ErrorContains(t, err, "key 3 failed")
+}
@@ -0,0 +1,272 @@
+package locache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/atkhx/locache/flight"
+)
+
+// denseNil is the index of a missing slot.
+const denseNil int32 = -1
+
+type denseSlot[Key comparable, Value any] struct {
+	key  Key
+	val  Value
+	exp  int64
+	prev int32
+	next int32
+}
+
+// Dense is a cache which keeps entries in a contiguous slice of slots linked
+// by integer indexes, and the map of keys holds slot indexes instead of pointers.
+// With keys and values without pointers the garbage collector doesn't scan
+// the entries at all, which suits large caches of small structs.
+//
+// Entries are evicted in least-recently-used order when WithMaxEntries is set.
+// Freed slots are reused by the next writes. It supports WithTTL, WithMetrics,
+// WithClock and WithMaxEntries, other options are ignored.
+type Dense[Key comparable, Value any] struct {
+	ttl   time.Duration
+	mtr   Metrics
+	clock Clock
+	timed bool
+
+	requestClock Clock
+
+	maxEntries int
+
+	mtx   sync.RWMutex
+	index map[Key]int32
+	slots []denseSlot[Key, Value]
+	head  int32
+	tail  int32
+	free  int32
+
+	calls flight.Group[Key, Value]
+}
+
+func NewDense[Key comparable, Value any](opts ...Option) *Dense[Key, Value] {
+	o := newOptions(opts)
+
+	return &Dense[Key, Value]{
+		ttl:   o.ttl,
+		mtr:   o.mtr,
+		clock: o.clock,
+		timed: !isNopMetrics(o.mtr),
+
+		requestClock: requestClock(o.clock),
+
+		maxEntries: o.maxEntries,
+
+		index: make(map[Key]int32),
+		head:  denseNil,
+		tail:  denseNil,
+		free:  denseNil,
+	}
+}
+
+func (d *Dense[Key, Value]) Get(key Key) (Value, bool) {
+	if d.timed {
+		defer d.mtr.ObserveRequest(MethodGet, d.requestClock.Now())
+	}
+
+	val, ok := d.get(key)
+	if !ok {
+		d.mtr.IncMisses(MethodGet)
+		return val, false
+	}
+
+	d.mtr.IncHits(MethodGet)
+	return val, true
+}
+
+func (d *Dense[Key, Value]) Set(key Key, value Value) {
+	d.SetWithTTL(key, value, d.ttl)
+}
+
+// SetWithTTL stores the value with its own TTL, a ttl <= 0 keeps the entry
+// until it is deleted or evicted.
+func (d *Dense[Key, Value]) SetWithTTL(key Key, value Value, ttl time.Duration) {
+	if d.timed {
+		defer d.mtr.ObserveRequest(MethodSet, d.requestClock.Now())
+	}
+
+	d.set(key, value, ttl)
+}
+
+func (d *Dense[Key, Value]) Del(key Key) {
+	if d.timed {
+		defer d.mtr.ObserveRequest(MethodDel, d.requestClock.Now())
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if idx, found := d.index[key]; found {
+		d.remove(idx)
+		d.mtr.SetItemsCount(len(d.index))
+	}
+}
+
+// GetOrRefresh works like Cache.GetOrRefresh: concurrent misses of the same key
+// wait for one refresh call.
+func (d *Dense[Key, Value]) GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error) {
+	if d.timed {
+		defer d.mtr.ObserveRequest(MethodGetOrRefresh, d.requestClock.Now())
+	}
+
+	if val, ok := d.get(key); ok {
+		d.mtr.IncHits(MethodGetOrRefresh)
+		return val, nil
+	}
+
+	return d.calls.DoOnce(key, func() (Value, error) {
+		// The value could be stored while the call was registered.
+		if val, ok := d.get(key); ok {
+			d.mtr.IncHits(MethodGetOrRefresh)
+			return val, nil
+		}
+
+		val, err := refresh()
+		if err != nil {
+			d.mtr.IncErrors(MethodGetOrRefresh)
+			return val, fmt.Errorf("refresh val: %w", err)
+		}
+
+		d.mtr.IncMisses(MethodGetOrRefresh)
+		d.set(key, val, d.ttl)
+		return val, nil
+	})
+}
+
+// Purge removes expired entries walking all the slots.
+func (d *Dense[Key, Value]) Purge() {
+	if d.timed {
+		defer d.mtr.ObserveRequest(MethodPurge, d.requestClock.Now())
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	current := d.clock.Now().UnixNano()
+	for idx := d.head; idx != denseNil; {
+		next := d.slots[idx].next
+		if denseExpired(d.slots[idx].exp, current) {
+			d.remove(idx)
+		}
+		idx = next
+	}
+	d.mtr.SetItemsCount(len(d.index))
+}
+
+// Len returns the number of entries, including expired entries which are not purged yet.
+func (d *Dense[Key, Value]) Len() int {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+
+	return len(d.index)
+}
+
+func denseExpired(exp, at int64) bool {
+	return exp != 0 && at >= exp
+}
+
+func (d *Dense[Key, Value]) get(key Key) (Value, bool) {
+	// Reads move the entry in the eviction order only when there is a limit.
+	if d.maxEntries > 0 {
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+	} else {
+		d.mtx.RLock()
+		defer d.mtx.RUnlock()
+	}
+
+	idx, found := d.index[key]
+	if !found || denseExpired(d.slots[idx].exp, d.clock.Now().UnixNano()) {
+		var val Value
+		return val, false
+	}
+
+	if d.maxEntries > 0 {
+		d.unlink(idx)
+		d.linkBack(idx)
+	}
+	return d.slots[idx].val, true
+}
+
+func (d *Dense[Key, Value]) set(key Key, value Value, ttl time.Duration) {
+	var exp int64
+	if ttl > 0 {
+		exp = d.clock.Now().Add(ttl).UnixNano()
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if idx, found := d.index[key]; found {
+		d.slots[idx].val = value
+		d.slots[idx].exp = exp
+		d.unlink(idx)
+		d.linkBack(idx)
+		return
+	}
+
+	for d.maxEntries > 0 && len(d.index) >= d.maxEntries {
+		d.remove(d.head)
+		d.mtr.IncEvictions(MethodSet)
+	}
+
+	idx := d.free
+	if idx != denseNil {
+		d.free = d.slots[idx].next
+	} else {
+		idx = int32(len(d.slots))
+		d.slots = append(d.slots, denseSlot[Key, Value]{})
+	}
+
+	d.slots[idx].key = key
+	d.slots[idx].val = value
+	d.slots[idx].exp = exp
+	d.linkBack(idx)
+	d.index[key] = idx
+
+	d.mtr.SetItemsCount(len(d.index))
+}
+
+// remove unlinks the slot and puts it to the free list.
+func (d *Dense[Key, Value]) remove(idx int32) {
+	d.unlink(idx)
+	delete(d.index, d.slots[idx].key)
+
+	// The zero slot releases references of the key and the value.
+	d.slots[idx] = denseSlot[Key, Value]{prev: denseNil, next: d.free}
+	d.free = idx
+}
+
+func (d *Dense[Key, Value]) unlink(idx int32) {
+	slot := &d.slots[idx]
+	if slot.prev != denseNil {
+		d.slots[slot.prev].next = slot.next
+	} else {
+		d.head = slot.next
+	}
+	if slot.next != denseNil {
+		d.slots[slot.next].prev = slot.prev
+	} else {
+		d.tail = slot.prev
+	}
+	slot.prev, slot.next = denseNil, denseNil
+}
+
+func (d *Dense[Key, Value]) linkBack(idx int32) {
+	d.slots[idx].prev = d.tail
+	d.slots[idx].next = denseNil
+	if d.tail != denseNil {
+		d.slots[d.tail].next = idx
+	} else {
+		d.head = idx
+	}
+	d.tail = idx
+}
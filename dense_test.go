@@ -0,0 +1,117 @@
+package locache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDense_GetSetDel(t *testing.T) {
+	dense := NewDense[string, int](WithTTL(time.Minute))
+
+	_, ok := dense.Get("key0")
+	require.False(t, ok)
+
+	dense.Set("key0", 0)
+	dense.Set("key1", 1)
+	dense.Set("key0", 10)
+
+	actual, ok := dense.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, 10, actual)
+	require.Equal(t, 2, dense.Len())
+
+	dense.Del("key0")
+	_, ok = dense.Get("key0")
+	require.False(t, ok)
+	require.Equal(t, 1, dense.Len())
+
+	// The freed slot is reused.
+	dense.Set("key2", 2)
+	require.Len(t, dense.slots, 2)
+
+	actual, ok = dense.Get("key2")
+	require.True(t, ok)
+	require.Equal(t, 2, actual)
+}
+
+func TestDense_Expiration(t *testing.T) {
+	clock := newFakeClock()
+	dense := NewDense[string, int](WithTTL(time.Minute), WithClock(clock))
+
+	dense.Set("key0", 0)
+	dense.SetWithTTL("key1", 1, 0)
+	dense.SetWithTTL("key2", 2, 2*time.Minute)
+
+	clock.Advance(time.Minute)
+	_, ok := dense.Get("key0")
+	require.False(t, ok)
+	require.Equal(t, 3, dense.Len())
+
+	dense.Purge()
+	require.Equal(t, 2, dense.Len())
+
+	_, ok = dense.Get("key1")
+	require.True(t, ok)
+	_, ok = dense.Get("key2")
+	require.True(t, ok)
+}
+
+func TestDense_MaxEntries(t *testing.T) {
+	dense := NewDense[string, int](WithMaxEntries(2))
+
+	dense.Set("key0", 0)
+	dense.Set("key1", 1)
+
+	_, ok := dense.Get("key0")
+	require.True(t, ok)
+
+	dense.Set("key2", 2)
+	require.Equal(t, 2, dense.Len())
+
+	_, ok = dense.Get("key1")
+	require.False(t, ok)
+	_, ok = dense.Get("key0")
+	require.True(t, ok)
+	_, ok = dense.Get("key2")
+	require.True(t, ok)
+}
+
+func TestDense_GetOrRefresh(t *testing.T) {
+	dense := NewDense[string, int](WithTTL(time.Minute))
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	refresh := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 1, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			actual, err := dense.GetOrRefresh("key0", refresh)
+			require.NoError(t, err)
+			require.Equal(t, 1, actual)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+
+	_, err := dense.GetOrRefresh("key1", func() (int, error) {
+		return 0, errors.New("failed")
+	})
+	require.EqualError(t, err, "refresh val: failed")
+	require.Equal(t, 1, dense.Len())
+}
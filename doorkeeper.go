@@ -0,0 +1,42 @@
+package locache
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// doorkeeper remembers keys seen within the window in a bloom filter.
+// It lets a key in only when the key is seen for the second time.
+type doorkeeper[Key comparable] struct {
+	mtx    sync.Mutex
+	seed   maphash.Seed
+	filter *bloomFilter
+	window time.Duration
+	reset  time.Time
+	clock  Clock
+}
+
+func newDoorkeeper[Key comparable](expectedKeys int, window time.Duration, clock Clock) *doorkeeper[Key] {
+	return &doorkeeper[Key]{
+		seed:   maphash.MakeSeed(),
+		filter: newBloomFilter(expectedKeys, 0.01),
+		window: window,
+		reset:  clock.Now().Add(window),
+		clock:  clock,
+	}
+}
+
+// Allow registers the key and reports whether it has been seen before.
+func (d *doorkeeper[Key]) Allow(key Key) bool {
+	hash := hashKey(d.seed, key)
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.window > 0 && d.reset.Before(d.clock.Now()) {
+		d.filter.Reset()
+		d.reset = d.clock.Now().Add(d.window)
+	}
+	return d.filter.Add(hash)
+}
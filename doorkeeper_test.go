@@ -0,0 +1,56 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoorkeeper_Allow(t *testing.T) {
+	dk := newDoorkeeper[string](100, 0, systemClock{})
+	require.False(t, dk.Allow("key0"))
+	require.True(t, dk.Allow("key0"))
+	require.False(t, dk.Allow("key1"))
+}
+
+func TestDoorkeeper_Window(t *testing.T) {
+	clock := newFakeClock()
+	dk := newDoorkeeper[string](100, time.Minute, clock)
+	require.False(t, dk.Allow("key0"))
+
+	clock.Advance(2 * time.Minute)
+	require.False(t, dk.Allow("key0"))
+	require.True(t, dk.Allow("key0"))
+}
+
+func TestCache_Set_Doorkeeper(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithDoorkeeper(100, time.Minute))
+
+	cache.Set("key0", "value0")
+	requireKeyNotExists(t, cache, "key0")
+
+	cache.Set("key0", "value0")
+	requireKeyExists(t, cache, "key0", "value0")
+
+	cache.Set("key0", "updated0")
+	requireKeyExists(t, cache, "key0", "updated0")
+}
+
+func TestCache_GetOrRefresh_Doorkeeper(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithDoorkeeper(100, time.Minute))
+	refresh := func() (string, error) {
+		return "value0", nil
+	}
+
+	actual, err := cache.GetOrRefresh("key0", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+	requireKeyNotExists(t, cache, "key0")
+	requireCacheItems(t, cache, []string{})
+
+	actual, err = cache.GetOrRefresh("key0", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+	requireKeyExists(t, cache, "key0", "value0")
+}
@@ -0,0 +1,110 @@
+package locache
+
+import "sync"
+
+// defaultEventsBuffer is the size of the events channel when WithEventsBuffer is not set.
+const defaultEventsBuffer = 1024
+
+// ChangeKind is the kind of the change of a cache entry.
+type ChangeKind int
+
+const (
+	ChangeSet ChangeKind = iota + 1
+	ChangeDelete
+	ChangeExpire
+	ChangeRefresh
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeSet:
+		return "set"
+	case ChangeDelete:
+		return "delete"
+	case ChangeExpire:
+		return "expire"
+	case ChangeRefresh:
+		return "refresh"
+	}
+	return "unknown"
+}
+
+// ChangeEvent describes the change of an entry: the value is stored by a write (ChangeSet)
+// or by GetOrRefresh (ChangeRefresh), the entry is deleted by Del or Pop (ChangeDelete)
+// or the expired entry is removed (ChangeExpire). The Value is the stored or the removed value.
+type ChangeEvent[Key comparable, Value any] struct {
+	Kind  ChangeKind
+	Key   Key
+	Value Value
+}
+
+// eventStream is a buffered channel of events which drops the oldest event when it is full.
+type eventStream[Key comparable, Value any] struct {
+	mtx    sync.Mutex
+	ch     chan ChangeEvent[Key, Value]
+	closed bool
+}
+
+func newEventStream[Key comparable, Value any](size int) *eventStream[Key, Value] {
+	return &eventStream[Key, Value]{ch: make(chan ChangeEvent[Key, Value], size)}
+}
+
+func (s *eventStream[Key, Value]) send(event ChangeEvent[Key, Value]) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	for {
+		select {
+		case s.ch <- event:
+			return
+		default:
+		}
+
+		// The buffer is full, the oldest event gives way to the new one.
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
+
+func (s *eventStream[Key, Value]) close() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Events returns the channel of entry changes. The stream is started by the first call,
+// later calls return the same channel, so there is one consumer per cache.
+// The channel is buffered (see WithEventsBuffer), when the consumer falls behind
+// the oldest events are dropped. Evictions, replaced values and Clear are not reported.
+// The channel is closed by Close.
+func (c *Cache[Key, Value]) Events() <-chan ChangeEvent[Key, Value] {
+	c.eventsOnce.Do(func() {
+		c.events.Store(newEventStream[Key, Value](max(c.eventsBuffer, 1)))
+	})
+	return c.events.Load().ch
+}
+
+// emit sends the event to the stream if Events was called.
+func (c *Cache[Key, Value]) emit(kind ChangeKind, key Key, value Value) {
+	if stream := c.events.Load(); stream != nil {
+		stream.send(ChangeEvent[Key, Value]{Kind: kind, Key: key, Value: value})
+	}
+}
+
+// closeEvents closes the events channel, Events called after it returns a closed channel.
+func (c *Cache[Key, Value]) closeEvents() {
+	c.eventsOnce.Do(func() {
+		c.events.Store(newEventStream[Key, Value](0))
+	})
+	c.events.Load().close()
+}
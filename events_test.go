@@ -0,0 +1,84 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func receiveEvents[Key comparable, Value any](events <-chan ChangeEvent[Key, Value]) []ChangeEvent[Key, Value] {
+	var received []ChangeEvent[Key, Value]
+	for {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		default:
+			return received
+		}
+	}
+}
+
+func TestCache_Events(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	// Changes before the subscription are not reported.
+	cache.Set("key0", "value0")
+	events := cache.Events()
+
+	cache.Set("key1", "value1")
+	_, err := cache.GetOrRefresh("key2", func() (string, error) { return "value2", nil })
+	require.NoError(t, err)
+	cache.Del("key1")
+	cache.Del("unknown")
+
+	require.Equal(t, []ChangeEvent[string, string]{
+		{Kind: ChangeSet, Key: "key1", Value: "value1"},
+		{Kind: ChangeRefresh, Key: "key2", Value: "value2"},
+		{Kind: ChangeDelete, Key: "key1", Value: "value1"},
+	}, receiveEvents(events))
+
+	require.NoError(t, cache.Close())
+	for range events {
+		t.Fatal("unexpected event")
+	}
+	_, open := <-cache.Events()
+	require.False(t, open)
+}
+
+func TestCache_Events_Expire(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	events := cache.Events()
+
+	cache.Set("key0", "value0")
+	clock.Advance(2 * time.Minute)
+	cache.Purge()
+
+	require.Equal(t, []ChangeEvent[string, string]{
+		{Kind: ChangeSet, Key: "key0", Value: "value0"},
+		{Kind: ChangeExpire, Key: "key0", Value: "value0"},
+	}, receiveEvents(events))
+}
+
+func TestCache_Events_DropOldest(t *testing.T) {
+	cache := New[int, int](WithEventsBuffer(2))
+	events := cache.Events()
+
+	for i := 0; i < 5; i++ {
+		cache.Set(i, i)
+	}
+
+	require.Equal(t, []ChangeEvent[int, int]{
+		{Kind: ChangeSet, Key: 3, Value: 3},
+		{Kind: ChangeSet, Key: 4, Value: 4},
+	}, receiveEvents(events))
+}
+
+func TestCache_Events_ClosedBeforeSubscribe(t *testing.T) {
+	cache := New[string, string]()
+	require.NoError(t, cache.Close())
+
+	_, open := <-cache.Events()
+	require.False(t, open)
+}
@@ -0,0 +1,174 @@
+package locache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// EvictionPolicy decides which entry leaves the cache when it is over capacity.
+// All methods are called with the cache write lock held.
+type EvictionPolicy[Key comparable] interface {
+	// OnGet is called on every cache hit.
+	OnGet(key Key)
+	// OnSet is called when a value is stored for the key.
+	OnSet(key Key)
+	// OnDelete is called when the key leaves the cache for any reason.
+	OnDelete(key Key)
+	// Victim returns the key that should be evicted next.
+	Victim() (Key, bool)
+}
+
+type EvictionMode int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU EvictionMode = iota
+	// FIFO evicts the oldest entry, access doesn't reorder entries.
+	FIFO
+	// MRU evicts the most recently used entry.
+	MRU
+)
+
+// itemsPolicy implements the built-in eviction modes on the list of the cache entries:
+// the entries are moved to its back when they are stored and, unless FIFO, when they are hit.
+// The entries without a value, new ones and placeholders of refreshes, are never victims.
+type itemsPolicy[Key comparable, Value any] struct {
+	cache *Cache[Key, Value]
+	mode  EvictionMode
+}
+
+func newItemsPolicy[Key comparable, Value any](cache *Cache[Key, Value], mode EvictionMode) *itemsPolicy[Key, Value] {
+	switch mode {
+	case LRU, FIFO, MRU:
+		return &itemsPolicy[Key, Value]{cache: cache, mode: mode}
+	default:
+		panic(fmt.Sprintf("locache: unknown eviction mode %d", mode))
+	}
+}
+
+func (p *itemsPolicy[Key, Value]) OnGet(key Key) {
+	if p.mode == FIFO {
+		return
+	}
+	if element, found := p.cache.index[key]; found {
+		p.cache.items.MoveToBack(element)
+	}
+}
+
+// OnSet does nothing, setItemValue moves the stored entry.
+func (p *itemsPolicy[Key, Value]) OnSet(_ Key) {}
+
+// OnDelete does nothing, the entry is already removed from the list.
+func (p *itemsPolicy[Key, Value]) OnDelete(_ Key) {}
+
+func (p *itemsPolicy[Key, Value]) Victim() (Key, bool) {
+	if p.mode == MRU {
+		for element := p.cache.items.Back(); element != nil; element = element.Prev() {
+			if item := p.cache.getItem(element); item.set {
+				return item.key, true
+			}
+		}
+	} else {
+		for element := p.cache.items.Front(); element != nil; element = element.Next() {
+			if item := p.cache.getItem(element); item.set {
+				return item.key, true
+			}
+		}
+	}
+
+	var key Key
+	return key, false
+}
+
+type slruEntry[Key comparable] struct {
+	key       Key
+	protected bool
+}
+
+// SLRUPolicy is a segmented LRU. New keys land in the probation segment
+// and are promoted to the protected segment on the next access.
+// When the protected segment is full, its least recently used key is
+// demoted back to probation. Victims are taken from probation first,
+// so a burst of keys accessed once can't flush the protected keys.
+type SLRUPolicy[Key comparable] struct {
+	probation *list.List
+	protected *list.List
+	index     map[Key]*list.Element
+
+	protectedSize int
+}
+
+// NewSLRUPolicy creates a segmented LRU with the given protected segment size.
+// The probation segment takes the rest of the cache capacity.
+func NewSLRUPolicy[Key comparable](protectedSize int) *SLRUPolicy[Key] {
+	return &SLRUPolicy[Key]{
+		probation: list.New(),
+		protected: list.New(),
+		index:     make(map[Key]*list.Element),
+
+		protectedSize: protectedSize,
+	}
+}
+
+func (p *SLRUPolicy[Key]) OnGet(key Key) {
+	element, found := p.index[key]
+	if !found {
+		return
+	}
+
+	entry := p.getEntry(element)
+	if entry.protected {
+		p.protected.MoveToBack(element)
+		return
+	}
+
+	p.probation.Remove(element)
+	entry.protected = true
+	p.index[key] = p.protected.PushBack(entry)
+
+	for p.protected.Len() > p.protectedSize {
+		demoted := p.protected.Front()
+		demotedEntry := p.getEntry(demoted)
+		p.protected.Remove(demoted)
+		demotedEntry.protected = false
+		p.index[demotedEntry.key] = p.probation.PushBack(demotedEntry)
+	}
+}
+
+func (p *SLRUPolicy[Key]) OnSet(key Key) {
+	if _, found := p.index[key]; found {
+		p.OnGet(key)
+		return
+	}
+	p.index[key] = p.probation.PushBack(&slruEntry[Key]{key: key})
+}
+
+func (p *SLRUPolicy[Key]) OnDelete(key Key) {
+	element, found := p.index[key]
+	if !found {
+		return
+	}
+
+	if p.getEntry(element).protected {
+		p.protected.Remove(element)
+	} else {
+		p.probation.Remove(element)
+	}
+	delete(p.index, key)
+}
+
+func (p *SLRUPolicy[Key]) Victim() (Key, bool) {
+	element := p.probation.Front()
+	if element == nil {
+		element = p.protected.Front()
+	}
+	if element == nil {
+		var key Key
+		return key, false
+	}
+	return p.getEntry(element).key, true
+}
+
+func (p *SLRUPolicy[Key]) getEntry(element *list.Element) *slruEntry[Key] {
+	return element.Value.(*slruEntry[Key]) //nolint:forcetypeassert
+}
@@ -0,0 +1,143 @@
+package locache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireVictim(t *testing.T, policy EvictionPolicy[string], expected string) {
+	t.Helper()
+	victim, ok := policy.Victim()
+	require.True(t, ok)
+	require.Equal(t, expected, victim)
+}
+
+func TestSLRUPolicy(t *testing.T) {
+	policy := NewSLRUPolicy[string](2)
+
+	_, ok := policy.Victim()
+	require.False(t, ok)
+
+	policy.OnSet("key0")
+	policy.OnSet("key1")
+	policy.OnSet("key2")
+	requireVictim(t, policy, "key0")
+
+	// Promote key0 and key1 to protected.
+	policy.OnGet("key0")
+	policy.OnGet("key1")
+	requireVictim(t, policy, "key2")
+
+	// Promoting key2 demotes key0 as least recently used protected key.
+	policy.OnGet("key2")
+	requireVictim(t, policy, "key0")
+
+	policy.OnDelete("key0")
+	requireVictim(t, policy, "key1")
+
+	policy.OnDelete("key1")
+	policy.OnDelete("key2")
+	_, ok = policy.Victim()
+	require.False(t, ok)
+}
+
+func TestCache_Set_SLRUPolicy_ProtectsHotEntries(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second),
+		WithMaxEntries(3),
+		WithEvictionPolicy[string](NewSLRUPolicy[string](2)),
+	)
+	cache.Set("hot0", "value0")
+	cache.Set("hot1", "value1")
+	requireKeyExists(t, cache, "hot0", "value0")
+	requireKeyExists(t, cache, "hot1", "value1")
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprintf("scan%d", i), "scan")
+	}
+
+	requireKeyExists(t, cache, "hot0", "value0")
+	requireKeyExists(t, cache, "hot1", "value1")
+	requireKeyExists(t, cache, "scan9", "scan")
+}
+
+func TestCache_Set_EvictionMode(t *testing.T) {
+	testCases := map[string]struct {
+		mode    EvictionMode
+		evicted string
+	}{
+		"lru":  {mode: LRU, evicted: "key1"},
+		"fifo": {mode: FIFO, evicted: "key0"},
+		"mru":  {mode: MRU, evicted: "key0"},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			cache := New[string, string](WithTTL(time.Second), WithMaxEntries(2), WithEvictionMode(tc.mode))
+			cache.Set("key0", "value0")
+			cache.Set("key1", "value1")
+			_, _ = cache.Get("key0")
+
+			cache.Set("key2", "value2")
+			require.Equal(t, 2, len(cache.index))
+			require.NotContains(t, cache.index, tc.evicted)
+			require.Contains(t, cache.index, "key2")
+		})
+	}
+}
+
+func TestCache_Set_EvictionMode_Update(t *testing.T) {
+	testCases := map[string]struct {
+		mode    EvictionMode
+		evicted string
+	}{
+		"lru":  {mode: LRU, evicted: "key1"},
+		"fifo": {mode: FIFO, evicted: "key0"},
+		"mru":  {mode: MRU, evicted: "key0"},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			cache := New[string, string](WithTTL(time.Second), WithMaxEntries(2), WithEvictionMode(tc.mode))
+			cache.Set("key0", "value0")
+			cache.Set("key1", "value1")
+			cache.Set("key0", "updated0")
+
+			cache.Set("key2", "value2")
+			require.Equal(t, 2, cache.items.Len())
+			require.NotContains(t, cache.index, tc.evicted)
+			require.Contains(t, cache.index, "key2")
+		})
+	}
+}
+
+func TestCache_Set_EvictionMode_SkipsRefreshPlaceholder(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithMaxEntries(2))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+	}()
+	<-started
+
+	// The placeholder of the refreshed key is the oldest entry, but it has no value to evict.
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	require.NotContains(t, cache.index, "key1")
+	require.Contains(t, cache.index, "key0")
+
+	close(release)
+	<-done
+	requireKeyExists(t, cache, "key0", "value0")
+}
@@ -13,15 +13,12 @@ type (
 		ID   int64
 		Name string
 	}
-	UserCache interface {
-		GetOrRefresh(id int64, refresh func() (*User, error)) (*User, error)
-	}
 	UsersRepository struct {
-		cache UserCache
+		cache locache.Cacher[int64, *User]
 	}
 )
 
-func NewUserRepository(cache UserCache) *UsersRepository {
+func NewUserRepository(cache locache.Cacher[int64, *User]) *UsersRepository {
 	return &UsersRepository{cache}
 }
 
@@ -41,8 +38,12 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	cache := locache.New[int64, *User](cacheTTL, locache.NewDefaultMetrics("users_cache"))
-	cache.SchedulePurge(ctx, cachePurgeInterval)
+	cache := locache.New[int64, *User](
+		locache.WithContext(ctx),
+		locache.WithTTL(cacheTTL),
+		locache.WithPurgeInterval(cachePurgeInterval),
+		locache.WithMetrics(locache.NewDefaultMetrics("users_cache")),
+	)
 
 	repo := NewUserRepository(cache)
 	user, err := repo.GetUser(777)
@@ -0,0 +1,116 @@
+package locache
+
+import (
+	"container/heap"
+	"container/list"
+	"time"
+)
+
+// expiryHeap orders items by expiration time, so Purge touches only expired items.
+// Items which never expire are not in the heap.
+type expiryHeap[Key comparable, Value any] []*Item[Key, Value]
+
+func (h expiryHeap[Key, Value]) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap[Key, Value]) Less(i, j int) bool {
+	return h[i].ExpiresAt().Before(h[j].ExpiresAt())
+}
+
+func (h expiryHeap[Key, Value]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIdx = i + 1
+	h[j].heapIdx = j + 1
+}
+
+func (h *expiryHeap[Key, Value]) Push(x any) {
+	item := x.(*Item[Key, Value]) //nolint:forcetypeassert
+	*h = append(*h, item)
+	item.heapIdx = len(*h)
+}
+
+func (h *expiryHeap[Key, Value]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	item.heapIdx = 0
+	return item
+}
+
+// update places the item according to its current expiration time.
+func (h *expiryHeap[Key, Value]) update(item *Item[Key, Value]) {
+	switch {
+	case item.ExpiresAt().IsZero():
+		h.remove(item)
+	case item.heapIdx == 0:
+		heap.Push(h, item)
+	default:
+		heap.Fix(h, item.heapIdx-1)
+	}
+}
+
+func (h *expiryHeap[Key, Value]) remove(item *Item[Key, Value]) {
+	if item.heapIdx != 0 {
+		heap.Remove(h, item.heapIdx-1)
+	}
+}
+
+// popExpired removes and returns the earliest item if it is expired at the given time.
+func (h *expiryHeap[Key, Value]) popExpired(at time.Time) (*Item[Key, Value], bool) {
+	if h.Len() == 0 || (*h)[0].ExpiresAt().After(at) {
+		return nil, false
+	}
+	return heap.Pop(h).(*Item[Key, Value]), true //nolint:forcetypeassert
+}
+
+// lazyExpiryBatch is the number of expired entries removed by every write
+// in the lazy expiration mode.
+const lazyExpiryBatch = 4
+
+// removeExpired removes up to n expired entries, skipping entries locked by a refresh.
+// It must be called under the write lock.
+func (c *Cache[Key, Value]) removeExpired(n int) {
+	var locked []*Item[Key, Value]
+	removed := 0
+	for i := 0; i < n; i++ {
+		item, ok := c.expiries.popExpired(c.clock.Now())
+		if !ok {
+			break
+		}
+		element, found := c.index[item.key]
+		if !found || c.getItem(element) != item {
+			continue
+		}
+		if !item.mtx.TryLock() {
+			locked = append(locked, item)
+			continue
+		}
+		c.removeElement(element, RemovalExpired)
+		item.mtx.Unlock()
+		removed++
+	}
+	for _, item := range locked {
+		c.expiries.update(item)
+	}
+	c.incExpired(removed)
+}
+
+// expireLazily removes the expired entry met by a read.
+func (c *Cache[Key, Value]) expireLazily(key Key, element *list.Element) {
+	c.mtx.Lock()
+	defer c.unlock()
+
+	if c.index[key] != element {
+		return
+	}
+
+	item := c.getItem(element)
+	if !item.set || !item.IsExpired(c.clock.Now()) || !item.mtx.TryLock() {
+		return
+	}
+	c.removeElement(element, RemovalExpired)
+	item.mtx.Unlock()
+	c.incExpired(1)
+}
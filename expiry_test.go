@@ -0,0 +1,187 @@
+package locache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Purge_ExpiryHeap(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+
+	cache.SetWithTTL("key0", "value0", 3*time.Second)
+	cache.SetWithTTL("key1", "value1", time.Second)
+	cache.SetWithTTL("key2", "value2", 2*time.Second)
+	cache.SetForever("key3", "value3")
+	require.Len(t, cache.expiries, 3)
+
+	require.True(t, cache.Expire("key1", 5*time.Second))
+	require.True(t, cache.Expire("key3", 4*time.Second))
+	require.Len(t, cache.expiries, 4)
+
+	clock.Advance(2 * time.Second)
+	cache.Purge()
+	require.Equal(t, []string{"key0", "key1", "key3"}, cache.Keys())
+
+	clock.Advance(2 * time.Second)
+	cache.Purge()
+	require.Equal(t, []string{"key1"}, cache.Keys())
+
+	cache.SetForever("key1", "value1")
+	require.Empty(t, cache.expiries)
+
+	clock.Advance(time.Hour)
+	cache.Purge()
+	require.Equal(t, []string{"key1"}, cache.Keys())
+}
+
+func TestCache_Purge_ExpiryHeap_LockedItem(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Second), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	item := cache.getItem(cache.index["key0"])
+	item.mtx.Lock()
+
+	clock.Advance(time.Second)
+	cache.Purge()
+	require.Equal(t, 1, cache.Len())
+	require.Len(t, cache.expiries, 1)
+
+	item.mtx.Unlock()
+	cache.Purge()
+	require.Equal(t, 0, cache.Len())
+	require.Empty(t, cache.expiries)
+}
+
+func TestCache_Purge_Batches(t *testing.T) {
+	testCases := map[string]Option{
+		"batch size":  WithPurgeBatchSize(2),
+		"time budget": WithPurgeTimeBudget(2 * time.Millisecond),
+	}
+
+	for name, opt := range testCases {
+		opt := opt
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			cache := New[int, int](WithTTL(time.Second), WithClock(clock), opt)
+			// Every read of the request clock advances the time used by the budget by one millisecond.
+			cache.requestClock = tickingClock{clock: newFakeClock(), step: time.Millisecond}
+
+			var lens []int
+			for i := 0; i < 5; i++ {
+				cache.Set(i, i)
+				cache.OnExpire(i, func(int, int) {
+					lens = append(lens, cache.Len())
+				})
+			}
+
+			clock.Advance(time.Second)
+			cache.Purge()
+			require.Equal(t, []int{3, 3, 1, 1, 0}, lens)
+		})
+	}
+}
+
+type tickingClock struct {
+	clock *fakeClock
+	step  time.Duration
+}
+
+func (c tickingClock) Now() time.Time {
+	c.clock.Advance(c.step)
+	return c.clock.Now()
+}
+
+func (c tickingClock) After(d time.Duration) <-chan time.Time {
+	return c.clock.After(d)
+}
+
+func TestCache_PurgeContext(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, int](WithTTL(time.Second), WithClock(clock), WithPurgeBatchSize(2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		cache.Set(i, i)
+		cache.OnExpire(i, func(int, int) {
+			cancel()
+		})
+	}
+
+	clock.Advance(time.Second)
+	require.ErrorIs(t, cache.PurgeContext(ctx), context.Canceled)
+	require.Equal(t, 3, cache.Len())
+	require.Len(t, cache.expiries, 3)
+
+	require.NoError(t, cache.PurgeContext(context.Background()))
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCache_LazyExpiration(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, int](WithTTL(time.Second), WithClock(clock), WithLazyExpiration())
+
+	for i := 0; i < 10; i++ {
+		cache.Set(i, i)
+	}
+	clock.Advance(time.Second)
+
+	_, ok := cache.Get(0)
+	require.False(t, ok)
+	require.Equal(t, 9, cache.Len())
+
+	cache.Set(10, 10)
+	require.Equal(t, 10-lazyExpiryBatch, cache.Len())
+
+	cache.Del(10)
+	require.Equal(t, 9-2*lazyExpiryBatch, cache.Len())
+
+	cache.Purge()
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCache_LazyExpiration_SkipsRefreshing(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Second), WithClock(clock), WithLazyExpiration())
+	cache.Set("key0", "value0")
+	clock.Advance(time.Second)
+
+	item := cache.getItem(cache.index["key0"])
+	item.mtx.Lock()
+
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+	cache.Set("key1", "value1")
+	require.Equal(t, 2, cache.Len())
+
+	item.mtx.Unlock()
+	cache.Del("key1")
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCache_IncrementalExpiry(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[int, int](WithTTL(time.Second), WithClock(clock), WithIncrementalExpiry(2))
+
+	for i := 0; i < 5; i++ {
+		cache.Set(i, i)
+	}
+	clock.Advance(time.Second)
+
+	_, ok := cache.Get(0)
+	require.False(t, ok)
+	require.Equal(t, 5, cache.Len())
+
+	cache.Set(5, 5)
+	require.Equal(t, 4, cache.Len())
+
+	cache.Del(5)
+	require.Equal(t, 1, cache.Len())
+}
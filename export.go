@@ -0,0 +1,82 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// exportChunkSize is the number of the entries copied by Export under one read lock.
+const exportChunkSize = 1024
+
+// Export calls fn for each valid entry with its expiration time, the zero time means
+// the entry never expires. Export copies the keys and then the entries in chunks
+// of exportChunkSize under the read lock and calls fn without holding it, so fn may
+// call the cache. The entries deleted or expired meanwhile are skipped, the entries
+// stored after Export started are not passed, each entry is passed at most once.
+// It stops at the first error of fn or when the context is done and returns the error.
+func (c *Cache[Key, Value]) Export(ctx context.Context, fn func(key Key, value Value, exp time.Time) error) error {
+	c.mtx.RLock()
+	keys := make([]Key, 0, c.items.Len())
+	for element := c.items.Front(); element != nil; element = element.Next() {
+		keys = append(keys, c.getItem(element).key)
+	}
+	c.mtx.RUnlock()
+
+	entries := make([]snapshotEntry[Key, Value], 0, min(len(keys), exportChunkSize))
+	for len(keys) > 0 {
+		chunk := keys[:min(len(keys), exportChunkSize)]
+		keys = keys[len(chunk):]
+
+		entries = c.exportChunk(chunk, entries[:0])
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(entry.Key, entry.Value, entry.Exp); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// exportChunk appends the valid entries of the keys to entries under the read lock.
+func (c *Cache[Key, Value]) exportChunk(keys []Key, entries []snapshotEntry[Key, Value]) []snapshotEntry[Key, Value] {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	for _, key := range keys {
+		element, found := c.index[key]
+		if !found {
+			continue
+		}
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			entries = append(entries, snapshotEntry[Key, Value]{Key: item.key, Value: item.val, Exp: item.ExpiresAt()})
+		}
+	}
+	return entries
+}
+
+// Import stores the entries returned by next until it returns io.EOF. The entries
+// are stored with their remaining TTLs like Restore does, the zero expiration time
+// means the entry never expires and the expired entries are skipped. Import stops
+// at the first other error of next or when the context is done and returns the error.
+func (c *Cache[Key, Value]) Import(ctx context.Context, next func() (key Key, value Value, exp time.Time, err error)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		key, value, exp, err := next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		c.restoreEntry(snapshotEntry[Key, Value]{Key: key, Value: value, Exp: exp})
+	}
+}
@@ -0,0 +1,119 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type exportedEntry struct {
+	key string
+	val string
+	exp time.Time
+}
+
+func TestCache_ExportImport(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "val0")
+	cache.SetForever("key1", "val1")
+	cache.SetWithTTL("key2", "val2", time.Second)
+
+	clock.Advance(2 * time.Second)
+
+	var entries []exportedEntry
+	require.NoError(t, cache.Export(context.Background(), func(key, val string, exp time.Time) error {
+		entries = append(entries, exportedEntry{key: key, val: val, exp: exp})
+		return nil
+	}))
+	require.ElementsMatch(t, []exportedEntry{
+		{key: "key0", val: "val0", exp: clock.Now().Add(58 * time.Second)},
+		{key: "key1", val: "val1"},
+	}, entries)
+
+	// The entry expired since the export is not imported.
+	entries = append(entries, exportedEntry{key: "key3", val: "val3", exp: clock.Now().Add(-time.Second)})
+
+	imported := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, imported.Import(context.Background(), func() (string, string, time.Time, error) {
+		if len(entries) == 0 {
+			return "", "", time.Time{}, io.EOF
+		}
+		entry := entries[0]
+		entries = entries[1:]
+		return entry.key, entry.val, entry.exp, nil
+	}))
+
+	require.ElementsMatch(t, []string{"key0", "key1"}, imported.Keys())
+	ttl, ok := imported.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 58*time.Second, ttl)
+
+	ttl, ok = imported.TTL("key1")
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), ttl)
+}
+
+func TestCache_Export_Error(t *testing.T) {
+	cache := New[string, string]()
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+
+	calls := 0
+	err := cache.Export(context.Background(), func(string, string, time.Time) error {
+		calls++
+		return fmt.Errorf("some error")
+	})
+	require.EqualError(t, err, "some error")
+	require.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, cache.Export(ctx, func(string, string, time.Time) error { return nil }), context.Canceled)
+}
+
+func TestCache_Import_Error(t *testing.T) {
+	cache := New[string, string]()
+
+	err := cache.Import(context.Background(), func() (string, string, time.Time, error) {
+		return "", "", time.Time{}, fmt.Errorf("some error")
+	})
+	require.EqualError(t, err, "some error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = cache.Import(ctx, func() (string, string, time.Time, error) {
+		return "key", "val", time.Time{}, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCache_Export_Chunks(t *testing.T) {
+	cache := New[int, int]()
+	for i := 0; i < exportChunkSize+10; i++ {
+		cache.Set(i, i)
+	}
+
+	// fn is called without the lock: it may write the cache, the deleted entries
+	// of the next chunk are skipped.
+	exported := make(map[int]int)
+	require.NoError(t, cache.Export(context.Background(), func(key, val int, _ time.Time) error {
+		if len(exported) == 0 {
+			for i := 0; i < exportChunkSize+10; i++ {
+				cache.Del(i)
+			}
+		}
+		exported[key] = val
+		return nil
+	}))
+	require.Len(t, exported, exportChunkSize)
+	for key, val := range exported {
+		require.Equal(t, key, val)
+	}
+	require.Zero(t, cache.Len())
+}
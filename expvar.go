@@ -0,0 +1,98 @@
+package locache
+
+import (
+	"expvar"
+	"time"
+)
+
+// ExpvarMetrics publishes the cache stats via expvar as a map under the prefix:
+// hits, misses, errors and evictions by method, the items count and purge stats.
+// Request timings are not published.
+type ExpvarMetrics struct {
+	hits      *expvar.Map
+	misses    *expvar.Map
+	errors    *expvar.Map
+	evictions *expvar.Map
+	items     *expvar.Int
+
+	purges        *expvar.Int
+	purgeScanned  *expvar.Int
+	purgeRemoved  *expvar.Int
+	purgeTimeMs   *expvar.Float
+	lastPurgeTime *expvar.String
+
+	clock Clock
+}
+
+// NewExpvarMetrics publishes the metrics, like expvar.Publish it panics
+// when the prefix is already used.
+func NewExpvarMetrics(prefix string) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		hits:      new(expvar.Map).Init(),
+		misses:    new(expvar.Map).Init(),
+		errors:    new(expvar.Map).Init(),
+		evictions: new(expvar.Map).Init(),
+		items:     new(expvar.Int),
+
+		purges:        new(expvar.Int),
+		purgeScanned:  new(expvar.Int),
+		purgeRemoved:  new(expvar.Int),
+		purgeTimeMs:   new(expvar.Float),
+		lastPurgeTime: new(expvar.String),
+
+		clock: systemClock{},
+	}
+
+	root := expvar.NewMap(prefix)
+	root.Set("hits", m.hits)
+	root.Set("misses", m.misses)
+	root.Set("errors", m.errors)
+	root.Set("evictions", m.evictions)
+	root.Set("items", m.items)
+	root.Set("purges", m.purges)
+	root.Set("purge_scanned", m.purgeScanned)
+	root.Set("purge_removed", m.purgeRemoved)
+	root.Set("purge_time_ms", m.purgeTimeMs)
+	root.Set("last_purge_time", m.lastPurgeTime)
+
+	return m
+}
+
+// WithClock returns a copy of the metrics which timestamps the purges with the clock,
+// it should be the clock of the cache passed to WithClock.
+// The copy shares the published variables with the original metrics.
+func (m *ExpvarMetrics) WithClock(clock Clock) *ExpvarMetrics {
+	cp := *m
+	cp.clock = clock
+	return &cp
+}
+
+func (m *ExpvarMetrics) IncHits(method string) {
+	m.hits.Add(method, 1)
+}
+
+func (m *ExpvarMetrics) IncMisses(method string) {
+	m.misses.Add(method, 1)
+}
+
+func (m *ExpvarMetrics) IncErrors(method string) {
+	m.errors.Add(method, 1)
+}
+
+func (m *ExpvarMetrics) IncEvictions(method string) {
+	m.evictions.Add(method, 1)
+}
+
+func (m *ExpvarMetrics) ObserveRequest(_ string, _ time.Time) {}
+
+func (m *ExpvarMetrics) SetItemsCount(count int) {
+	m.items.Set(int64(count))
+}
+
+func (m *ExpvarMetrics) ObservePurge(scanned, removed int, duration time.Duration) {
+	m.purges.Add(1)
+	m.purgeScanned.Add(int64(scanned))
+	m.purgeRemoved.Add(int64(removed))
+	m.purgeTimeMs.Add(milliseconds(duration))
+	m.lastPurgeTime.Set(m.clock.Now().Format(time.RFC3339Nano))
+}
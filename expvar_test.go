@@ -0,0 +1,33 @@
+package locache
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	clock := &fakeClock{current: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	mtr := NewExpvarMetrics("locache_test").WithClock(clock)
+	cache := New[string, string](WithTTL(time.Minute), WithMetrics(mtr), WithClock(clock))
+
+	cache.Set("key0", "value0")
+	cache.Get("key0")
+	cache.Get("key1")
+	cache.Purge()
+
+	var stats map[string]any
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("locache_test").String()), &stats))
+
+	require.Equal(t, map[string]any{"get": 1.0}, stats["hits"])
+	require.Equal(t, map[string]any{"get": 1.0}, stats["misses"])
+	require.Equal(t, 1.0, stats["items"])
+	require.Equal(t, 1.0, stats["purges"])
+	require.Equal(t, "2024-01-02T03:04:05Z", stats["last_purge_time"])
+
+	require.Panics(t, func() { NewExpvarMetrics("locache_test") })
+}
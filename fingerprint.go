@@ -0,0 +1,73 @@
+package locache
+
+import (
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// typeFingerprint hashes the name and the structure of the type, so the fingerprint
+// changes when a field of a struct is added, removed, renamed or changes its type.
+func typeFingerprint[T any]() uint64 {
+	var b strings.Builder
+	describeType(&b, reflect.TypeOf((*T)(nil)).Elem(), make(map[reflect.Type]bool))
+
+	h := fnv.New64a()
+	h.Write([]byte(b.String())) //nolint:errcheck
+	return h.Sum64()
+}
+
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// describeType writes the name of the named type followed by the description
+// of its underlying type, inProgress breaks the recursion of the recursive types.
+func describeType(b *strings.Builder, t reflect.Type, inProgress map[reflect.Type]bool) {
+	if t.Name() != "" {
+		b.WriteString(t.PkgPath())
+		b.WriteByte('.')
+		b.WriteString(t.Name())
+
+		if inProgress[t] {
+			return
+		}
+		inProgress[t] = true
+		defer delete(inProgress, t)
+		b.WriteByte('=')
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		b.WriteByte('*')
+		describeType(b, t.Elem(), inProgress)
+	case reflect.Slice:
+		b.WriteString("[]")
+		describeType(b, t.Elem(), inProgress)
+	case reflect.Array:
+		b.WriteString("[" + strconv.Itoa(t.Len()) + "]")
+		describeType(b, t.Elem(), inProgress)
+	case reflect.Map:
+		b.WriteString("map[")
+		describeType(b, t.Key(), inProgress)
+		b.WriteByte(']')
+		describeType(b, t.Elem(), inProgress)
+	case reflect.Struct:
+		b.WriteString("struct{")
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			b.WriteString(field.Name)
+			b.WriteByte(' ')
+			describeType(b, field.Type, inProgress)
+			b.WriteByte(';')
+		}
+		b.WriteByte('}')
+	default:
+		if t.Name() == "" {
+			b.WriteString(t.String())
+		} else {
+			b.WriteString(t.Kind().String())
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package flight deduplicates concurrent calls of the same key: the first caller
+// runs the function, others wait for its result. It is the primitive behind
+// GetOrRefresh of the locache read-mostly and dense caches, usable for operations
+// which are not cached.
+package flight
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errGoexit is returned to the callers waiting for the function which called runtime.Goexit.
+var errGoexit = errors.New("flight: function called runtime.Goexit")
+
+// PanicError is returned to the callers waiting for the function which panicked,
+// the caller which ran the function panics with the same value.
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("flight: function panicked: %v", e.Value)
+}
+
+type call[Value any] struct {
+	done chan struct{}
+	val  Value
+	err  error
+}
+
+// Group deduplicates the calls by key. The zero value is ready to use.
+type Group[Key comparable, Value any] struct {
+	mtx   sync.Mutex
+	calls map[Key]*call[Value]
+}
+
+// DoOnce runs fn unless a call of the key is in flight, in that case it waits
+// for the result of that call. The key is forgotten when fn returns,
+// later calls run fn again.
+func (g *Group[Key, Value]) DoOnce(key Key, fn func() (Value, error)) (Value, error) {
+	g.mtx.Lock()
+	if c, found := g.calls[key]; found {
+		g.mtx.Unlock()
+		<-c.done
+		return c.val, c.err
+	}
+
+	if g.calls == nil {
+		g.calls = make(map[Key]*call[Value])
+	}
+
+	c := &call[Value]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mtx.Unlock()
+
+	returned := false
+	defer func() {
+		var recovered any
+		if !returned {
+			// The nil value means fn called runtime.Goexit.
+			if recovered = recover(); recovered != nil {
+				c.err = &PanicError{Value: recovered}
+			} else {
+				c.err = errGoexit
+			}
+		}
+
+		g.forget(key, c)
+		close(c.done)
+
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+
+	c.val, c.err = fn()
+	returned = true
+
+	return c.val, c.err
+}
+
+// Forget makes the next calls of the key run the function
+// instead of waiting for the call in flight.
+func (g *Group[Key, Value]) Forget(key Key) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	delete(g.calls, key)
+}
+
+func (g *Group[Key, Value]) forget(key Key, c *call[Value]) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,99 @@
+package flight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup_DoOnce(t *testing.T) {
+	var (
+		group   Group[string, int]
+		calls   atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			val, err := group.DoOnce("key", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 42, val)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+
+	// The key is forgotten when the call returns.
+	_, err := group.DoOnce("key", func() (int, error) {
+		calls.Add(1)
+		return 0, errors.New("failed")
+	})
+	require.EqualError(t, err, "failed")
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestGroup_Forget(t *testing.T) {
+	var group Group[int, string]
+	started, release := make(chan struct{}), make(chan struct{})
+
+	go group.DoOnce(1, func() (string, error) { //nolint:errcheck
+		close(started)
+		<-release
+		return "first", nil
+	})
+	<-started
+
+	group.Forget(1)
+	val, err := group.DoOnce(1, func() (string, error) { return "second", nil })
+	require.NoError(t, err)
+	require.Equal(t, "second", val)
+	close(release)
+}
+
+func TestGroup_Panic(t *testing.T) {
+	var group Group[int, int]
+	started, release := make(chan struct{}), make(chan struct{})
+	waited := make(chan error)
+
+	go func() {
+		defer func() { recover() }() //nolint:errcheck
+
+		group.DoOnce(1, func() (int, error) { //nolint:errcheck
+			close(started)
+			<-release
+			panic("failed")
+		})
+	}()
+	<-started
+
+	go func() {
+		_, err := group.DoOnce(1, func() (int, error) { return 0, nil })
+		waited <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	var panicErr *PanicError
+	require.ErrorAs(t, <-waited, &panicErr)
+	require.Equal(t, "failed", panicErr.Value)
+
+	require.PanicsWithValue(t, "failed", func() {
+		group.DoOnce(1, func() (int, error) { panic("failed") }) //nolint:errcheck
+	})
+}
@@ -0,0 +1,109 @@
+package locache
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// hashKey returns a hash of the key which is stable for the given seed.
+func hashKey[Key comparable](seed maphash.Seed, key Key) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return maphash.String(seed, k)
+	case int:
+		return mixHash(seed, uint64(k))
+	case int8:
+		return mixHash(seed, uint64(k))
+	case int16:
+		return mixHash(seed, uint64(k))
+	case int32:
+		return mixHash(seed, uint64(k))
+	case int64:
+		return mixHash(seed, uint64(k))
+	case uint:
+		return mixHash(seed, uint64(k))
+	case uint8:
+		return mixHash(seed, uint64(k))
+	case uint16:
+		return mixHash(seed, uint64(k))
+	case uint32:
+		return mixHash(seed, uint64(k))
+	case uint64:
+		return mixHash(seed, k)
+	case uintptr:
+		return mixHash(seed, uint64(k))
+	default:
+		var h maphash.Hash
+		h.SetSeed(seed)
+		writeHash(&h, reflect.ValueOf(&key).Elem())
+		return h.Sum64()
+	}
+}
+
+func mixHash(seed maphash.Seed, v uint64) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	writeHashUint64(&h, v)
+	return h.Sum64()
+}
+
+// writeHash writes the comparable value to the hash field by field, so the values equal
+// by == have the same hash: -0.0 and +0.0 are written as zero and the blank fields are skipped.
+func writeHash(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1) //nolint:errcheck
+		} else {
+			h.WriteByte(0) //nolint:errcheck
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeHashUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeHashUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeHashFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeHashFloat(h, real(v.Complex()))
+		writeHashFloat(h, imag(v.Complex()))
+	case reflect.String:
+		// The length keeps the adjacent strings of the struct unambiguous.
+		writeHashUint64(h, uint64(v.Len()))
+		h.WriteString(v.String()) //nolint:errcheck
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeHashUint64(h, uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			writeHash(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name != "_" {
+				writeHash(h, v.Field(i))
+			}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0) //nolint:errcheck
+			return
+		}
+		h.WriteByte(1) //nolint:errcheck
+		writeHash(h, v.Elem())
+	}
+}
+
+func writeHashUint64(h *maphash.Hash, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	h.Write(buf[:]) //nolint:errcheck
+}
+
+func writeHashFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		// -0.0 == +0.0
+		f = 0
+	}
+	writeHashUint64(h, math.Float64bits(f))
+}
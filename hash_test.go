@@ -0,0 +1,46 @@
+package locache
+
+import (
+	"hash/maphash"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hashPoint struct {
+	X, Y float64
+	Name string
+	_    int
+	Tag  any
+}
+
+func TestHashKey_Struct(t *testing.T) {
+	seed := maphash.MakeSeed()
+
+	negZero := math.Copysign(0, -1)
+	require.Equal(t, hashPoint{X: 0}, hashPoint{X: negZero})
+	require.Equal(t, hashKey(seed, hashPoint{X: 0}), hashKey(seed, hashPoint{X: negZero}))
+
+	require.Equal(t,
+		hashKey(seed, hashPoint{X: 1, Y: 2, Name: "a", Tag: 1}),
+		hashKey(seed, hashPoint{X: 1, Y: 2, Name: "a", Tag: 1}),
+	)
+	require.NotEqual(t, hashKey(seed, hashPoint{X: 1}), hashKey(seed, hashPoint{Y: 1}))
+	require.NotEqual(t, hashKey(seed, hashPoint{Name: "a"}), hashKey(seed, hashPoint{Name: "a", Tag: ""}))
+
+	// The adjacent strings are not concatenated.
+	type pair struct{ A, B string }
+	require.NotEqual(t, hashKey(seed, pair{A: "ab", B: "c"}), hashKey(seed, pair{A: "a", B: "bc"}))
+}
+
+func TestHashKey_Allocs(t *testing.T) {
+	seed := maphash.MakeSeed()
+	key := hashPoint{X: 1, Y: 2, Name: "a"}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		hashKey(seed, key)
+	})
+	// The key is only copied to the heap for the reflection.
+	require.LessOrEqual(t, allocs, 1.0)
+}
@@ -0,0 +1,51 @@
+package locache
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// defaultRingReplicas is the number of points of each peer on the ring.
+const defaultRingReplicas = 64
+
+// HashRing assigns keys to peers with consistent hashing: each peer has several points
+// on the ring and the key belongs to the peer of the first point after the hash of the key,
+// so adding or removing a peer moves only the keys of its points.
+type HashRing struct {
+	replicas int
+	hashes   []uint32
+	peers    map[uint32]string
+}
+
+// NewHashRing creates the ring, replicas <= 0 uses 64 points per peer.
+func NewHashRing(replicas int, peers ...string) *HashRing {
+	if replicas <= 0 {
+		replicas = defaultRingReplicas
+	}
+
+	r := &HashRing{replicas: replicas, peers: make(map[uint32]string, replicas*len(peers))}
+	for _, peer := range peers {
+		for i := 0; i < replicas; i++ {
+			hash := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + peer))
+			r.hashes = append(r.hashes, hash)
+			r.peers[hash] = peer
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Get returns the peer of the key, the empty ring returns the empty string.
+func (r *HashRing) Get(key []byte) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	hash := crc32.ChecksumIEEE(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.peers[r.hashes[i]]
+}
@@ -0,0 +1,34 @@
+package locache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRing(t *testing.T) {
+	require.Equal(t, "", NewHashRing(0).Get([]byte("key")))
+
+	ring := NewHashRing(0, "peer0", "peer1", "peer2")
+
+	owners := map[string]string{}
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		key := "key" + strconv.Itoa(i)
+		owners[key] = ring.Get([]byte(key))
+		counts[owners[key]]++
+	}
+	require.Len(t, counts, 3)
+	for _, count := range counts {
+		require.Greater(t, count, 500)
+	}
+
+	// Only the keys of the new peer move.
+	ring = NewHashRing(0, "peer0", "peer1", "peer2", "peer3")
+	for key, owner := range owners {
+		if newOwner := ring.Get([]byte(key)); newOwner != owner {
+			require.Equal(t, "peer3", newOwner)
+		}
+	}
+}
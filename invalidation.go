@@ -0,0 +1,109 @@
+package locache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// invalidationRetryInterval is the pause before the failed subscription is restarted.
+const invalidationRetryInterval = time.Second
+
+// Bus carries the invalidation messages between the replicas of the cache,
+// e.g. Redis pub/sub (locacheredis.NewBus).
+type Bus interface {
+	// Publish sends the message to all subscribers, including the publisher.
+	Publish(ctx context.Context, msg []byte) error
+	// Subscribe calls fn for each message until the context is done.
+	Subscribe(ctx context.Context, fn func(msg []byte)) error
+}
+
+// newOrigin returns the random id of the replica which skips its own messages.
+func newOrigin() string {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(fmt.Sprintf("locache: read random origin: %v", err))
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// publishInvalidation schedules the message with the key written or deleted by this replica,
+// it must be called under the write lock.
+func (c *Cache[Key, Value]) publishInvalidation(key Key) {
+	if c.bus == nil || c.invalidating {
+		return
+	}
+
+	c.callbacks = append(c.callbacks, func() {
+		msg, err := c.appendKey(appendFrame(nil, []byte(c.origin)), key)
+		if err == nil {
+			err = c.bus.Publish(c.ctx, msg)
+		}
+		if err != nil {
+			c.logger.busFailed("publish", key, err)
+		}
+	})
+}
+
+// subscribeInvalidations removes the keys written or deleted by other replicas
+// until the cache is closed.
+func (c *Cache[Key, Value]) subscribeInvalidations() {
+	if !c.beginWork() {
+		return
+	}
+
+	go func() {
+		defer c.work.Done()
+		for {
+			err := c.bus.Subscribe(c.ctx, c.invalidate)
+			if c.ctx.Err() != nil {
+				return
+			}
+			c.logger.busFailed("subscribe", nil, err)
+
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-c.clock.After(invalidationRetryInterval):
+			}
+		}
+	}()
+}
+
+// invalidate deletes the key of the message published by another replica without publishing it again.
+func (c *Cache[Key, Value]) invalidate(msg []byte) {
+	r := bufio.NewReader(bytes.NewReader(msg))
+
+	origin, err := readFrame(r)
+	if err != nil {
+		c.logger.busFailed("invalidate", nil, err)
+		return
+	}
+	if string(origin) == c.origin {
+		return
+	}
+
+	key, err := c.readKey(r)
+	if err != nil {
+		c.logger.busFailed("invalidate", nil, err)
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	c.invalidating = true
+	defer func() { c.invalidating = false }()
+
+	if element, found := c.index[key]; found {
+		c.auditReason = "invalidated"
+		c.deleteElement(element)
+		c.auditReason = ""
+		c.incRemoved(MethodDel)
+	}
+	c.unspill(key)
+}
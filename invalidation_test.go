@@ -0,0 +1,178 @@
+package locache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBus delivers the messages to the subscribers of the process.
+type memoryBus struct {
+	mtx         sync.Mutex
+	subscribers map[int]func([]byte)
+	nextID      int
+	published   int
+	err         error
+}
+
+func newMemoryBus() *memoryBus {
+	return &memoryBus{subscribers: map[int]func([]byte){}}
+}
+
+func (b *memoryBus) Publish(_ context.Context, msg []byte) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+	b.published++
+	for _, fn := range b.subscribers {
+		fn(msg)
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context, fn func([]byte)) error {
+	b.mtx.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = fn
+	b.mtx.Unlock()
+
+	<-ctx.Done()
+
+	b.mtx.Lock()
+	delete(b.subscribers, id)
+	b.mtx.Unlock()
+	return nil
+}
+
+func (b *memoryBus) publishes() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.published
+}
+
+func (b *memoryBus) subscribed() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return len(b.subscribers)
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	bus := newMemoryBus()
+	replica0 := New[string, string](WithInvalidation(bus))
+	replica1 := New[string, string](WithInvalidation(bus))
+	require.Eventually(t, func() bool { return bus.subscribed() == 2 }, time.Second, time.Millisecond)
+
+	replica0.Set("key0", "val0")
+	replica1.Set("key0", "val1")
+
+	// The replica which wrote the key keeps its value.
+	_, ok := replica0.Get("key0")
+	require.False(t, ok)
+	val, ok := replica1.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, "val1", val)
+
+	replica0.Set("key1", "val1")
+	replica1.Set("key1", "val1")
+	replica0.Del("key1")
+	require.Equal(t, []string{"key0"}, replica1.Keys())
+
+	// The values of GetOrRefresh are not published.
+	_, err := replica0.GetOrRefresh("key2", func() (string, error) { return "val2", nil })
+	require.NoError(t, err)
+	_, err = replica1.GetOrRefresh("key2", func() (string, error) { return "val2", nil })
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"key2"}, replica0.Keys())
+	require.ElementsMatch(t, []string{"key0", "key2"}, replica1.Keys())
+
+	require.NoError(t, replica0.Close())
+	require.NoError(t, replica1.Close())
+	require.Equal(t, 0, bus.subscribed())
+}
+
+func TestCache_Invalidation_Audit(t *testing.T) {
+	bus := newMemoryBus()
+	replica0 := New[string, string](WithInvalidation(bus))
+	replica1 := New[string, string](WithInvalidation(bus), WithAudit(10))
+	defer replica0.Close()
+	defer replica1.Close()
+	require.Eventually(t, func() bool { return bus.subscribed() == 2 }, time.Second, time.Millisecond)
+
+	replica1.Set("key", "val")
+	replica0.Del("key")
+
+	log := replica1.AuditLog()
+	require.Len(t, log, 2)
+	require.Equal(t, ChangeDelete, log[1].Op)
+	require.Equal(t, "invalidated", log[1].Reason)
+}
+
+func TestCache_Invalidation_PublishError(t *testing.T) {
+	bus := newMemoryBus()
+	bus.err = fmt.Errorf("some error")
+
+	cache := New[string, string](WithInvalidation(bus))
+	defer cache.Close()
+
+	cache.Set("key", "val")
+	val, ok := cache.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "val", val)
+}
+
+func TestCache_Invalidation_Restore(t *testing.T) {
+	source := New[string, string](WithTTL(time.Minute))
+	source.Set("key0", "val0")
+	source.Set("key1", "val1")
+	source.SetForever("key2", "val2")
+
+	var snapshot bytes.Buffer
+	require.NoError(t, source.Snapshot(&snapshot))
+
+	bus := newMemoryBus()
+	cache := New[string, string](WithInvalidation(bus))
+	defer cache.Close()
+
+	// The restored, imported and replayed entries are not news for other replicas.
+	require.NoError(t, cache.Restore(&snapshot))
+	require.Equal(t, 3, cache.Len())
+
+	keys := []string{"key3"}
+	require.NoError(t, cache.Import(context.Background(), func() (string, string, time.Time, error) {
+		if len(keys) == 0 {
+			return "", "", time.Time{}, io.EOF
+		}
+		key := keys[0]
+		keys = keys[1:]
+		return key, "val", time.Time{}, nil
+	}))
+	require.Equal(t, 4, cache.Len())
+
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	wal := New[string, string]()
+	require.NoError(t, wal.OpenWAL(path))
+	wal.Set("key0", "val0")
+	wal.Set("key4", "val4")
+	wal.Del("key0")
+	require.NoError(t, wal.Close())
+
+	require.NoError(t, cache.OpenWAL(path))
+	require.ElementsMatch(t, []string{"key1", "key2", "key3", "key4"}, cache.Keys())
+	require.Equal(t, 0, bus.publishes())
+
+	cache.Set("key5", "val5")
+	require.Eventually(t, func() bool { return bus.publishes() == 1 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,31 @@
+package locache
+
+import "context"
+
+// Loader loads the value of the key which is missing in the cache.
+type Loader[Key comparable, Value any] func(ctx context.Context, key Key) (Value, error)
+
+// Loading is a cache which loads missing values with the fixed loader.
+type Loading[Key comparable, Value any] struct {
+	cache  *Cache[Key, Value]
+	loader Loader[Key, Value]
+}
+
+func NewLoading[Key comparable, Value any](loader Loader[Key, Value], opts ...Option) *Loading[Key, Value] {
+	return &Loading[Key, Value]{
+		cache:  New[Key, Value](opts...),
+		loader: loader,
+	}
+}
+
+// Get returns the cached value of the key or loads it with the loader.
+func (l *Loading[Key, Value]) Get(ctx context.Context, key Key) (Value, error) {
+	return l.cache.GetOrRefreshCtx(ctx, key, func(ctx context.Context) (Value, error) {
+		return l.loader(ctx, key)
+	})
+}
+
+// Cache returns the underlying cache, e.g. to invalidate or to close it.
+func (l *Loading[Key, Value]) Cache() *Cache[Key, Value] {
+	return l.cache
+}
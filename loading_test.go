@@ -0,0 +1,37 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoading_Get(t *testing.T) {
+	calls := 0
+	cache := NewLoading(func(_ context.Context, key int) (string, error) {
+		calls++
+		if key < 0 {
+			return "", errors.New("negative key")
+		}
+		return strconv.Itoa(key), nil
+	}, WithTTL(time.Minute))
+
+	for i := 0; i < 2; i++ {
+		actual, err := cache.Get(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, "1", actual)
+	}
+	require.Equal(t, 1, calls)
+
+	_, err := cache.Get(context.Background(), -1)
+	require.Error(t, err)
+
+	cache.Cache().Del(1)
+	_, err = cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+}
@@ -0,0 +1,142 @@
+// Package locachebolt implements locache.Store on top of a bbolt database, so the spilled
+// entries (locache.WithSpill) or the second level of a tiered cache survive restarts.
+package locachebolt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var now = time.Now
+
+// Store keeps the values in a bucket of the database with their expiration times.
+// The expired values are removed when they are read or by Purge.
+type Store struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// New creates the bucket if it doesn't exist. The database is owned by the caller.
+func New(db *bolt.DB, bucket string) (*Store, error) {
+	s := &Store{db: db, bucket: []byte(bucket)}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create bucket: %w", err)
+	}
+	return s, nil
+}
+
+// Get implements locache.Store.
+func (s *Store) Get(_ context.Context, key string) ([]byte, bool, error) {
+	var (
+		value   []byte
+		expired bool
+	)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(s.bucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+
+		exp, n := binary.Varint(data)
+		if n <= 0 {
+			return errors.New("corrupted expiration")
+		}
+		if isExpired(exp) {
+			expired = true
+			return nil
+		}
+
+		// The data is valid only during the transaction.
+		value = append([]byte(nil), data[n:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("get %q: %w", key, err)
+	}
+
+	if expired {
+		return nil, false, s.Del(context.Background(), key)
+	}
+	return value, value != nil, nil
+}
+
+// Set implements locache.Store.
+func (s *Store) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	var exp int64
+	if ttl > 0 {
+		exp = now().Add(ttl).UnixNano()
+	}
+
+	data := binary.AppendVarint(make([]byte, 0, binary.MaxVarintLen64+len(value)), exp)
+	data = append(data, value...)
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Del implements locache.Store.
+func (s *Store) Del(_ context.Context, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("del %q: %w", key, err)
+	}
+	return nil
+}
+
+// Purge removes the expired values and returns their number.
+func (s *Store) Purge(ctx context.Context) (int, error) {
+	removed := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+
+		// The bucket can't be modified while it is iterated.
+		var keys [][]byte
+		err := bucket.ForEach(func(key, data []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if exp, n := binary.Varint(data); n <= 0 || isExpired(exp) {
+				keys = append(keys, append([]byte(nil), key...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		removed = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("purge: %w", err)
+	}
+	return removed, nil
+}
+
+func isExpired(exp int64) bool {
+	return exp != 0 && now().UnixNano() >= exp
+}
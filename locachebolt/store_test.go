@@ -0,0 +1,87 @@
+package locachebolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/atkhx/locache"
+)
+
+func newStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "cache.db"), 0o600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	store, err := New(db, "cache")
+	require.NoError(t, err)
+	return store
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	_, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val0"), 0))
+	require.NoError(t, store.Set(ctx, "key", []byte("val1"), time.Minute))
+
+	val, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("val1"), val)
+
+	require.NoError(t, store.Del(ctx, "key"))
+	require.NoError(t, store.Del(ctx, "key"))
+
+	_, found, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestStore_Expired(t *testing.T) {
+	defer func(n func() time.Time) { now = n }(now)
+
+	ctx := context.Background()
+	store := newStore(t)
+
+	require.NoError(t, store.Set(ctx, "key0", []byte("val0"), time.Second))
+	require.NoError(t, store.Set(ctx, "key1", []byte("val1"), time.Second))
+	require.NoError(t, store.Set(ctx, "key2", []byte("val2"), 0))
+	require.NoError(t, store.Set(ctx, "key3", []byte("val3"), time.Second))
+
+	at := time.Now().Add(time.Second)
+	now = func() time.Time { return at }
+
+	_, found, err := store.Get(ctx, "key0")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	removed, err := store.Purge(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, removed)
+
+	val, found, err := store.Get(ctx, "key2")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("val2"), val)
+}
+
+func TestStore_Spill(t *testing.T) {
+	store := newStore(t)
+
+	cache := locache.New[string, string](locache.WithMaxEntries(1), locache.WithSpill(store))
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+
+	val, ok := cache.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, "val0", val)
+}
@@ -0,0 +1,62 @@
+package locachegrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/atkhx/locache"
+)
+
+// Client calls the cache served by Server.
+type Client struct {
+	client CacheClient
+}
+
+var _ locache.Store = (*Client)(nil)
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{client: NewCacheClient(conn)}
+}
+
+// Get implements locache.Store.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	resp, err := c.client.Get(ctx, &GetRequest{Key: key})
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.GetValue(), resp.GetFound(), nil
+}
+
+// Set implements locache.Store, a ttl <= 0 means the value never expires.
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ttlMs := int64(-1)
+	if ttl > 0 {
+		// The TTLs shorter than a millisecond are not rounded down to the cache TTL.
+		ttlMs = max(ttl.Milliseconds(), 1)
+	}
+	return c.SetWithTTLMs(ctx, key, value, ttlMs)
+}
+
+// SetWithTTLMs stores the value with the TTL in milliseconds: 0 means the TTL of the cache
+// and a negative value means the value never expires.
+func (c *Client) SetWithTTLMs(ctx context.Context, key string, value []byte, ttlMs int64) error {
+	_, err := c.client.Set(ctx, &SetRequest{Key: key, Value: value, TtlMs: ttlMs})
+	return err
+}
+
+// Del implements locache.Store.
+func (c *Client) Del(ctx context.Context, key string) error {
+	_, err := c.client.Del(ctx, &DelRequest{Key: key})
+	return err
+}
+
+// GetOrRefresh returns the cached value or the value loaded by the loader of the server.
+func (c *Client) GetOrRefresh(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.client.GetOrRefresh(ctx, &GetRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetValue(), nil
+}
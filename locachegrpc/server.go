@@ -0,0 +1,80 @@
+// Package locachegrpc exposes a locache.Cache[string, []byte] over gRPC (locache.proto),
+// so a small shared cache node serves clients of any language. The Go client implements
+// locache.Store and can be the second level of the tiered cache.
+package locachegrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/atkhx/locache"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative locache.proto
+
+// Server serves the cache, the loader loads the values of GetOrRefresh.
+type Server struct {
+	UnimplementedCacheServer
+
+	cache  *locache.Cache[string, []byte]
+	loader locache.Loader[string, []byte]
+}
+
+var _ CacheServer = (*Server)(nil)
+
+// NewServer creates the server, the nil loader makes GetOrRefresh fail with FailedPrecondition.
+func NewServer(cache *locache.Cache[string, []byte], loader locache.Loader[string, []byte]) *Server {
+	return &Server{cache: cache, loader: loader}
+}
+
+// Register registers the service in the gRPC server.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	RegisterCacheServer(registrar, s)
+}
+
+// Get implements CacheServer.
+func (s *Server) Get(_ context.Context, req *GetRequest) (*GetResponse, error) {
+	val, ok := s.cache.Get(req.GetKey())
+	return &GetResponse{Value: val, Found: ok}, nil
+}
+
+// Set implements CacheServer.
+func (s *Server) Set(_ context.Context, req *SetRequest) (*SetResponse, error) {
+	key, val := req.GetKey(), req.GetValue()
+
+	switch ttl := req.GetTtlMs(); {
+	case ttl > 0:
+		s.cache.SetWithTTL(key, val, time.Duration(ttl)*time.Millisecond)
+	case ttl < 0:
+		s.cache.SetForever(key, val)
+	default:
+		s.cache.Set(key, val)
+	}
+	return &SetResponse{}, nil
+}
+
+// Del implements CacheServer.
+func (s *Server) Del(_ context.Context, req *DelRequest) (*DelResponse, error) {
+	s.cache.Del(req.GetKey())
+	return &DelResponse{}, nil
+}
+
+// GetOrRefresh implements CacheServer.
+func (s *Server) GetOrRefresh(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	if s.loader == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the server has no loader")
+	}
+
+	key := req.GetKey()
+	val, err := s.cache.GetOrRefreshCtx(ctx, key, func(ctx context.Context) ([]byte, error) {
+		return s.loader(ctx, key)
+	})
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return &GetResponse{Value: val, Found: true}, nil
+}
@@ -0,0 +1,132 @@
+package locachegrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/atkhx/locache"
+)
+
+func newTestClient(t *testing.T, cache *locache.Cache[string, []byte], loader locache.Loader[string, []byte]) *Client {
+	listener := bufconn.Listen(1 << 20)
+
+	var (
+		mtx     sync.Mutex
+		methods []string
+	)
+	server := grpc.NewServer(grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			mtx.Lock()
+			methods = append(methods, info.FullMethod)
+			mtx.Unlock()
+			return handler(ctx, req)
+		},
+	))
+	NewServer(cache, loader).Register(server)
+	go server.Serve(listener) //nolint:errcheck
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		require.Contains(t, methods, "/locache.v1.Cache/Get")
+	})
+
+	return NewClient(conn)
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	cache := locache.New[string, []byte](locache.WithTTL(time.Hour))
+	client := newTestClient(t, cache, nil)
+
+	_, found, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, client.Set(ctx, "key0", []byte("val0"), time.Minute))
+	require.NoError(t, client.Set(ctx, "key1", []byte("val1"), 0))
+	require.NoError(t, client.SetWithTTLMs(ctx, "key2", []byte("val2"), 0))
+
+	val, found, err := client.Get(ctx, "key0")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("val0"), val)
+
+	ttl, _ := cache.TTL("key0")
+	require.LessOrEqual(t, ttl, time.Minute)
+	ttl, _ = cache.TTL("key1")
+	require.Equal(t, time.Duration(0), ttl)
+	ttl, _ = cache.TTL("key2")
+	require.Greater(t, ttl, time.Minute)
+
+	require.NoError(t, client.Del(ctx, "key0"))
+	_, found, err = client.Get(ctx, "key0")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, err = client.GetOrRefresh(ctx, "key")
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestClient_GetOrRefresh(t *testing.T) {
+	ctx := context.Background()
+	loads := 0
+	client := newTestClient(t, locache.New[string, []byte](), func(_ context.Context, key string) ([]byte, error) {
+		loads++
+		if key == "fail" {
+			return nil, fmt.Errorf("some error")
+		}
+		return []byte("val of " + key), nil
+	})
+
+	for i := 0; i < 2; i++ {
+		val, err := client.GetOrRefresh(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("val of key"), val)
+	}
+	require.Equal(t, 1, loads)
+
+	_, found, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+
+	_, err = client.GetOrRefresh(ctx, "fail")
+	require.Equal(t, codes.Unknown, status.Code(err))
+	require.ErrorContains(t, err, "some error")
+}
+
+func TestClient_Tiered(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, locache.New[string, []byte](), nil)
+
+	codec := locache.WithKeyCodec[int](locache.JSONCodec[int]{})
+	tiered := locache.NewTiered[int, string](locache.New[int, string](codec), client, time.Hour)
+	require.NoError(t, tiered.Set(ctx, 1, "val"))
+	tiered.L1().Del(1)
+
+	val, found, err := tiered.Get(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "val", val)
+}
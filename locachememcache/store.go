@@ -0,0 +1,98 @@
+// Package locachememcache implements locache.Store on top of gomemcache, to use memcached
+// as a byte store or as the second level of the tiered cache (locache.NewTiered).
+package locachememcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// maxKeyLength is the limit of the memcached keys.
+	maxKeyLength = 250
+	// maxRelativeExpiration is the longest expiration memcached treats as relative,
+	// the longer ones are set as unix timestamps.
+	maxRelativeExpiration = 30 * 24 * time.Hour
+)
+
+var now = time.Now
+
+// Client is the part of *memcache.Client used by the store.
+type Client interface {
+	Get(key string) (*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Delete(key string) error
+}
+
+var _ Client = (*memcache.Client)(nil)
+
+// Store keeps the values in memcached. The keys are encoded with base64 and prefixed
+// by the prefix, the keys longer than the memcached limit are replaced by their hashes.
+// The calls of gomemcache don't accept a context, they are limited by the client timeout.
+type Store struct {
+	client Client
+	prefix string
+}
+
+// New creates the store, the prefix separates the keys of caches sharing the servers.
+func New(client Client, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+// Get implements locache.Store.
+func (s *Store) Get(_ context.Context, key string) ([]byte, bool, error) {
+	item, err := s.client.Get(s.key(key))
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("memcache get: %w", err)
+	}
+	return item.Value, true, nil
+}
+
+// Set implements locache.Store.
+func (s *Store) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	item := &memcache.Item{Key: s.key(key), Value: value, Expiration: expiration(ttl)}
+	if err := s.client.Set(item); err != nil {
+		return fmt.Errorf("memcache set: %w", err)
+	}
+	return nil
+}
+
+// Del implements locache.Store.
+func (s *Store) Del(_ context.Context, key string) error {
+	if err := s.client.Delete(s.key(key)); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return fmt.Errorf("memcache delete: %w", err)
+	}
+	return nil
+}
+
+// key converts the key to the characters allowed by memcached.
+func (s *Store) key(key string) string {
+	encoded := s.prefix + base64.RawURLEncoding.EncodeToString([]byte(key))
+	if len(encoded) <= maxKeyLength {
+		return encoded
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return s.prefix + hex.EncodeToString(sum[:])
+}
+
+// expiration converts the ttl to seconds rounded up, so the short ttl doesn't mean forever.
+func expiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > maxRelativeExpiration {
+		return int32(now().Add(ttl).Unix())
+	}
+	return int32((ttl + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,121 @@
+package locachememcache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+)
+
+type fakeClient struct {
+	items map[string]*memcache.Item
+	err   error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{items: map[string]*memcache.Item{}}
+}
+
+func (c *fakeClient) Get(key string) (*memcache.Item, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	item, found := c.items[key]
+	if !found {
+		return nil, memcache.ErrCacheMiss
+	}
+	return item, nil
+}
+
+func (c *fakeClient) Set(item *memcache.Item) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.items[item.Key] = item
+	return nil
+}
+
+func (c *fakeClient) Delete(key string) error {
+	if c.err != nil {
+		return c.err
+	}
+	if _, found := c.items[key]; !found {
+		return memcache.ErrCacheMiss
+	}
+	delete(c.items, key)
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	store := New(client, "cache:")
+
+	_, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val"), time.Minute))
+	require.Equal(t, int32(60), client.items["cache:a2V5"].Expiration)
+
+	val, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("val"), val)
+
+	require.NoError(t, store.Del(ctx, "key"))
+	require.NoError(t, store.Del(ctx, "key"))
+	require.Empty(t, client.items)
+}
+
+func TestStore_Keys(t *testing.T) {
+	store := New(newFakeClient(), "cache:")
+
+	require.Equal(t, "cache:a2V5IHdpdGggc3BhY2VzCg", store.key("key with spaces\n"))
+
+	long := store.key(strings.Repeat("k", 300))
+	require.Len(t, long, len("cache:")+64)
+}
+
+func TestStore_Error(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	client.err = fmt.Errorf("some error")
+	store := New(client, "")
+
+	_, _, err := store.Get(ctx, "key")
+	require.EqualError(t, err, "memcache get: some error")
+	require.EqualError(t, store.Set(ctx, "key", nil, 0), "memcache set: some error")
+	require.EqualError(t, store.Del(ctx, "key"), "memcache delete: some error")
+}
+
+func TestExpiration(t *testing.T) {
+	defer func(n func() time.Time) { now = n }(now)
+	at := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return at }
+
+	require.Equal(t, int32(0), expiration(0))
+	require.Equal(t, int32(0), expiration(-time.Second))
+	require.Equal(t, int32(1), expiration(time.Millisecond))
+	require.Equal(t, int32(60), expiration(time.Minute))
+	require.Equal(t, int32(1_700_000_000+31*24*3600), expiration(31*24*time.Hour))
+}
+
+func TestStore_Tiered(t *testing.T) {
+	ctx := context.Background()
+	tiered := locache.NewTiered[string, string](locache.New[string, string](), New(newFakeClient(), ""), time.Hour)
+
+	require.NoError(t, tiered.Set(ctx, "key", "val"))
+	tiered.L1().Del("key")
+
+	val, found, err := tiered.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "val", val)
+}
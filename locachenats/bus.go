@@ -0,0 +1,61 @@
+// Package locachenats implements locache.Bus on top of NATS subjects, the replicas
+// subscribed to the subject delete the keys written or deleted by each other
+// (locache.WithInvalidation). The messages published by a replica carry its origin id,
+// the replica skips its own messages.
+package locachenats
+
+import (
+	"context"
+	"fmt"
+)
+
+// Conn is the part of the NATS connection used by the bus. The package doesn't depend
+// on the NATS client, a small adapter of *nats.Conn implements it:
+//
+//	func (c natsConn) Subscribe(subject string, handler func(data []byte)) (func() error, error) {
+//		sub, err := c.conn.Subscribe(subject, func(msg *nats.Msg) { handler(msg.Data) })
+//		if err != nil {
+//			return nil, err
+//		}
+//		return sub.Unsubscribe, nil
+//	}
+type Conn interface {
+	Publish(subject string, data []byte) error
+	// Subscribe calls the handler for each message of the subject until unsubscribe is called.
+	Subscribe(subject string, handler func(data []byte)) (unsubscribe func() error, err error)
+}
+
+// Bus publishes the invalidation messages to the subject. NATS core doesn't keep
+// the messages, the replica disconnected from the server misses them.
+type Bus struct {
+	conn    Conn
+	subject string
+}
+
+// NewBus creates the bus of the subject.
+func NewBus(conn Conn, subject string) *Bus {
+	return &Bus{conn: conn, subject: subject}
+}
+
+// Publish implements locache.Bus.
+func (b *Bus) Publish(_ context.Context, msg []byte) error {
+	if err := b.conn.Publish(b.subject, msg); err != nil {
+		return fmt.Errorf("nats publish: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements locache.Bus. The subscription is restored by the NATS client
+// after reconnects, it ends when the context is done.
+func (b *Bus) Subscribe(ctx context.Context, fn func(msg []byte)) error {
+	unsubscribe, err := b.conn.Subscribe(b.subject, fn)
+	if err != nil {
+		return fmt.Errorf("nats subscribe: %w", err)
+	}
+
+	<-ctx.Done()
+	if err := unsubscribe(); err != nil {
+		return fmt.Errorf("nats unsubscribe: %w", err)
+	}
+	return nil
+}
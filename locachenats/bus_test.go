@@ -0,0 +1,102 @@
+package locachenats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+)
+
+// fakeConn delivers the messages to the handlers of the subject like a NATS server.
+type fakeConn struct {
+	mtx      sync.Mutex
+	handlers map[string]map[int]func([]byte)
+	nextID   int
+	err      error
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{handlers: map[string]map[int]func([]byte){}}
+}
+
+func (c *fakeConn) Publish(subject string, data []byte) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	for _, handler := range c.handlers[subject] {
+		handler(data)
+	}
+	return nil
+}
+
+func (c *fakeConn) Subscribe(subject string, handler func([]byte)) (func() error, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.handlers[subject] == nil {
+		c.handlers[subject] = map[int]func([]byte){}
+	}
+	id := c.nextID
+	c.nextID++
+	c.handlers[subject][id] = handler
+
+	return func() error {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		delete(c.handlers[subject], id)
+		return nil
+	}, nil
+}
+
+func (c *fakeConn) subscribed(subject string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return len(c.handlers[subject])
+}
+
+func TestBus_Invalidation(t *testing.T) {
+	conn := newFakeConn()
+
+	replica0 := locache.New[string, string](locache.WithInvalidation(NewBus(conn, "cache.invalidation")))
+	replica1 := locache.New[string, string](locache.WithInvalidation(NewBus(conn, "cache.invalidation")))
+	require.Eventually(t, func() bool { return conn.subscribed("cache.invalidation") == 2 }, time.Second, time.Millisecond)
+
+	replica0.Set("key", "val0")
+	replica1.Set("key", "val1")
+
+	_, ok := replica0.Get("key")
+	require.False(t, ok)
+	val, ok := replica1.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "val1", val)
+
+	replica0.Del("key")
+	_, ok = replica1.Get("key")
+	require.False(t, ok)
+
+	require.NoError(t, replica0.Close())
+	require.NoError(t, replica1.Close())
+	require.Equal(t, 0, conn.subscribed("cache.invalidation"))
+}
+
+func TestBus_Error(t *testing.T) {
+	conn := newFakeConn()
+	conn.err = fmt.Errorf("some error")
+	bus := NewBus(conn, "cache.invalidation")
+
+	require.EqualError(t, bus.Publish(context.Background(), nil), "nats publish: some error")
+	require.EqualError(t, bus.Subscribe(context.Background(), func([]byte) {}), "nats subscribe: some error")
+}
@@ -0,0 +1,53 @@
+package locacheredis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Bus implements locache.Bus on top of Redis pub/sub, the replicas subscribed to
+// the channel delete the keys written or deleted by each other (locache.WithInvalidation).
+// Redis doesn't keep the messages, the replica disconnected from Redis misses them.
+type Bus struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewBus creates the bus of the channel.
+func NewBus(client redis.UniversalClient, channel string) *Bus {
+	return &Bus{client: client, channel: channel}
+}
+
+// Publish implements locache.Bus.
+func (b *Bus) Publish(ctx context.Context, msg []byte) error {
+	if err := b.client.Publish(ctx, b.channel, msg).Err(); err != nil {
+		return fmt.Errorf("redis publish: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements locache.Bus. The subscription is reconnected by go-redis.
+func (b *Bus) Subscribe(ctx context.Context, fn func(msg []byte)) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+
+	// The messages are published to the channel only after the subscription is confirmed.
+	if _, err := sub.Receive(ctx); err != nil {
+		return fmt.Errorf("redis subscribe: %w", err)
+	}
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			fn([]byte(msg.Payload))
+		}
+	}
+}
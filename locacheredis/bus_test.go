@@ -0,0 +1,42 @@
+package locacheredis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+)
+
+func TestBus_Invalidation(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	codec := locache.WithKeyCodec[string](locache.JSONCodec[string]{})
+	replica0 := locache.New[string, string](codec, locache.WithInvalidation(NewBus(client, "invalidation")))
+	replica1 := locache.New[string, string](codec, locache.WithInvalidation(NewBus(client, "invalidation")))
+	defer replica0.Close()
+	defer replica1.Close()
+
+	require.Eventually(t, func() bool {
+		return server.PubSubNumSub("invalidation")["invalidation"] == 2
+	}, time.Second, time.Millisecond)
+
+	replica0.Set("key", "val0")
+	replica1.Set("key", "val1")
+
+	require.Eventually(t, func() bool {
+		_, ok := replica0.Get("key")
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	replica0.Del("key")
+	require.Eventually(t, func() bool {
+		_, ok := replica1.Get("key")
+		return !ok
+	}, time.Second, time.Millisecond)
+}
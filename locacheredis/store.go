@@ -0,0 +1,58 @@
+// Package locacheredis implements locache.Store on top of go-redis, so the tiered cache
+// (locache.NewTiered) shares the warm values across replicas while each of them keeps
+// the hot set in memory.
+package locacheredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps the values in Redis strings with the keys prefixed by the prefix,
+// the expiration of the values is set by Redis.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// New creates the store, the prefix separates the keys of caches sharing a database.
+func New(client redis.UniversalClient, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+// Get implements locache.Store.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("redis get: %w", err)
+	}
+	return value, true, nil
+}
+
+// Set implements locache.Store.
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	// The zero expiration of go-redis means the value never expires.
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	if err := s.client.Set(ctx, s.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+// Del implements locache.Store.
+func (s *Store) Del(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key).Err(); err != nil {
+		return fmt.Errorf("redis del: %w", err)
+	}
+	return nil
+}
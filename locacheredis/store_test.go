@@ -0,0 +1,77 @@
+package locacheredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+)
+
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { require.NoError(t, client.Close()) })
+
+	return New(client, "cache:"), server
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store, server := newStore(t)
+
+	_, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val0"), 0))
+	require.Equal(t, time.Duration(0), server.TTL("cache:key"))
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val1"), time.Minute))
+	require.Equal(t, time.Minute, server.TTL("cache:key"))
+
+	val, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("val1"), val)
+
+	server.FastForward(time.Minute)
+	_, found, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val2"), -time.Second))
+	require.NoError(t, store.Del(ctx, "key"))
+	require.False(t, server.Exists("cache:key"))
+}
+
+func TestStore_Error(t *testing.T) {
+	ctx := context.Background()
+	store, server := newStore(t)
+	server.Close()
+
+	_, _, err := store.Get(ctx, "key")
+	require.ErrorContains(t, err, "redis get")
+	require.ErrorContains(t, store.Set(ctx, "key", nil, 0), "redis set")
+	require.ErrorContains(t, store.Del(ctx, "key"), "redis del")
+}
+
+func TestStore_Tiered(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newStore(t)
+
+	codec := locache.WithKeyCodec[string](locache.JSONCodec[string]{})
+	replica0 := locache.NewTiered[string, string](locache.New[string, string](codec), store, time.Hour)
+	replica1 := locache.NewTiered[string, string](locache.New[string, string](codec), store, time.Hour)
+
+	require.NoError(t, replica0.Set(ctx, "key", "val"))
+
+	val, found, err := replica1.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "val", val)
+}
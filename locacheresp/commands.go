@@ -0,0 +1,140 @@
+package locacheresp
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// execute writes the reply of the command, it reports whether the client quits.
+func (s *Server) execute(w *bufio.Writer, args [][]byte) bool {
+	name := strings.ToUpper(string(args[0]))
+	args = args[1:]
+
+	arity, found := commandArity[name]
+	if !found {
+		writeError(w, "ERR unknown command '"+name+"'")
+		return false
+	}
+	if len(args) < arity.min || (arity.max >= 0 && len(args) > arity.max) {
+		writeError(w, "ERR wrong number of arguments for '"+strings.ToLower(name)+"' command")
+		return false
+	}
+
+	switch name {
+	case "PING":
+		if len(args) == 1 {
+			writeBulk(w, args[0])
+		} else {
+			writeSimple(w, "PONG")
+		}
+	case "ECHO":
+		writeBulk(w, args[0])
+	case "GET":
+		if val, ok := s.cache.Get(string(args[0])); ok {
+			writeBulk(w, val)
+		} else {
+			writeNull(w)
+		}
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		deleted := int64(0)
+		for _, key := range args {
+			if _, ok := s.cache.Pop(string(key)); ok {
+				deleted++
+			}
+		}
+		writeInt(w, deleted)
+	case "EXISTS":
+		count := int64(0)
+		for _, key := range args {
+			if _, ok := s.cache.TTL(string(key)); ok {
+				count++
+			}
+		}
+		writeInt(w, count)
+	case "EXPIRE", "PEXPIRE":
+		unit := time.Second
+		if name == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		n, err := strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil {
+			writeError(w, "ERR value is not an integer or out of range")
+			return false
+		}
+		if s.cache.Expire(string(args[0]), time.Duration(n)*unit) {
+			writeInt(w, 1)
+		} else {
+			writeInt(w, 0)
+		}
+	case "TTL", "PTTL":
+		unit := time.Second
+		if name == "PTTL" {
+			unit = time.Millisecond
+		}
+		ttl, ok := s.cache.TTL(string(args[0]))
+		writeInt(w, ttlReply(ttl, ok, unit))
+	case "COMMAND":
+		// redis-cli requests the docs of the commands on start, the empty reply disables the hints.
+		w.WriteString("*0\r\n")
+	case "QUIT":
+		writeSimple(w, "OK")
+		return true
+	}
+	return false
+}
+
+// arity is the number of the arguments of the command, the negative max means any number.
+type arity struct {
+	min, max int
+}
+
+var commandArity = map[string]arity{
+	"PING":    {0, 1},
+	"ECHO":    {1, 1},
+	"GET":     {1, 1},
+	"SET":     {2, 4},
+	"DEL":     {1, -1},
+	"EXISTS":  {1, -1},
+	"EXPIRE":  {2, 2},
+	"PEXPIRE": {2, 2},
+	"TTL":     {1, 1},
+	"PTTL":    {1, 1},
+	"COMMAND": {0, -1},
+	"QUIT":    {0, 0},
+}
+
+// set stores the value: SET key value [EX seconds | PX milliseconds].
+func (s *Server) set(w *bufio.Writer, args [][]byte) {
+	key, val := string(args[0]), append([]byte(nil), args[1]...)
+
+	switch len(args) {
+	case 2:
+		s.cache.Set(key, val)
+	case 4:
+		unit := time.Second
+		switch strings.ToUpper(string(args[2])) {
+		case "EX":
+		case "PX":
+			unit = time.Millisecond
+		default:
+			writeError(w, "ERR syntax error")
+			return
+		}
+
+		n, err := strconv.ParseInt(string(args[3]), 10, 64)
+		if err != nil || n <= 0 {
+			writeError(w, "ERR invalid expire time in 'set' command")
+			return
+		}
+		s.cache.SetWithTTL(key, val, time.Duration(n)*unit)
+	default:
+		writeError(w, "ERR syntax error")
+		return
+	}
+
+	writeSimple(w, "OK")
+}
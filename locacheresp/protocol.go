@@ -0,0 +1,103 @@
+package locacheresp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// maxBulkLength limits the size of the arguments like the proto-max-bulk-len of Redis.
+const maxBulkLength = 512 << 20
+
+// protocolError is the malformed request, the server replies with the error and closes the connection.
+type protocolError string
+
+func (e protocolError) Error() string {
+	return string(e)
+}
+
+// readCommand reads the array of bulk strings sent by the clients
+// or the inline command typed in telnet.
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n > 1024*1024 {
+		return nil, protocolError("invalid multibulk length")
+	}
+
+	args := make([][]byte, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, protocolError("expected '$'")
+		}
+
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size < 0 || size > maxBulkLength {
+			return nil, protocolError("invalid bulk length")
+		}
+
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(arg, []byte("\r\n")) {
+			return nil, protocolError("expected CRLF")
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, protocolError("too big request")
+		}
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func writeSimple(w *bufio.Writer, s string) {
+	w.WriteByte('+')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func writeError(w *bufio.Writer, s string) {
+	w.WriteByte('-')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func writeInt(w *bufio.Writer, n int64) {
+	w.WriteByte(':')
+	w.WriteString(strconv.FormatInt(n, 10))
+	w.WriteString("\r\n")
+}
+
+func writeBulk(w *bufio.Writer, b []byte) {
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(b)))
+	w.WriteString("\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func writeNull(w *bufio.Writer) {
+	w.WriteString("$-1\r\n")
+}
@@ -0,0 +1,171 @@
+// Package locacheresp serves a locache.Cache[string, []byte] over the Redis protocol (RESP),
+// so Redis clients and redis-cli talk to an embedded cache during development and at the edge.
+// The server supports PING, ECHO, GET, SET with EX or PX, DEL, EXISTS, EXPIRE, PEXPIRE, TTL,
+// PTTL and QUIT.
+package locacheresp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/atkhx/locache"
+)
+
+// ErrServerClosed is returned by Serve after Close.
+var ErrServerClosed = errors.New("locacheresp: server closed")
+
+// Server accepts the connections of the Redis clients.
+type Server struct {
+	cache *locache.Cache[string, []byte]
+
+	mtx       sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer creates the server of the cache. SET without EX or PX stores the value
+// with the cache TTL, the values of SET with them expire like in Redis.
+func NewServer(cache *locache.Cache[string, []byte]) *Server {
+	return &Server{
+		cache:     cache,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves the connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve serves the connections of the listener until Close is called,
+// then it returns ErrServerClosed.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, nil) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l, nil)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !s.track(nil, conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(nil, conn)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close closes the listeners and the connections and waits for the connection goroutines.
+func (s *Server) Close() error {
+	s.mtx.Lock()
+	s.closed = true
+	var err error
+	for l := range s.listeners {
+		err = errors.Join(err, l.Close())
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mtx.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			var protoErr protocolError
+			if errors.As(err, &protoErr) {
+				writeError(w, "ERR Protocol error: "+protoErr.Error())
+				w.Flush() //nolint:errcheck
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(w, args)
+
+		// The replies of pipelined commands are flushed together.
+		if quit || r.Buffered() == 0 {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) track(l net.Listener, conn net.Conn) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return false
+	}
+	if l != nil {
+		s.listeners[l] = struct{}{}
+	}
+	if conn != nil {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener, conn net.Conn) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.listeners, l)
+	delete(s.conns, conn)
+}
+
+func (s *Server) isClosed() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.closed
+}
+
+// ttlReply converts the remaining lifetime to the TTL reply of Redis:
+// -2 for a missing key, -1 for a key without expiration.
+func ttlReply(ttl time.Duration, found bool, unit time.Duration) int64 {
+	switch {
+	case !found:
+		return -2
+	case ttl == 0:
+		return -1
+	}
+	// Redis rounds the seconds to the nearest one.
+	return int64((ttl + unit/2) / unit)
+}
@@ -0,0 +1,131 @@
+package locacheresp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+)
+
+func newTestServer(t *testing.T, cache *locache.Cache[string, []byte]) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := NewServer(cache)
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(l) }()
+
+	t.Cleanup(func() {
+		require.NoError(t, server.Close())
+		require.ErrorIs(t, <-done, ErrServerClosed)
+	})
+	return l.Addr().String()
+}
+
+// command encodes the command as a RESP array of bulk strings, the way the clients send it.
+func command(args ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return b.String()
+}
+
+// readReply reads one reply, the bulk string is returned with its header.
+func readReply(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	if strings.HasPrefix(line, "$") && line != "$-1\r\n" {
+		data, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line += data
+	}
+	return line
+}
+
+func TestServer_Commands(t *testing.T) {
+	cache := locache.New[string, []byte](locache.WithTTL(time.Hour))
+	conn, err := net.Dial("tcp", newTestServer(t, cache))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	do := func(args ...string) string {
+		_, err := conn.Write([]byte(command(args...)))
+		require.NoError(t, err)
+		return readReply(t, r)
+	}
+
+	require.Equal(t, "+PONG\r\n", do("PING"))
+	require.Equal(t, "$-1\r\n", do("GET", "key"))
+
+	require.Equal(t, "+OK\r\n", do("SET", "key0", "val0"))
+	require.Equal(t, "+OK\r\n", do("SET", "key1", "val1", "EX", "60"))
+	require.Equal(t, "+OK\r\n", do("SET", "key2", "val2", "PX", "1500"))
+
+	require.Equal(t, "$4\r\nval0\r\n", do("GET", "key0"))
+	require.Equal(t, ":60\r\n", do("TTL", "key1"))
+
+	pttl, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(do("PTTL", "key2"), ":")))
+	require.NoError(t, err)
+	require.Greater(t, pttl, 1000)
+
+	require.Equal(t, ":-2\r\n", do("TTL", "missing"))
+
+	require.Equal(t, ":1\r\n", do("EXPIRE", "key0", "10"))
+	require.Equal(t, ":10\r\n", do("TTL", "key0"))
+
+	require.Equal(t, ":2\r\n", do("EXISTS", "key0", "key1", "missing"))
+	require.Equal(t, ":2\r\n", do("DEL", "key0", "key1", "missing"))
+	require.Equal(t, []string{"key2"}, cache.Keys())
+
+	// Pipelined commands.
+	_, err = conn.Write([]byte(command("SET", "key3", "val3") + command("GET", "key3")))
+	require.NoError(t, err)
+	require.Equal(t, "+OK\r\n", readReply(t, r))
+	require.Equal(t, "$4\r\nval3\r\n", readReply(t, r))
+
+	require.Equal(t, "-ERR unknown command 'FLUSHALL'\r\n", do("FLUSHALL"))
+	require.Equal(t, "-ERR wrong number of arguments for 'get' command\r\n", do("GET"))
+	require.Equal(t, "-ERR invalid expire time in 'set' command\r\n", do("SET", "key", "val", "EX", "0"))
+}
+
+func TestServer_Inline(t *testing.T) {
+	cache := locache.New[string, []byte]()
+	conn, err := net.Dial("tcp", newTestServer(t, cache))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	_, err = conn.Write([]byte("SET key val\r\nGET key\r\nTTL key\r\nQUIT\r\n"))
+	require.NoError(t, err)
+
+	for _, expected := range []string{"+OK\r\n", "$3\r\n", "val\r\n", ":-1\r\n", "+OK\r\n"} {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, expected, line)
+	}
+}
+
+func TestServer_ProtocolError(t *testing.T) {
+	conn, err := net.Dial("tcp", newTestServer(t, locache.New[string, []byte]()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("*1\r\n+GET\r\n"))
+	require.NoError(t, err)
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "-ERR Protocol error: expected '$'\r\n", line)
+}
@@ -0,0 +1,38 @@
+package locachetest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/atkhx/locache"
+)
+
+// AdvanceAndPurge moves the clock forward and purges the cache,
+// so entries expired by the time are removed immediately.
+func AdvanceAndPurge[Key comparable, Value any](clock *Clock, cache locache.Cacher[Key, Value], d time.Duration) {
+	clock.Advance(d)
+	cache.Purge()
+}
+
+// RequireHit fails the test when the cache has no valid entry with the expected value.
+func RequireHit[Key comparable, Value any](t testing.TB, cache locache.Cacher[Key, Value], key Key, expected Value) {
+	t.Helper()
+
+	actual, ok := cache.Get(key)
+	if !ok {
+		t.Fatalf("locachetest: expected hit for key %v, got miss", key)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("locachetest: expected value %v for key %v, got %v", expected, key, actual)
+	}
+}
+
+// RequireMiss fails the test when the cache has a valid entry for the key.
+func RequireMiss[Key comparable, Value any](t testing.TB, cache locache.Cacher[Key, Value], key Key) {
+	t.Helper()
+
+	if actual, ok := cache.Get(key); ok {
+		t.Fatalf("locachetest: expected miss for key %v, got value %v", key, actual)
+	}
+}
@@ -0,0 +1,39 @@
+package locachetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/atkhx/locache"
+)
+
+func TestRequireHitMiss(t *testing.T) {
+	clock := NewClock(time.Now())
+	cache := locache.New[string, string](locache.WithTTL(time.Minute), locache.WithClock(clock))
+	cache.Set("key0", "value0")
+
+	RequireHit[string, string](t, cache, "key0", "value0")
+	RequireMiss[string, string](t, cache, "unknown")
+
+	AdvanceAndPurge[string, string](clock, cache, time.Minute)
+	RequireMiss[string, string](t, cache, "key0")
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestClock_SchedulePurge(t *testing.T) {
+	clock := NewClock(time.Now())
+	cache := locache.New[string, string](locache.WithTTL(time.Minute), locache.WithClock(clock))
+	stop := cache.SchedulePurge(context.Background(), time.Second)
+	defer stop()
+
+	cache.Set("key0", "value0")
+	clock.Advance(time.Minute)
+	require.Equal(t, 1, cache.Len())
+
+	require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return cache.Len() == 0 }, time.Second, time.Millisecond)
+}
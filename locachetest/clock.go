@@ -0,0 +1,75 @@
+// Package locachetest provides helpers for testing code which uses locache
+// without sleeping in tests.
+package locachetest
+
+import (
+	"sync"
+	"time"
+)
+
+type timer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// Clock is a fake locache.Clock which is moved forward manually.
+// Channels returned by After fire when the clock is advanced past their deadline,
+// so schedules like SchedulePurge are driven by Advance.
+type Clock struct {
+	mtx     sync.Mutex
+	current time.Time
+	timers  []timer
+}
+
+// NewClock returns the clock set to the given time.
+func NewClock(current time.Time) *Clock {
+	return &Clock{current: current}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.current
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.current
+		return ch
+	}
+
+	c.timers = append(c.timers, timer{at: c.current.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires the expired After channels.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.current = c.current.Add(d)
+
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.current) {
+			timers = append(timers, t)
+			continue
+		}
+		t.ch <- c.current
+	}
+	c.timers = timers
+}
+
+// Waiters returns the number of After channels which have not fired yet.
+// It lets tests wait for a schedule to start waiting before advancing the clock.
+func (c *Clock) Waiters() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return len(c.timers)
+}
@@ -0,0 +1,36 @@
+package locache
+
+import "context"
+
+// itemLock is a mutex which can be acquired with a context.
+type itemLock chan struct{}
+
+func newItemLock() itemLock {
+	return make(itemLock, 1)
+}
+
+func (l itemLock) Lock() {
+	l <- struct{}{}
+}
+
+func (l itemLock) TryLock() bool {
+	select {
+	case l <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l itemLock) LockContext(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l itemLock) Unlock() {
+	<-l
+}
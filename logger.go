@@ -0,0 +1,161 @@
+package locache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LoggerOpts set the levels of the cache logs, the nil level uses the default one.
+type LoggerOpts struct {
+	// RefreshErrorLevel of failed refresh functions, slog.LevelWarn by default.
+	RefreshErrorLevel slog.Leveler
+	// PurgeLevel of purge summaries, slog.LevelDebug by default.
+	PurgeLevel slog.Leveler
+	// EvictionLevel of entries evicted by the size limit or Shrink, slog.LevelDebug by default.
+	EvictionLevel slog.Leveler
+	// SnapshotErrorLevel of failed scheduled snapshots, slog.LevelError by default.
+	SnapshotErrorLevel slog.Leveler
+	// WALErrorLevel of failed write-ahead log writes, slog.LevelError by default.
+	WALErrorLevel slog.Leveler
+	// StoreErrorLevel of failed operations of the Store of the spill tier, of Tiered
+	// and of the peer fetches, slog.LevelError by default.
+	StoreErrorLevel slog.Leveler
+	// BusErrorLevel of failed publishes, subscriptions and messages of the invalidation Bus,
+	// slog.LevelError by default.
+	BusErrorLevel slog.Leveler
+}
+
+// cacheLogger writes the cache logs, the nil logger writes nothing.
+type cacheLogger struct {
+	logger *slog.Logger
+
+	refreshErrorLevel slog.Leveler
+	purgeLevel        slog.Leveler
+	evictionLevel     slog.Leveler
+
+	snapshotErrorLevel slog.Leveler
+	walErrorLevel      slog.Leveler
+	storeErrorLevel    slog.Leveler
+	busErrorLevel      slog.Leveler
+}
+
+func newCacheLogger(logger *slog.Logger, opts LoggerOpts) *cacheLogger {
+	if logger == nil {
+		return nil
+	}
+
+	l := &cacheLogger{
+		logger:            logger,
+		refreshErrorLevel: opts.RefreshErrorLevel,
+		purgeLevel:        opts.PurgeLevel,
+		evictionLevel:     opts.EvictionLevel,
+
+		snapshotErrorLevel: opts.SnapshotErrorLevel,
+		walErrorLevel:      opts.WALErrorLevel,
+		storeErrorLevel:    opts.StoreErrorLevel,
+		busErrorLevel:      opts.BusErrorLevel,
+	}
+	if l.refreshErrorLevel == nil {
+		l.refreshErrorLevel = slog.LevelWarn
+	}
+	if l.purgeLevel == nil {
+		l.purgeLevel = slog.LevelDebug
+	}
+	if l.evictionLevel == nil {
+		l.evictionLevel = slog.LevelDebug
+	}
+	if l.snapshotErrorLevel == nil {
+		l.snapshotErrorLevel = slog.LevelError
+	}
+	if l.walErrorLevel == nil {
+		l.walErrorLevel = slog.LevelError
+	}
+	if l.storeErrorLevel == nil {
+		l.storeErrorLevel = slog.LevelError
+	}
+	if l.busErrorLevel == nil {
+		l.busErrorLevel = slog.LevelError
+	}
+	return l
+}
+
+// enabled reports whether the logs of the level are written.
+func (l *cacheLogger) enabled(level slog.Leveler) bool {
+	return l.logger.Enabled(context.Background(), level.Level())
+}
+
+func (l *cacheLogger) refreshFailed(key any, err error, staleServed bool) {
+	if l == nil || !l.enabled(l.refreshErrorLevel) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), l.refreshErrorLevel.Level(), "locache: refresh failed",
+		slog.Any("key", key),
+		slog.Any("error", err),
+		slog.Bool("stale_served", staleServed),
+	)
+}
+
+func (l *cacheLogger) purged(scanned, removed int, duration time.Duration) {
+	if l == nil || !l.enabled(l.purgeLevel) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), l.purgeLevel.Level(), "locache: purge",
+		slog.Int("scanned", scanned),
+		slog.Int("removed", removed),
+		slog.Duration("duration", duration),
+	)
+}
+
+// evicted is called after the cache lock is released, see Cache.logEviction.
+func (l *cacheLogger) evicted(key any, method string) {
+	l.logger.LogAttrs(context.Background(), l.evictionLevel.Level(), "locache: eviction",
+		slog.Any("key", key),
+		slog.String("method", method),
+	)
+}
+
+func (l *cacheLogger) snapshotFailed(path string, err error) {
+	if l == nil || !l.enabled(l.snapshotErrorLevel) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), l.snapshotErrorLevel.Level(), "locache: snapshot failed",
+		slog.String("path", path),
+		slog.Any("error", err),
+	)
+}
+
+func (l *cacheLogger) walFailed(path string, err error) {
+	if l == nil || !l.enabled(l.walErrorLevel) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), l.walErrorLevel.Level(), "locache: wal write failed",
+		slog.String("path", path),
+		slog.Any("error", err),
+	)
+}
+
+// storeFailed reports the failed operation of the Store used by the spill tier or by Tiered
+// and of the peer fetch.
+func (l *cacheLogger) storeFailed(op string, key any, err error) {
+	if l == nil || !l.enabled(l.storeErrorLevel) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), l.storeErrorLevel.Level(), "locache: store failed",
+		slog.String("op", op),
+		slog.Any("key", key),
+		slog.Any("error", err),
+	)
+}
+
+// busFailed reports the failed operation of the invalidation Bus, the nil key is unknown.
+func (l *cacheLogger) busFailed(op string, key any, err error) {
+	if l == nil || !l.enabled(l.busErrorLevel) {
+		return
+	}
+	l.logger.LogAttrs(context.Background(), l.busErrorLevel.Level(), "locache: invalidation failed",
+		slog.String("op", op),
+		slog.Any("key", key),
+		slog.Any("error", err),
+	)
+}
@@ -0,0 +1,102 @@
+package locache
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == "duration" {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+
+	cache := New[string, string](
+		WithMaxEntries(1),
+		WithLogger(logger, LoggerOpts{RefreshErrorLevel: slog.LevelError}),
+	)
+
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	_, err := cache.GetOrRefresh("key2", func() (string, error) { return "", fmt.Errorf("some error") })
+	require.Error(t, err)
+
+	cache.Purge()
+
+	require.Equal(t, []string{
+		`level=DEBUG msg="locache: eviction" key=key0 method=set`,
+		`level=ERROR msg="locache: refresh failed" key=key2 error="some error" stale_served=false`,
+		`level=DEBUG msg="locache: purge" scanned=0 removed=0`,
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
+
+func TestCache_WithLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	cache := New[string, string](WithTTL(time.Minute), WithMaxEntries(1), WithLogger(logger, LoggerOpts{}))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.Purge()
+
+	require.Empty(t, buf.String())
+}
+
+func TestCacheLogger_ErrorLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newCacheLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})), LoggerOpts{SnapshotErrorLevel: slog.LevelWarn, WALErrorLevel: slog.LevelDebug})
+
+	err := fmt.Errorf("some error")
+	logger.snapshotFailed("cache.snap", err)
+	logger.walFailed("cache.wal", err)
+	logger.storeFailed("spill", "key", err)
+
+	require.Equal(t, []string{
+		`level=WARN msg="locache: snapshot failed" path=cache.snap error="some error"`,
+		`level=ERROR msg="locache: store failed" op=spill key=key error="some error"`,
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
+
+func TestCache_WithLogger_BusErrorLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+
+	bus := newMemoryBus()
+	bus.err = fmt.Errorf("some error")
+
+	cache := New[string, string](
+		WithInvalidation(bus),
+		WithLogger(logger, LoggerOpts{BusErrorLevel: slog.LevelWarn, StoreErrorLevel: slog.LevelDebug}),
+	)
+	defer cache.Close()
+
+	cache.Set("key", "val")
+	require.Equal(t, `level=WARN msg="locache: invalidation failed" op=publish key=key error="some error"`, strings.TrimSpace(buf.String()))
+}
@@ -0,0 +1,115 @@
+package locache
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type managedCache interface {
+	Purge()
+	Close() error
+}
+
+// Manager creates named caches which share one purge schedule and one instance
+// of metrics, the metrics of every cache are labeled with its name.
+type Manager struct {
+	mtr  *NamedMetrics
+	opts []Option
+
+	scheduler *PurgeScheduler
+
+	mtx    sync.Mutex
+	caches map[string]managedCache
+}
+
+// NewManager creates the manager which purges all caches every purgeInterval,
+// if it is positive. The mtr may be nil, then caches use NopMetrics.
+// The opts are applied to every cache before its own options.
+func NewManager(purgeInterval time.Duration, mtr *NamedMetrics, opts ...Option) *Manager {
+	m := &Manager{
+		mtr:    mtr,
+		opts:   opts,
+		caches: make(map[string]managedCache),
+	}
+
+	if purgeInterval > 0 {
+		m.scheduler = NewPurgeScheduler(purgeInterval, opts...)
+		m.opts = append(opts[:len(opts):len(opts)], WithPurgeScheduler(m.scheduler))
+	}
+	return m
+}
+
+// NewManaged returns the cache with the name, creating it when it does not exist.
+// It panics when the existing cache has other key or value types.
+func NewManaged[Key comparable, Value any](m *Manager, name string, opts ...Option) *Cache[Key, Value] {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if existing, found := m.caches[name]; found {
+		cache, ok := existing.(*Cache[Key, Value])
+		if !ok {
+			panic(fmt.Sprintf("locache: cache %q has type %T", name, existing))
+		}
+		return cache
+	}
+
+	cacheOpts := make([]Option, 0, len(m.opts)+len(opts)+2)
+	cacheOpts = append(cacheOpts, WithName(name))
+	cacheOpts = append(cacheOpts, m.opts...)
+	if m.mtr != nil {
+		cacheOpts = append(cacheOpts, WithMetrics(m.mtr.For(name)))
+	}
+	cacheOpts = append(cacheOpts, opts...)
+
+	cache := New[Key, Value](cacheOpts...)
+	m.caches[name] = cache
+	return cache
+}
+
+// Names returns the sorted names of the caches.
+func (m *Manager) Names() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PurgeAll purges the caches one by one.
+func (m *Manager) PurgeAll() {
+	for _, cache := range m.snapshot() {
+		cache.Purge()
+	}
+}
+
+// CloseAll stops the purge schedule and closes all caches.
+func (m *Manager) CloseAll() error {
+	if m.scheduler != nil {
+		m.scheduler.Stop()
+	}
+
+	caches := m.snapshot()
+	errs := make([]error, 0, len(caches))
+	for _, cache := range caches {
+		errs = append(errs, cache.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) snapshot() []managedCache {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	caches := make([]managedCache, 0, len(m.caches))
+	for _, cache := range m.caches {
+		caches = append(caches, cache)
+	}
+	return caches
+}
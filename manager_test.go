@@ -0,0 +1,58 @@
+package locache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	clock := newFakeClock()
+	manager := NewManager(time.Second, nil, WithTTL(time.Minute), WithClock(clock))
+
+	users := NewManaged[int, string](manager, "users")
+	orders := NewManaged[string, int](manager, "orders", WithTTL(time.Hour))
+	require.Same(t, users, NewManaged[int, string](manager, "users"))
+	require.Equal(t, []string{"orders", "users"}, manager.Names())
+
+	require.Panics(t, func() {
+		NewManaged[string, string](manager, "users")
+	})
+
+	users.Set(1, "user1")
+	orders.Set("order1", 1)
+
+	require.Eventually(t, func() bool { return clock.waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return users.Len() == 0 }, time.Second, time.Millisecond)
+	require.Equal(t, 1, orders.Len())
+
+	require.NoError(t, manager.CloseAll())
+	require.Equal(t, 0, orders.Len())
+}
+
+func TestManager_NamedMetrics(t *testing.T) {
+	mtr := NewNamedMetrics("test")
+	manager := NewManager(0, mtr)
+
+	users := NewManaged[int, string](manager, "users")
+	users.Set(1, "user1")
+	_, _ = users.Get(1)
+
+	orders := NewManaged[string, int](manager, "orders")
+	_, _ = orders.Get("order1")
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(mtr.requestsCounter)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_requests_total Cache request counter
+# TYPE test_requests_total counter
+test_requests_total{cache="orders",method="get",status="misses"} 1
+test_requests_total{cache="users",method="get",status="hits"} 1
+`)))
+}
@@ -0,0 +1,77 @@
+package locache
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"time"
+)
+
+// memoryPressureShrinkRatio is the share of entries evicted on every tick
+// of the memory watch while the heap stays above the soft limit.
+const memoryPressureShrinkRatio = 0.25
+
+var readHeapAlloc = func() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// ScheduleMemoryWatch samples the heap size every interval and shrinks the cache
+// while the heap is above the softLimit (in bytes) until ctx is done, the returned
+// stop function is called or the cache is closed. runtime.ReadMemStats stops
+// the world for a short time, so the interval should not be too small.
+// The stop function waits for the running shrink to finish.
+func (c *Cache[Key, Value]) ScheduleMemoryWatch(
+	ctx context.Context,
+	softLimit uint64,
+	interval time.Duration,
+) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := c.schedule(ctx, interval, func() {
+		if readHeapAlloc() > softLimit {
+			c.Shrink(memoryPressureShrinkRatio)
+		}
+	})
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Shrink evicts the given ratio (from 0 to 1) of the oldest entries and returns
+// the number of evicted entries. It can be called directly on an external
+// memory pressure signal. Entries locked by an in-flight refresh are skipped.
+func (c *Cache[Key, Value]) Shrink(ratio float64) int {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodShrink, c.requestClock.Now())
+	}
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	limit := int(math.Ceil(float64(c.items.Len()) * math.Min(ratio, 1)))
+
+	evicted := 0
+	for element := c.items.Front(); element != nil && evicted < limit; {
+		item := c.getItem(element)
+		if !item.mtx.TryLock() {
+			element = element.Next()
+			continue
+		}
+
+		remove := element
+		element = element.Next()
+		c.removeElement(remove, RemovalCapacity)
+		item.mtx.Unlock()
+
+		evicted++
+		c.mtr.IncEvictions(MethodShrink)
+		c.logEviction(item.key, MethodShrink)
+	}
+
+	c.setItemsCount()
+
+	return evicted
+}
@@ -0,0 +1,50 @@
+package locache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Shrink(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3")
+
+	require.Equal(t, 2, cache.Shrink(0.5))
+	requireCacheItems(t, cache, []string{"value2", "value3"})
+
+	require.Equal(t, 1, cache.Shrink(0.1))
+	requireCacheItems(t, cache, []string{"value3"})
+
+	require.Equal(t, 1, cache.Shrink(2))
+	requireCacheItems(t, cache, []string{})
+}
+
+func TestCache_ScheduleMemoryWatch(t *testing.T) {
+	heapAlloc := atomic.Uint64{}
+	defer func(origin func() uint64) { readHeapAlloc = origin }(readHeapAlloc)
+	readHeapAlloc = heapAlloc.Load
+
+	cache := New[string, string](WithTTL(time.Second))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	stop := cache.ScheduleMemoryWatch(context.Background(), 100, time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	requireKeyExists(t, cache, "key0", "value0")
+
+	heapAlloc.Store(200)
+	require.Eventually(t, func() bool {
+		_, ok := cache.Get("key1")
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	stop()
+}
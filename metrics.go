@@ -1,6 +1,7 @@
 package locache
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -13,48 +14,138 @@ const (
 
 	MethodGetOrRefresh = "get_or_refresh"
 	MethodPurge        = "purge"
+	MethodShrink       = "shrink"
+	MethodTouch        = "touch"
+	MethodExpire       = "expire"
+	MethodTTL          = "ttl"
+	MethodClear        = "clear"
+	MethodGetOrSet     = "get_or_set"
+	MethodAdd          = "add"
+	MethodReplace      = "replace"
+	MethodPop          = "pop"
+	MethodUpdate       = "update"
+
+	MethodCompareAndSwap = "compare_and_swap"
+	MethodGetStale       = "get_stale"
+	MethodIncrement      = "increment"
+	MethodDecrement      = "decrement"
+	MethodBatch          = "batch"
 )
 
 type Metrics interface {
 	IncHits(method string)
 	IncErrors(method string)
 	IncMisses(method string)
+	IncEvictions(method string)
 	ObserveRequest(method string, timeStart time.Time)
 	SetItemsCount(count int)
 }
 
+// RemovalMetrics is an optional extension of Metrics counting removed entries:
+// expired entries removed by purge, lazy or incremental expiry, and entries
+// removed explicitly (Del, Pop). Evictions are counted by IncEvictions.
+type RemovalMetrics interface {
+	IncExpired(count int)
+	IncRemoved(method string)
+}
+
+// CostMetrics is an optional extension of Metrics observing the accumulated cost
+// of the entries, e.g. bytes with a weigher. It is set right before the items count.
+type CostMetrics interface {
+	SetCost(cost int64)
+}
+
+// RefreshMetrics is an optional extension of Metrics observing the refresh functions
+// of GetOrRefresh apart from the time of the cache: StartRefresh is called before
+// every call of the function and ObserveRefresh when it returns, even after the caller
+// stopped waiting for it on a timeout.
+type RefreshMetrics interface {
+	StartRefresh()
+	ObserveRefresh(duration time.Duration, err error)
+}
+
+// SnapshotMetrics is an optional extension of Metrics observing the snapshots written
+// by ScheduleSnapshot: the size of the file in bytes, the duration and the error.
+type SnapshotMetrics interface {
+	ObserveSnapshot(size int64, duration time.Duration, err error)
+}
+
+// PurgeMetrics is an optional extension of Metrics observing purge passes:
+// the number of scanned expired entries, the number of removed ones and the duration.
+type PurgeMetrics interface {
+	ObservePurge(scanned, removed int, duration time.Duration)
+}
+
+// DefaultMetrics are prometheus metrics of one cache. It is a prometheus.Collector,
+// so it can be registered in any registry.
 type DefaultMetrics struct {
 	requestsCounter   *prometheus.CounterVec
-	requestsTimeHist  *prometheus.HistogramVec
+	requestsTimeHist  prometheus.ObserverVec
+	evictionsCounter  *prometheus.CounterVec
 	itemsInCacheTotal prometheus.Gauge
+
+	purgeScannedCounter *prometheus.CounterVec
+	purgeRemovedCounter *prometheus.CounterVec
+	purgeTimeHist       prometheus.ObserverVec
+
+	expiredCounter prometheus.Counter
+	removedCounter *prometheus.CounterVec
+	hitRatio       *rollingRatio
+
+	refreshesInFlight prometheus.Gauge
+	refreshTimeHist   prometheus.ObserverVec
+
+	snapshotSizeGauge prometheus.Gauge
+	snapshotTimeHist  prometheus.ObserverVec
+
+	collectors []prometheus.Collector
+	seconds    bool
+
+	clock Clock
 }
 
-func NewDefaultMetrics(prefix string) *DefaultMetrics {
-	requestsCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: prefix + "_requests_total",
-		Help: "Cache request counter",
-	}, []string{"method", "status"})
+// DefaultMetricsOpts configure the names of the metrics:
+// namespace_subsystem_requests_total and so on.
+type DefaultMetricsOpts struct {
+	Namespace string
+	Subsystem string
 
-	requestsTimeHist := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    prefix + "_requests_time_ms",
-		Help:    "Cache request timings",
-		Buckets: prometheus.DefBuckets,
-	}, []string{"method"})
+	// Buckets of the request and purge time histograms, prometheus.DefBuckets by default.
+	Buckets []float64
+	// Seconds switches the time histograms from milliseconds to seconds,
+	// the histograms are named requests_time_seconds and purge_time_seconds.
+	Seconds bool
+	// ConstLabels are added to every metric, e.g. the service or the cache name.
+	ConstLabels prometheus.Labels
+}
 
-	itemsInCacheTotal := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: prefix + "_items_total",
-		Help: "Cache request counter",
-	})
+func NewDefaultMetrics(prefix string) *DefaultMetrics {
+	return newMetricVecs(DefaultMetricsOpts{Namespace: prefix}).curry(prometheus.Labels{}, true)
+}
 
-	return &DefaultMetrics{
-		requestsCounter:   requestsCounter,
-		requestsTimeHist:  requestsTimeHist,
-		itemsInCacheTotal: itemsInCacheTotal,
+// NewDefaultMetricsWithRegisterer creates the metrics and registers them in the registerer.
+func NewDefaultMetricsWithRegisterer(reg prometheus.Registerer, opts DefaultMetricsOpts) (*DefaultMetrics, error) {
+	m := newMetricVecs(opts).curry(prometheus.Labels{}, true)
+	if err := reg.Register(m); err != nil {
+		return nil, fmt.Errorf("register metrics: %w", err)
 	}
+	return m, nil
 }
 
 func (m *DefaultMetrics) MustRegister() {
-	prometheus.MustRegister(m.requestsCounter, m.requestsTimeHist, m.itemsInCacheTotal)
+	prometheus.MustRegister(m)
+}
+
+func (m *DefaultMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range m.collectors {
+		collector.Describe(ch)
+	}
+}
+
+func (m *DefaultMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range m.collectors {
+		collector.Collect(ch)
+	}
 }
 
 func (m *DefaultMetrics) IncHits(method string) {
@@ -62,6 +153,7 @@ func (m *DefaultMetrics) IncHits(method string) {
 		"method": method,
 		"status": "hits",
 	}).Inc()
+	m.hitRatio.record(true)
 }
 
 func (m *DefaultMetrics) IncMisses(method string) {
@@ -69,6 +161,7 @@ func (m *DefaultMetrics) IncMisses(method string) {
 		"method": method,
 		"status": "misses",
 	}).Inc()
+	m.hitRatio.record(false)
 }
 
 func (m *DefaultMetrics) IncErrors(method string) {
@@ -78,14 +171,326 @@ func (m *DefaultMetrics) IncErrors(method string) {
 	}).Inc()
 }
 
+func (m *DefaultMetrics) IncEvictions(method string) {
+	m.evictionsCounter.With(prometheus.Labels{"method": method}).Inc()
+	m.removedCounter.With(prometheus.Labels{"method": method}).Inc()
+}
+
+func (m *DefaultMetrics) IncExpired(count int) {
+	m.expiredCounter.Add(float64(count))
+}
+
+func (m *DefaultMetrics) IncRemoved(method string) {
+	m.removedCounter.With(prometheus.Labels{"method": method}).Inc()
+}
+
+func (m *DefaultMetrics) StartRefresh() {
+	m.refreshesInFlight.Inc()
+}
+
+func (m *DefaultMetrics) ObserveRefresh(duration time.Duration, err error) {
+	m.refreshesInFlight.Dec()
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.refreshTimeHist.With(prometheus.Labels{"status": status}).Observe(m.duration(duration))
+}
+
+func (m *DefaultMetrics) ObserveSnapshot(size int64, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	} else {
+		m.snapshotSizeGauge.Set(float64(size))
+	}
+	m.snapshotTimeHist.With(prometheus.Labels{"status": status}).Observe(m.duration(duration))
+}
+
 func (m *DefaultMetrics) ObserveRequest(method string, timeStart time.Time) {
-	m.requestsTimeHist.With(prometheus.Labels{"method": method}).Observe(float64(now().Sub(timeStart).Milliseconds()))
+	m.requestsTimeHist.With(prometheus.Labels{"method": method}).Observe(m.duration(m.now().Sub(timeStart)))
+}
+
+func (m *DefaultMetrics) now() time.Time {
+	if m.clock != nil {
+		return m.clock.Now()
+	}
+	return time.Now()
+}
+
+// WithClock returns a copy of the metrics which measures the request time with the clock,
+// it should be the clock of the cache passed to WithClock, e.g. CoarseClock.
+// The copy shares the collectors with the original metrics.
+func (m *DefaultMetrics) WithClock(clock Clock) *DefaultMetrics {
+	cp := *m
+	cp.clock = clock
+	return &cp
 }
 
 func (m *DefaultMetrics) SetItemsCount(count int) {
 	m.itemsInCacheTotal.Set(float64(count))
 }
 
+func (m *DefaultMetrics) ObservePurge(scanned, removed int, duration time.Duration) {
+	m.purgeScannedCounter.With(nil).Add(float64(scanned))
+	m.purgeRemovedCounter.With(nil).Add(float64(removed))
+	m.purgeTimeHist.With(nil).Observe(m.duration(duration))
+}
+
+func (m *DefaultMetrics) duration(d time.Duration) float64 {
+	if m.seconds {
+		return d.Seconds()
+	}
+	return float64(d.Milliseconds())
+}
+
+// NamedMetrics are metrics shared by several caches,
+// the caches are distinguished by the "cache" label.
+// It is a prometheus.Collector, the metrics returned by For collect nothing.
+type NamedMetrics struct {
+	metricVecs
+}
+
+func NewNamedMetrics(prefix string) *NamedMetrics {
+	return NewNamedMetricsWithOpts(DefaultMetricsOpts{Namespace: prefix})
+}
+
+// NewNamedMetricsWithOpts works like NewNamedMetrics, but configures the metrics with the options.
+func NewNamedMetricsWithOpts(opts DefaultMetricsOpts) *NamedMetrics {
+	return &NamedMetrics{metricVecs: newMetricVecs(opts, "cache")}
+}
+
+func (m *NamedMetrics) MustRegister() {
+	prometheus.MustRegister(m)
+}
+
+func (m *NamedMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range m.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+func (m *NamedMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range m.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// For returns the metrics of the cache with the given name.
+func (m *NamedMetrics) For(name string) *DefaultMetrics {
+	return m.curry(prometheus.Labels{"cache": name}, false)
+}
+
+// metricVecs are the collectors of the metrics, labels prepend the labels of every metric.
+type metricVecs struct {
+	requestsCounter   *prometheus.CounterVec
+	requestsTimeHist  *prometheus.HistogramVec
+	evictionsCounter  *prometheus.CounterVec
+	itemsInCacheTotal *prometheus.GaugeVec
+
+	purgeScannedCounter *prometheus.CounterVec
+	purgeRemovedCounter *prometheus.CounterVec
+	purgeTimeHist       *prometheus.HistogramVec
+
+	expiredCounter *prometheus.CounterVec
+	removedCounter *prometheus.CounterVec
+	hitRatios      *hitRatios
+
+	refreshesInFlight *prometheus.GaugeVec
+	refreshTimeHist   *prometheus.HistogramVec
+
+	snapshotSizeGauge *prometheus.GaugeVec
+	snapshotTimeHist  *prometheus.HistogramVec
+
+	seconds bool
+}
+
+func newMetricVecs(opts DefaultMetricsOpts, labels ...string) metricVecs {
+	withLabels := func(names ...string) []string {
+		return append(labels[:len(labels):len(labels)], names...)
+	}
+
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	timeUnit := "ms"
+	if opts.Seconds {
+		timeUnit = "seconds"
+	}
+
+	return metricVecs{
+		requestsCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "requests_total",
+			Help:        "Cache request counter",
+		}, withLabels("method", "status")),
+
+		requestsTimeHist: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "requests_time_" + timeUnit,
+			Help:        "Cache request timings",
+			Buckets:     buckets,
+		}, withLabels("method")),
+
+		evictionsCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "evictions_total",
+			Help:        "Cache evictions counter",
+		}, withLabels("method")),
+
+		itemsInCacheTotal: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "items_total",
+			Help:        "Cache request counter",
+		}, withLabels()),
+
+		purgeScannedCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "purge_scanned_total",
+			Help:        "Expired entries scanned by purge",
+		}, withLabels()),
+
+		purgeRemovedCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "purge_removed_total",
+			Help:        "Expired entries removed by purge",
+		}, withLabels()),
+
+		purgeTimeHist: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "purge_time_" + timeUnit,
+			Help:        "Purge timings",
+			Buckets:     buckets,
+		}, withLabels()),
+
+		expiredCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "expired_total",
+			Help:        "Expired entries removed by purge, lazy or incremental expiry",
+		}, withLabels()),
+
+		removedCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "removed_total",
+			Help:        "Entries removed by eviction or deletion",
+		}, withLabels("method")),
+
+		hitRatios: newHitRatios(prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, opts.Subsystem, "hit_ratio"),
+			"Ratio of hits to hits and misses over the last minute",
+			withLabels(), opts.ConstLabels,
+		), withLabels()),
+
+		refreshesInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "refreshes_in_flight",
+			Help:        "Currently running refresh functions",
+		}, withLabels()),
+
+		refreshTimeHist: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "refresh_time_" + timeUnit,
+			Help:        "Refresh function timings",
+			Buckets:     buckets,
+		}, withLabels("status")),
+
+		snapshotSizeGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "snapshot_size_bytes",
+			Help:        "Size of the last written snapshot",
+		}, withLabels()),
+
+		snapshotTimeHist: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			ConstLabels: opts.ConstLabels,
+			Name:        "snapshot_time_" + timeUnit,
+			Help:        "Snapshot timings",
+			Buckets:     buckets,
+		}, withLabels("status")),
+
+		seconds: opts.Seconds,
+	}
+}
+
+func (v metricVecs) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		v.requestsCounter,
+		v.requestsTimeHist,
+		v.evictionsCounter,
+		v.itemsInCacheTotal,
+		v.purgeScannedCounter,
+		v.purgeRemovedCounter,
+		v.purgeTimeHist,
+		v.expiredCounter,
+		v.removedCounter,
+		v.hitRatios,
+		v.refreshesInFlight,
+		v.refreshTimeHist,
+		v.snapshotSizeGauge,
+		v.snapshotTimeHist,
+	}
+}
+
+// curry returns the metrics with the labels set, collect tells whether
+// the returned metrics collect the vecs.
+func (v metricVecs) curry(labels prometheus.Labels, collect bool) *DefaultMetrics {
+	m := &DefaultMetrics{
+		requestsCounter:   v.requestsCounter.MustCurryWith(labels),
+		requestsTimeHist:  v.requestsTimeHist.MustCurryWith(labels),
+		evictionsCounter:  v.evictionsCounter.MustCurryWith(labels),
+		itemsInCacheTotal: v.itemsInCacheTotal.With(labels),
+
+		purgeScannedCounter: v.purgeScannedCounter.MustCurryWith(labels),
+		purgeRemovedCounter: v.purgeRemovedCounter.MustCurryWith(labels),
+		purgeTimeHist:       v.purgeTimeHist.MustCurryWith(labels),
+
+		expiredCounter: v.expiredCounter.With(labels),
+		removedCounter: v.removedCounter.MustCurryWith(labels),
+		hitRatio:       v.hitRatios.get(labels),
+
+		refreshesInFlight: v.refreshesInFlight.With(labels),
+		refreshTimeHist:   v.refreshTimeHist.MustCurryWith(labels),
+
+		snapshotSizeGauge: v.snapshotSizeGauge.With(labels),
+		snapshotTimeHist:  v.snapshotTimeHist.MustCurryWith(labels),
+
+		seconds: v.seconds,
+	}
+
+	if collect {
+		m.collectors = v.collectors()
+	}
+	return m
+}
+
 func NewNopMetrics() *NopMetrics {
 	return &NopMetrics{}
 }
@@ -95,5 +500,25 @@ type NopMetrics struct{}
 func (n *NopMetrics) IncHits(_ string)                     {}
 func (n *NopMetrics) IncMisses(_ string)                   {}
 func (n *NopMetrics) IncErrors(_ string)                   {}
+func (n *NopMetrics) IncEvictions(_ string)                {}
 func (n *NopMetrics) ObserveRequest(_ string, _ time.Time) {}
 func (n *NopMetrics) SetItemsCount(_ int)                  {}
+
+// isNopMetrics reports whether the metrics discard everything,
+// so there is no point in measuring the request time.
+func isNopMetrics(mtr Metrics) bool {
+	switch mtr := mtr.(type) {
+	case *NopMetrics:
+		return true
+	case *shardMetrics:
+		return isNopMetrics(mtr.Metrics)
+	case *MultiMetrics:
+		for _, m := range mtr.metrics {
+			if !isNopMetrics(m) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
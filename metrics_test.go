@@ -0,0 +1,174 @@
+package locache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultMetrics_ObservePurge(t *testing.T) {
+	mtr := NewDefaultMetrics("test")
+
+	clock := newFakeClock()
+	cache := New[int, int](WithTTL(time.Second), WithClock(clock), WithMetrics(mtr))
+	for i := 0; i < 3; i++ {
+		cache.Set(i, i)
+	}
+	cache.SetForever(3, 3)
+
+	item := cache.getItem(cache.index[0])
+	item.mtx.Lock()
+	defer item.mtx.Unlock()
+
+	clock.Advance(time.Second)
+	cache.Purge()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(mtr.purgeScannedCounter, mtr.purgeRemovedCounter)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_purge_removed_total Expired entries removed by purge
+# TYPE test_purge_removed_total counter
+test_purge_removed_total 2
+# HELP test_purge_scanned_total Expired entries scanned by purge
+# TYPE test_purge_scanned_total counter
+test_purge_scanned_total 3
+`)))
+}
+
+func TestDefaultMetrics_WithClock(t *testing.T) {
+	mtr := NewDefaultMetrics("test")
+	clock := newFakeClock()
+
+	clocked := mtr.WithClock(clock)
+	require.Nil(t, mtr.clock)
+	require.Equal(t, clock, clocked.clock)
+
+	start := clock.Now()
+	clock.Advance(3 * time.Millisecond)
+	clocked.ObserveRequest(MethodGet, start)
+
+	collector, ok := mtr.requestsTimeHist.(prometheus.Collector)
+	require.True(t, ok)
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_requests_time_ms Cache request timings
+# TYPE test_requests_time_ms histogram
+test_requests_time_ms_bucket{method="get",le="0.005"} 0
+test_requests_time_ms_bucket{method="get",le="0.01"} 0
+test_requests_time_ms_bucket{method="get",le="0.025"} 0
+test_requests_time_ms_bucket{method="get",le="0.05"} 0
+test_requests_time_ms_bucket{method="get",le="0.1"} 0
+test_requests_time_ms_bucket{method="get",le="0.25"} 0
+test_requests_time_ms_bucket{method="get",le="0.5"} 0
+test_requests_time_ms_bucket{method="get",le="1"} 0
+test_requests_time_ms_bucket{method="get",le="2.5"} 0
+test_requests_time_ms_bucket{method="get",le="5"} 1
+test_requests_time_ms_bucket{method="get",le="10"} 1
+test_requests_time_ms_bucket{method="get",le="+Inf"} 1
+test_requests_time_ms_sum{method="get"} 3
+test_requests_time_ms_count{method="get"} 1
+`)))
+}
+
+func TestNewDefaultMetricsWithRegisterer(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	opts := DefaultMetricsOpts{Namespace: "app", Subsystem: "users"}
+
+	mtr, err := NewDefaultMetricsWithRegisterer(registry, opts)
+	require.NoError(t, err)
+
+	mtr.IncHits(MethodGet)
+	mtr.SetItemsCount(2)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP app_users_items_total Cache request counter
+# TYPE app_users_items_total gauge
+app_users_items_total 2
+# HELP app_users_requests_total Cache request counter
+# TYPE app_users_requests_total counter
+app_users_requests_total{method="get",status="hits"} 1
+`), "app_users_items_total", "app_users_requests_total"))
+
+	_, err = NewDefaultMetricsWithRegisterer(registry, opts)
+	require.Error(t, err)
+}
+
+func TestNamedMetrics_Collector(t *testing.T) {
+	mtr := NewNamedMetrics("named")
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(mtr)
+	registry.MustRegister(mtr.For("users"))
+
+	mtr.For("users").IncMisses(MethodGet)
+	mtr.For("orders").IncMisses(MethodGet)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP named_requests_total Cache request counter
+# TYPE named_requests_total counter
+named_requests_total{cache="orders",method="get",status="misses"} 1
+named_requests_total{cache="users",method="get",status="misses"} 1
+`), "named_requests_total"))
+}
+
+func TestNewDefaultMetricsWithRegisterer_Opts(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	mtr, err := NewDefaultMetricsWithRegisterer(registry, DefaultMetricsOpts{
+		Namespace:   "app",
+		Buckets:     []float64{0.001, 0.01},
+		Seconds:     true,
+		ConstLabels: prometheus.Labels{"service": "users"},
+	})
+	require.NoError(t, err)
+
+	mtr.ObservePurge(1, 1, 5*time.Millisecond)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP app_purge_time_seconds Purge timings
+# TYPE app_purge_time_seconds histogram
+app_purge_time_seconds_bucket{service="users",le="0.001"} 0
+app_purge_time_seconds_bucket{service="users",le="0.01"} 1
+app_purge_time_seconds_bucket{service="users",le="+Inf"} 1
+app_purge_time_seconds_sum{service="users"} 0.005
+app_purge_time_seconds_count{service="users"} 1
+`), "app_purge_time_seconds"))
+}
+
+func TestDefaultMetrics_Removals(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	mtr, err := NewDefaultMetricsWithRegisterer(registry, DefaultMetricsOpts{Namespace: "removals"})
+	require.NoError(t, err)
+
+	clock := newFakeClock()
+	cache := New[int, int](WithTTL(time.Second), WithClock(clock), WithMetrics(mtr), WithMaxEntries(2))
+	cache.Set(0, 0)
+	cache.Set(1, 1)
+	cache.Set(2, 2)
+	cache.Del(1)
+	cache.Get(2)
+	cache.Get(3)
+
+	clock.Advance(time.Second)
+	cache.Purge()
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP removals_expired_total Expired entries removed by purge, lazy or incremental expiry
+# TYPE removals_expired_total counter
+removals_expired_total 1
+# HELP removals_hit_ratio Ratio of hits to hits and misses over the last minute
+# TYPE removals_hit_ratio gauge
+removals_hit_ratio 0.5
+# HELP removals_removed_total Entries removed by eviction or deletion
+# TYPE removals_removed_total counter
+removals_removed_total{method="del"} 1
+removals_removed_total{method="set"} 1
+`), "removals_expired_total", "removals_hit_ratio", "removals_removed_total"))
+}
@@ -0,0 +1,145 @@
+package locache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RemovalReason tells why the entry left the cache.
+type RemovalReason int
+
+const (
+	RemovalExpired RemovalReason = iota + 1
+	RemovalCapacity
+	RemovalManual
+	RemovalReplaced
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovalExpired:
+		return "expired"
+	case RemovalCapacity:
+		return "capacity"
+	case RemovalManual:
+		return "manual"
+	case RemovalReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+type EventKind int
+
+const (
+	EventHit EventKind = iota + 1
+	EventMiss
+	EventError
+	EventRequest
+	EventRemoval
+	EventSize
+	EventPurge
+	EventRefreshStart
+	EventRefresh
+	EventSnapshot
+)
+
+// Event is a structured event of the cache, the fields are set according to the kind:
+//   - EventHit, EventMiss, EventError: Method;
+//   - EventRequest: Method and Duration;
+//   - EventRemoval: Reason and Count, Method for capacity and manual removals;
+//   - EventSize: Count of items and Bytes, the accumulated cost of the entries;
+//   - EventPurge: Scanned and Count of removed entries, Duration;
+//   - EventRefreshStart: nothing;
+//   - EventRefresh: Duration and Err of the refresh function;
+//   - EventSnapshot: Bytes written, Duration and Err of the snapshot.
+type Event struct {
+	Kind     EventKind
+	Method   string
+	Reason   RemovalReason
+	Count    int
+	Scanned  int
+	Bytes    int64
+	Duration time.Duration
+	Err      error
+}
+
+// MetricsV2 receives structured events instead of separate calls per metric.
+// The cache reports to Metrics, AdaptMetricsV2 converts the calls to events.
+type MetricsV2 interface {
+	Observe(event Event)
+}
+
+// MetricsV2Adapter implements Metrics and its optional extensions on top of MetricsV2.
+type MetricsV2Adapter struct {
+	m     MetricsV2
+	clock Clock
+	bytes *atomic.Int64
+}
+
+// AdaptMetricsV2 returns the adapter to pass to WithMetrics.
+func AdaptMetricsV2(m MetricsV2) *MetricsV2Adapter {
+	return &MetricsV2Adapter{m: m, clock: systemClock{}, bytes: new(atomic.Int64)}
+}
+
+// WithClock returns a copy of the adapter which measures the request time with the clock,
+// it should be the clock of the cache passed to WithClock, e.g. CoarseClock.
+// The copy shares the bytes gauge with the original adapter.
+func (a *MetricsV2Adapter) WithClock(clock Clock) *MetricsV2Adapter {
+	cp := *a
+	cp.clock = clock
+	return &cp
+}
+
+func (a *MetricsV2Adapter) IncHits(method string) {
+	a.m.Observe(Event{Kind: EventHit, Method: method})
+}
+
+func (a *MetricsV2Adapter) IncMisses(method string) {
+	a.m.Observe(Event{Kind: EventMiss, Method: method})
+}
+
+func (a *MetricsV2Adapter) IncErrors(method string) {
+	a.m.Observe(Event{Kind: EventError, Method: method})
+}
+
+func (a *MetricsV2Adapter) IncEvictions(method string) {
+	a.m.Observe(Event{Kind: EventRemoval, Method: method, Reason: RemovalCapacity, Count: 1})
+}
+
+func (a *MetricsV2Adapter) IncExpired(count int) {
+	a.m.Observe(Event{Kind: EventRemoval, Reason: RemovalExpired, Count: count})
+}
+
+func (a *MetricsV2Adapter) IncRemoved(method string) {
+	a.m.Observe(Event{Kind: EventRemoval, Method: method, Reason: RemovalManual, Count: 1})
+}
+
+func (a *MetricsV2Adapter) ObserveRequest(method string, timeStart time.Time) {
+	a.m.Observe(Event{Kind: EventRequest, Method: method, Duration: a.clock.Now().Sub(timeStart)})
+}
+
+func (a *MetricsV2Adapter) SetItemsCount(count int) {
+	a.m.Observe(Event{Kind: EventSize, Count: count, Bytes: a.bytes.Load()})
+}
+
+// SetCost stores the cost, it is reported with the next items count.
+func (a *MetricsV2Adapter) SetCost(cost int64) {
+	a.bytes.Store(cost)
+}
+
+func (a *MetricsV2Adapter) ObservePurge(scanned, removed int, duration time.Duration) {
+	a.m.Observe(Event{Kind: EventPurge, Scanned: scanned, Count: removed, Duration: duration})
+}
+
+func (a *MetricsV2Adapter) StartRefresh() {
+	a.m.Observe(Event{Kind: EventRefreshStart})
+}
+
+func (a *MetricsV2Adapter) ObserveRefresh(duration time.Duration, err error) {
+	a.m.Observe(Event{Kind: EventRefresh, Duration: duration, Err: err})
+}
+
+func (a *MetricsV2Adapter) ObserveSnapshot(size int64, duration time.Duration, err error) {
+	a.m.Observe(Event{Kind: EventSnapshot, Bytes: size, Duration: duration, Err: err})
+}
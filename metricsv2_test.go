@@ -0,0 +1,76 @@
+package locache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type eventsRecorder struct {
+	mtx    sync.Mutex
+	events []Event
+}
+
+func (r *eventsRecorder) Observe(event Event) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	event.Duration = 0
+	r.events = append(r.events, event)
+}
+
+func (r *eventsRecorder) byKind(kind EventKind) []Event {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var events []Event
+	for _, event := range r.events {
+		if event.Kind == kind {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestAdaptMetricsV2(t *testing.T) {
+	recorder := &eventsRecorder{}
+	clock := newFakeClock()
+	cache := New[string, string](
+		WithTTL(time.Second),
+		WithClock(clock),
+		WithMaxEntries(2),
+		WithWeigher(func(_ string, value string) int64 { return int64(len(value)) }),
+		WithMetrics(AdaptMetricsV2(recorder)),
+	)
+
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Del("key1")
+	cache.Get("key2")
+	cache.Get("key3")
+	cache.SetWithTTL("key4", "value4", time.Minute)
+
+	clock.Advance(time.Second)
+	cache.Purge()
+
+	require.Equal(t, []Event{
+		{Kind: EventRemoval, Method: MethodSet, Reason: RemovalCapacity, Count: 1},
+		{Kind: EventRemoval, Method: MethodDel, Reason: RemovalManual, Count: 1},
+		{Kind: EventRemoval, Reason: RemovalExpired, Count: 1},
+	}, recorder.byKind(EventRemoval))
+
+	require.Equal(t, []Event{{Kind: EventHit, Method: MethodGet}}, recorder.byKind(EventHit))
+	require.Equal(t, []Event{{Kind: EventMiss, Method: MethodGet}}, recorder.byKind(EventMiss))
+	require.Equal(t, []Event{{Kind: EventPurge, Scanned: 1, Count: 1}}, recorder.byKind(EventPurge))
+	require.Equal(t, []Event{{Kind: EventSize, Count: 1, Bytes: 6}}, recorder.byKind(EventSize))
+}
+
+func TestRemovalReason_String(t *testing.T) {
+	require.Equal(t, "expired", RemovalExpired.String())
+	require.Equal(t, "capacity", RemovalCapacity.String())
+	require.Equal(t, "manual", RemovalManual.String())
+	require.Equal(t, "unknown", RemovalReason(0).String())
+}
@@ -0,0 +1,168 @@
+package locache
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/atkhx/locache/flight"
+)
+
+// CachedResponse is the response stored by the caching middleware.
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// MiddlewareOpts configure the caching middleware.
+type MiddlewareOpts struct {
+	// Methods of the cached requests, GET and HEAD by default.
+	Methods []string
+	// Vary lists the request headers which are the part of the key, e.g. Accept-Encoding.
+	Vary []string
+	// Cacheable reports whether the response is stored. By default the responses with
+	// the 200 status code without Set-Cookie and Cache-Control no-store or private are stored.
+	Cacheable func(r *http.Request, resp CachedResponse) bool
+	// MaxBodySize of the stored responses, zero means no limit.
+	MaxBodySize int
+}
+
+// sharedResponse is the response of the handler shared with the concurrent requests.
+type sharedResponse struct {
+	resp      CachedResponse
+	cacheable bool
+}
+
+// NewCachingMiddleware caches the responses of the handler in the cache with its TTL.
+// The key is the method, the host, the URL and the headers listed in opts.Vary.
+// Concurrent requests of a missing key wait for the handler call of the first one,
+// the response which is not cacheable is not shared: the waiting requests call the handler
+// themselves concurrently. The requests with the Authorization header and other methods
+// are passed to the handler as is.
+func NewCachingMiddleware(
+	cache *Cache[string, CachedResponse],
+	opts MiddlewareOpts,
+) func(http.Handler) http.Handler {
+	if opts.Methods == nil {
+		opts.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if opts.Cacheable == nil {
+		opts.Cacheable = cacheableResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		var calls flight.Group[string, sharedResponse]
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !opts.cached(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := opts.key(r)
+			if resp, ok := cache.Get(key); ok {
+				writeResponse(w, resp)
+				return
+			}
+
+			// own reports whether the handler was called by this request,
+			// a panic of the handler is propagated to its request only.
+			own := false
+			shared, err := calls.DoOnce(key, func() (sharedResponse, error) {
+				own = true
+
+				rec := &responseRecorder{header: make(http.Header)}
+				next.ServeHTTP(rec, r)
+
+				resp := rec.response()
+				cacheable := (opts.MaxBodySize <= 0 || len(resp.Body) <= opts.MaxBodySize) && opts.Cacheable(r, resp)
+				if cacheable {
+					cache.Set(key, resp)
+				}
+				return sharedResponse{resp: resp, cacheable: cacheable}, nil
+			})
+
+			if own || (err == nil && shared.cacheable) {
+				writeResponse(w, shared.resp)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (o MiddlewareOpts) cached(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	for _, method := range o.Methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (o MiddlewareOpts) key(r *http.Request) string {
+	var key strings.Builder
+	key.WriteString(r.Method)
+	key.WriteByte(' ')
+	key.WriteString(r.Host)
+	key.WriteString(r.URL.RequestURI())
+	for _, name := range o.Vary {
+		key.WriteByte('\n')
+		key.WriteString(http.CanonicalHeaderKey(name))
+		key.WriteString(": ")
+		key.WriteString(strings.Join(r.Header.Values(name), ", "))
+	}
+	return key.String()
+}
+
+func cacheableResponse(_ *http.Request, resp CachedResponse) bool {
+	if resp.Status != http.StatusOK || resp.Header.Get("Set-Cookie") != "" {
+		return false
+	}
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-store", "private":
+			return false
+		}
+	}
+	return true
+}
+
+func writeResponse(w http.ResponseWriter, resp CachedResponse) {
+	for name, values := range resp.Header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body) //nolint:errcheck
+}
+
+// responseRecorder captures the response of the handler.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+func (r *responseRecorder) response() CachedResponse {
+	r.WriteHeader(http.StatusOK)
+	return CachedResponse{Status: r.status, Header: r.header, Body: r.body.Bytes()}
+}
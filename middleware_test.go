@@ -0,0 +1,178 @@
+package locache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s %s %d", r.URL.Path, r.Header.Get("Accept-Language"), n)
+	})
+
+	cache := New[string, CachedResponse](WithTTL(time.Minute))
+	server := httptest.NewServer(NewCachingMiddleware(cache, MiddlewareOpts{Vary: []string{"Accept-Language"}})(handler))
+	defer server.Close()
+
+	get := func(path, lang string) string {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", lang)
+
+		rec := httptest.NewRecorder()
+		server.Config.Handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+		return rec.Body.String()
+	}
+
+	require.Equal(t, "/a en 1", get("/a", "en"))
+	require.Equal(t, "/a en 1", get("/a", "en"))
+	require.Equal(t, "/a de 2", get("/a", "de"))
+	require.Equal(t, "/b en 3", get("/b", "en"))
+	require.Equal(t, 3, cache.Len())
+
+	rec := httptest.NewRecorder()
+	server.Config.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a", nil))
+	require.Equal(t, "/a  4", rec.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	req.Header.Set("Authorization", "token")
+	rec = httptest.NewRecorder()
+	server.Config.Handler.ServeHTTP(rec, req)
+	require.Equal(t, "/a  5", rec.Body.String())
+	require.Equal(t, 3, cache.Len())
+}
+
+func TestCachingMiddleware_Singleflight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Write([]byte("value")) //nolint:errcheck
+	})
+	middleware := NewCachingMiddleware(New[string, CachedResponse](), MiddlewareOpts{})(handler)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, "value", rec.Body.String())
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestCachingMiddleware_Uncacheable(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "max-age=60, private")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/large":
+			w.Write(make([]byte, 100)) //nolint:errcheck
+			return
+		case "/panic":
+			panic("handler failed")
+		}
+		fmt.Fprint(w, n)
+	})
+
+	cache := New[string, CachedResponse](WithErrorTTL(time.Minute))
+	middleware := NewCachingMiddleware(cache, MiddlewareOpts{MaxBodySize: 10})(handler)
+
+	for _, path := range []string{"/private", "/missing", "/large"} {
+		// The responses are not stored, even as the errors with the error TTL.
+		for i := 0; i < 2; i++ {
+			middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+	require.Equal(t, int32(6), calls.Load())
+
+	rec := httptest.NewRecorder()
+	middleware.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "7", rec.Body.String())
+
+	require.PanicsWithValue(t, "handler failed", func() {
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+}
+
+func TestCachingMiddleware_UncacheableConcurrent(t *testing.T) {
+	const requests = 10
+
+	var calls atomic.Int32
+	release, arrived := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Cache-Control", "private")
+		switch n {
+		case 1:
+			<-release
+			fmt.Fprint(w, n)
+			return
+		case requests:
+			close(arrived)
+		}
+
+		// The waiting requests call the handler concurrently, not one by one.
+		select {
+		case <-arrived:
+			fmt.Fprint(w, n)
+		case <-time.After(time.Second):
+			fmt.Fprint(w, "timeout")
+		}
+	})
+	middleware := NewCachingMiddleware(New[string, CachedResponse](WithErrorTTL(time.Minute)), MiddlewareOpts{})(handler)
+
+	var (
+		wg     sync.WaitGroup
+		mtx    sync.Mutex
+		bodies = make(map[string]bool)
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			mtx.Lock()
+			bodies[rec.Body.String()] = true
+			mtx.Unlock()
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(requests), calls.Load())
+	require.Len(t, bodies, requests)
+	require.False(t, bodies["timeout"])
+}
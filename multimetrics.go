@@ -0,0 +1,105 @@
+package locache
+
+import "time"
+
+// MultiMetrics forwards every call to several metrics implementations,
+// the calls of optional extensions are forwarded to the ones implementing them.
+type MultiMetrics struct {
+	metrics []Metrics
+}
+
+func NewMultiMetrics(metrics ...Metrics) *MultiMetrics {
+	return &MultiMetrics{metrics: metrics}
+}
+
+func (m *MultiMetrics) IncHits(method string) {
+	for _, mtr := range m.metrics {
+		mtr.IncHits(method)
+	}
+}
+
+func (m *MultiMetrics) IncMisses(method string) {
+	for _, mtr := range m.metrics {
+		mtr.IncMisses(method)
+	}
+}
+
+func (m *MultiMetrics) IncErrors(method string) {
+	for _, mtr := range m.metrics {
+		mtr.IncErrors(method)
+	}
+}
+
+func (m *MultiMetrics) IncEvictions(method string) {
+	for _, mtr := range m.metrics {
+		mtr.IncEvictions(method)
+	}
+}
+
+func (m *MultiMetrics) ObserveRequest(method string, timeStart time.Time) {
+	for _, mtr := range m.metrics {
+		mtr.ObserveRequest(method, timeStart)
+	}
+}
+
+func (m *MultiMetrics) SetItemsCount(count int) {
+	for _, mtr := range m.metrics {
+		mtr.SetItemsCount(count)
+	}
+}
+
+func (m *MultiMetrics) ObservePurge(scanned, removed int, duration time.Duration) {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(PurgeMetrics); ok {
+			mtr.ObservePurge(scanned, removed, duration)
+		}
+	}
+}
+
+func (m *MultiMetrics) IncExpired(count int) {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(RemovalMetrics); ok {
+			mtr.IncExpired(count)
+		}
+	}
+}
+
+func (m *MultiMetrics) IncRemoved(method string) {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(RemovalMetrics); ok {
+			mtr.IncRemoved(method)
+		}
+	}
+}
+
+func (m *MultiMetrics) StartRefresh() {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(RefreshMetrics); ok {
+			mtr.StartRefresh()
+		}
+	}
+}
+
+func (m *MultiMetrics) ObserveRefresh(duration time.Duration, err error) {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(RefreshMetrics); ok {
+			mtr.ObserveRefresh(duration, err)
+		}
+	}
+}
+
+func (m *MultiMetrics) ObserveSnapshot(size int64, duration time.Duration, err error) {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(SnapshotMetrics); ok {
+			mtr.ObserveSnapshot(size, duration, err)
+		}
+	}
+}
+
+func (m *MultiMetrics) SetCost(cost int64) {
+	for _, mtr := range m.metrics {
+		if mtr, ok := mtr.(CostMetrics); ok {
+			mtr.SetCost(cost)
+		}
+	}
+}
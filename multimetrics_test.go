@@ -0,0 +1,43 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiMetrics(t *testing.T) {
+	w1, w2 := &linesWriter{}, &linesWriter{}
+	mtr := NewMultiMetrics(
+		NewStatsDMetrics(w1, StatsDOpts{}),
+		NewNopMetrics(),
+		NewStatsDMetrics(w2, StatsDOpts{}),
+	)
+
+	mtr.IncHits(MethodGet)
+	mtr.IncMisses(MethodGet)
+	mtr.IncErrors(MethodGet)
+	mtr.IncEvictions(MethodSet)
+	mtr.SetItemsCount(1)
+	mtr.ObservePurge(1, 1, time.Millisecond)
+
+	expected := []string{
+		"requests.get.hits:1|c",
+		"requests.get.misses:1|c",
+		"requests.get.error:1|c",
+		"evictions.set:1|c",
+		"items:1|g",
+		"purge_scanned:1|c",
+		"purge_removed:1|c",
+		"purge_time:1|ms",
+	}
+	require.Equal(t, expected, w1.lines)
+	require.Equal(t, expected, w2.lines)
+}
+
+func TestMultiMetrics_Nop(t *testing.T) {
+	require.True(t, isNopMetrics(NewMultiMetrics()))
+	require.True(t, isNopMetrics(NewMultiMetrics(NewNopMetrics(), NewNopMetrics())))
+	require.False(t, isNopMetrics(NewMultiMetrics(NewNopMetrics(), NewStatsDMetrics(&linesWriter{}, StatsDOpts{}))))
+}
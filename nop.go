@@ -0,0 +1,24 @@
+package locache
+
+// NopCache never stores values: every Get misses and every GetOrRefresh
+// calls the refresh function. It is useful when caching is disabled.
+type NopCache[Key comparable, Value any] struct{}
+
+func NewNopCache[Key comparable, Value any]() *NopCache[Key, Value] {
+	return &NopCache[Key, Value]{}
+}
+
+func (n *NopCache[Key, Value]) Get(_ Key) (Value, bool) {
+	var val Value
+	return val, false
+}
+
+func (n *NopCache[Key, Value]) Set(_ Key, _ Value) {}
+
+func (n *NopCache[Key, Value]) Del(_ Key) {}
+
+func (n *NopCache[Key, Value]) GetOrRefresh(_ Key, refresh func() (Value, error)) (Value, error) {
+	return refresh()
+}
+
+func (n *NopCache[Key, Value]) Purge() {}
@@ -0,0 +1,239 @@
+package locache
+
+import "container/list"
+
+// GetOrSet returns the value of the key if the cache has a valid entry,
+// otherwise it stores the given value. The loaded result is true
+// if the value was loaded, false if it was stored.
+func (c *Cache[Key, Value]) GetOrSet(key Key, value Value) (Value, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodGetOrSet, c.requestClock.Now())
+	}
+
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+
+	cost := c.weigh(key, value)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	if element, found := c.index[key]; found {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			c.hitLocked(key, element)
+			c.mtr.IncHits(MethodGetOrSet)
+			return item.val, true
+		}
+	}
+
+	c.mtr.IncMisses(MethodGetOrSet)
+	c.store(MethodGetOrSet, key, value, cost, c.ttl)
+	return value, false
+}
+
+// Add stores the value only if the cache has no valid entry for the key.
+// It reports whether the value was stored.
+func (c *Cache[Key, Value]) Add(key Key, value Value) bool {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodAdd, c.requestClock.Now())
+	}
+
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+
+	cost := c.weigh(key, value)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	if element, found := c.index[key]; found && c.getItem(element).IsValid(c.clock.Now()) {
+		return false
+	}
+	return c.store(MethodAdd, key, value, cost, c.ttl)
+}
+
+// Replace stores the value only if the cache has a valid entry for the key.
+// It reports whether the value was stored.
+func (c *Cache[Key, Value]) Replace(key Key, value Value) bool {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodReplace, c.requestClock.Now())
+	}
+
+	cost := c.weigh(key, value)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	if element, found := c.index[key]; !found || !c.getItem(element).IsValid(c.clock.Now()) {
+		return false
+	}
+	return c.store(MethodReplace, key, value, cost, c.ttl)
+}
+
+// Pop returns the value of a valid entry and removes it under one lock.
+func (c *Cache[Key, Value]) Pop(key Key) (Value, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodPop, c.requestClock.Now())
+	}
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	var val Value
+
+	element, found := c.index[key]
+	if !found {
+		c.mtr.IncMisses(MethodPop)
+		return val, false
+	}
+
+	item := c.getItem(element)
+	valid := item.IsValid(c.clock.Now())
+	val = item.val
+
+	if !valid {
+		c.removeElement(element, RemovalExpired)
+		if item.set {
+			c.incExpired(1)
+		}
+		c.mtr.IncMisses(MethodPop)
+
+		var emptyVal Value
+		return emptyVal, false
+	}
+
+	c.deleteElement(element)
+	c.publishInvalidation(key)
+	c.incRemoved(MethodPop)
+	c.mtr.IncHits(MethodPop)
+	return val, true
+}
+
+// Update performs read-modify-write of the key under the entry lock, so it is atomic
+// against other Update and GetOrRefresh calls of the same key. The fn receives
+// the current value and whether the cache has a valid entry, it returns the new value
+// and whether to store it. Update returns the resulting value and whether it exists,
+// fn is not called by the closed cache.
+func (c *Cache[Key, Value]) Update(key Key, fn func(old Value, exists bool) (Value, bool)) (Value, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodUpdate, c.requestClock.Now())
+	}
+
+	// Close waits for the update, so the value stored by it is removed.
+	if !c.beginWork() {
+		var emptyVal Value
+		return emptyVal, false
+	}
+	defer c.work.Done()
+
+	element := c.getOrCreateElement(key)
+
+	item := c.getItem(element)
+	item.mtx.Lock()
+
+	c.mtx.RLock()
+	old, exists := item.val, item.IsValid(c.clock.Now())
+	c.mtx.RUnlock()
+
+	if !exists {
+		var emptyVal Value
+		old = emptyVal
+	}
+
+	val, ok := fn(old, exists)
+	if !ok {
+		if !item.set {
+			c.removePlaceholder(key, element)
+		}
+		item.mtx.Unlock()
+		return old, exists
+	}
+
+	cost := c.weigh(key, val)
+
+	// The entry lock is released before the callbacks are called.
+	c.mtx.Lock()
+	stored := c.storeUpdated(key, element, val, cost)
+	item.mtx.Unlock()
+	c.unlock()
+
+	return val, stored
+}
+
+// storeUpdated stores the value computed by Update under the write lock.
+func (c *Cache[Key, Value]) storeUpdated(key Key, element *list.Element, val Value, cost int64) bool {
+	if c.index[key] != element {
+		return c.store(MethodUpdate, key, val, cost, c.ttl)
+	}
+
+	item := c.getItem(element)
+	if item.set || (c.allowedByDoorkeeper(key) && c.admit(key, 0, cost)) {
+		c.setItemValue(MethodUpdate, element, val, cost, c.ttl)
+		return true
+	}
+
+	c.removeElement(element, RemovalManual)
+	return false
+}
+
+// GetWithVersion works like Get, but also returns the version of the value.
+// The version is unique within the cache and grows on every store.
+func (c *Cache[Key, Value]) GetWithVersion(key Key) (Value, uint64, bool) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodGet, c.requestClock.Now())
+	}
+
+	c.mtx.RLock()
+
+	var val Value
+
+	element, found := c.index[key]
+	if !found {
+		c.mtx.RUnlock()
+		c.mtr.IncMisses(MethodGet)
+		return val, 0, false
+	}
+
+	item := c.getItem(element)
+	if !item.IsValid(c.clock.Now()) {
+		c.mtx.RUnlock()
+		c.mtr.IncMisses(MethodGet)
+		return val, 0, false
+	}
+
+	val, version, exp := item.val, item.version, item.ExpiresAt()
+	c.mtx.RUnlock()
+
+	c.onHit(key, element, exp)
+	c.mtr.IncHits(MethodGet)
+
+	return val, version, true
+}
+
+// CompareAndSwap stores the value only if the version of the current value equals
+// the expectedVersion. The zero expectedVersion matches a missing or expired entry.
+// It reports whether the value was stored.
+func (c *Cache[Key, Value]) CompareAndSwap(key Key, expectedVersion uint64, value Value) bool {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodCompareAndSwap, c.requestClock.Now())
+	}
+
+	cost := c.weigh(key, value)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	version := uint64(0)
+	if element, found := c.index[key]; found {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			version = item.version
+		}
+	}
+
+	if version != expectedVersion {
+		return false
+	}
+	return c.store(MethodCompareAndSwap, key, value, cost, c.ttl)
+}
@@ -0,0 +1,233 @@
+package locache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetOrSet(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	actual, loaded := cache.GetOrSet("key0", "value0")
+	require.False(t, loaded)
+	require.Equal(t, "value0", actual)
+
+	actual, loaded = cache.GetOrSet("key0", "value1")
+	require.True(t, loaded)
+	require.Equal(t, "value0", actual)
+
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_GetOrSet_Expired(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.SetWithTTL("key0", "value0", time.Nanosecond)
+	time.Sleep(time.Nanosecond)
+
+	actual, loaded := cache.GetOrSet("key0", "value1")
+	require.False(t, loaded)
+	require.Equal(t, "value1", actual)
+	requireKeyExists(t, cache, "key0", "value1")
+}
+
+func TestCache_Add(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	require.True(t, cache.Add("key0", "value0"))
+	require.False(t, cache.Add("key0", "value1"))
+	requireKeyExists(t, cache, "key0", "value0")
+
+	cache.Expire("key0", 0)
+	require.True(t, cache.Add("key0", "value2"))
+	requireKeyExists(t, cache, "key0", "value2")
+}
+
+func TestCache_Replace(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	require.False(t, cache.Replace("key0", "value0"))
+	requireKeyNotExists(t, cache, "key0")
+
+	cache.Set("key0", "value0")
+	require.True(t, cache.Replace("key0", "value1"))
+	requireKeyExists(t, cache, "key0", "value1")
+
+	cache.Expire("key0", 0)
+	require.False(t, cache.Replace("key0", "value2"))
+	requireKeyNotExists(t, cache, "key0")
+}
+
+func TestCache_Pop(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+
+	actual, ok := cache.Pop("key0")
+	require.True(t, ok)
+	require.Equal(t, "value0", actual)
+	requireKeyNotExists(t, cache, "key0")
+
+	_, ok = cache.Pop("key0")
+	require.False(t, ok)
+}
+
+func TestCache_Pop_Expired(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+	cache.Expire("key0", 0)
+
+	actual, ok := cache.Pop("key0")
+	require.False(t, ok)
+	require.Empty(t, actual)
+	requireCacheItems(t, cache, []string{})
+}
+
+func TestCache_Pop_Concurrent(t *testing.T) {
+	cache := New[int, int](WithTTL(time.Minute))
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+
+	popped := atomic.Int32{}
+	wg := sync.WaitGroup{}
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, ok := cache.Pop(i); ok {
+					popped.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(100), popped.Load())
+}
+
+func TestCache_Update(t *testing.T) {
+	cache := New[string, []string](WithTTL(time.Minute))
+	appendValue := func(value string) func([]string, bool) ([]string, bool) {
+		return func(old []string, _ bool) ([]string, bool) {
+			return append(old, value), true
+		}
+	}
+
+	actual, ok := cache.Update("key0", appendValue("a"))
+	require.True(t, ok)
+	require.Equal(t, []string{"a"}, actual)
+
+	actual, ok = cache.Update("key0", appendValue("b"))
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b"}, actual)
+
+	actual, ok = cache.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b"}, actual)
+}
+
+func TestCache_Update_Skip(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	_, ok := cache.Update("key0", func(old string, exists bool) (string, bool) {
+		require.False(t, exists)
+		require.Empty(t, old)
+		return "", false
+	})
+	require.False(t, ok)
+	requireCacheItems(t, cache, []string{})
+
+	cache.Set("key0", "value0")
+	actual, ok := cache.Update("key0", func(old string, exists bool) (string, bool) {
+		require.True(t, exists)
+		require.Equal(t, "value0", old)
+		return "", false
+	})
+	require.True(t, ok)
+	require.Equal(t, "value0", actual)
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_Update_Concurrent(t *testing.T) {
+	cache := New[string, int](WithTTL(time.Minute))
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				cache.Update("counter", func(old int, _ bool) (int, bool) {
+					return old + 1, true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	actual, ok := cache.Get("counter")
+	require.True(t, ok)
+	require.Equal(t, 1000, actual)
+}
+
+func TestCache_CompareAndSwap(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+
+	require.False(t, cache.CompareAndSwap("key0", 1, "value0"))
+	require.True(t, cache.CompareAndSwap("key0", 0, "value0"))
+	require.False(t, cache.CompareAndSwap("key0", 0, "value1"))
+
+	actual, version, ok := cache.GetWithVersion("key0")
+	require.True(t, ok)
+	require.Equal(t, "value0", actual)
+
+	require.True(t, cache.CompareAndSwap("key0", version, "value1"))
+	require.False(t, cache.CompareAndSwap("key0", version, "value2"))
+	requireKeyExists(t, cache, "key0", "value1")
+
+	_, newVersion, ok := cache.GetWithVersion("key0")
+	require.True(t, ok)
+	require.Greater(t, newVersion, version)
+}
+
+func TestCache_CompareAndSwap_Recreated(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+	_, version, _ := cache.GetWithVersion("key0")
+
+	cache.Del("key0")
+	cache.Set("key0", "value1")
+
+	require.False(t, cache.CompareAndSwap("key0", version, "value2"))
+	requireKeyExists(t, cache, "key0", "value1")
+}
+
+func TestCache_CompareAndSwap_Concurrent(t *testing.T) {
+	cache := New[string, int](WithTTL(time.Minute))
+	cache.Set("counter", 0)
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				for {
+					value, version, _ := cache.GetWithVersion("counter")
+					if cache.CompareAndSwap("counter", version, value+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	actual, ok := cache.Get("counter")
+	require.True(t, ok)
+	require.Equal(t, 1000, actual)
+}
@@ -0,0 +1,431 @@
+package locache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Option func(*options)
+
+// Weigher returns the cost of an entry, e.g. the size of the value in bytes.
+type Weigher[Key comparable, Value any] func(key Key, value Value) int64
+
+type options struct {
+	ttl   time.Duration
+	mtr   Metrics
+	clock Clock
+
+	ctx            context.Context
+	purgeInterval  time.Duration
+	purgeScheduler *PurgeScheduler
+
+	purgeBatchSize  int
+	purgeTimeBudget time.Duration
+	lazyExpiration  bool
+	expiryPerWrite  int
+
+	maxEntries int
+	maxCost    int64
+	weigher    any
+	policy     any
+	onEvict    any
+	onSet      any
+	onDelete   any
+
+	eventsBuffer int
+
+	logger     *slog.Logger
+	loggerOpts LoggerOpts
+
+	tracer Tracer
+
+	name string
+
+	auditSize int
+
+	keyCodec   any
+	valueCodec any
+
+	closeSnapshotPath    string
+	closeSnapshotTimeout time.Duration
+
+	spill Store
+	bus   Bus
+
+	evictionMode EvictionMode
+
+	tinyLFUSamples int
+
+	doorkeeperKeys   int
+	doorkeeperWindow time.Duration
+
+	sliding bool
+	maxIdle time.Duration
+
+	errorTTL time.Duration
+
+	refreshTimeout time.Duration
+	retryPolicy    RetryPolicy
+
+	refreshWaitTimeout time.Duration
+
+	maxConcurrentRefreshes int
+
+	earlyRefreshBeta float64
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		mtr:   NewNopMetrics(),
+		clock: systemClock{},
+		ctx:   context.Background(),
+
+		eventsBuffer: defaultEventsBuffer,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Nil dependencies fall back to the defaults instead of panicking later.
+	if o.mtr == nil {
+		o.mtr = NewNopMetrics()
+	}
+	if o.clock == nil {
+		o.clock = systemClock{}
+	}
+	if o.ctx == nil {
+		o.ctx = context.Background()
+	}
+	return o
+}
+
+// WithTTL sets the default lifetime of entries. Zero means entries never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithMetrics sets the metrics of the cache, NopMetrics are used by default or when mtr is nil.
+func WithMetrics(mtr Metrics) Option {
+	return func(o *options) {
+		o.mtr = mtr
+	}
+}
+
+// WithClock sets the source of time used for expiration of entries and for scheduling
+// of the background work, e.g. a fake clock in tests.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithContext sets the context which bounds the background work of the cache,
+// e.g. the purging started by WithPurgeInterval.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithPurgeInterval starts purging of expired entries with the given interval
+// until the context set by WithContext is done.
+func WithPurgeInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.purgeInterval = interval
+	}
+}
+
+// WithPurgeScheduler registers the cache in the shared scheduler,
+// the cache is unregistered by Close.
+func WithPurgeScheduler(scheduler *PurgeScheduler) Option {
+	return func(o *options) {
+		o.purgeScheduler = scheduler
+	}
+}
+
+// WithLazyExpiration removes expired entries opportunistically: Get removes
+// the expired entry it meets, Set and Del remove a few expired entries
+// (see WithIncrementalExpiry).
+// It lets short-lived processes go without the purge goroutine, Purge still
+// can be called explicitly.
+func WithLazyExpiration() Option {
+	return func(o *options) {
+		o.lazyExpiration = true
+	}
+}
+
+// WithIncrementalExpiry makes every Set and Del remove up to n expired entries,
+// spreading the cost of purging across writes, so the purge interval can be longer.
+func WithIncrementalExpiry(n int) Option {
+	return func(o *options) {
+		o.expiryPerWrite = n
+	}
+}
+
+// WithPurgeBatchSize limits the number of expired entries processed by Purge
+// under one write lock. Purge releases the lock between batches to let other
+// operations proceed and resumes until no expired entries remain.
+func WithPurgeBatchSize(n int) Option {
+	return func(o *options) {
+		o.purgeBatchSize = n
+	}
+}
+
+// WithPurgeTimeBudget limits the time Purge holds the write lock at once.
+// Like WithPurgeBatchSize, Purge releases the lock when the budget is spent and resumes.
+func WithPurgeTimeBudget(d time.Duration) Option {
+	return func(o *options) {
+		o.purgeTimeBudget = d
+	}
+}
+
+// WithMaxEntries limits the number of entries held by the cache.
+// When the limit is reached, an entry chosen by the eviction policy
+// (the least recently used one by default, see WithEvictionMode) is evicted.
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// WithMaxCost limits the accumulated cost of entries held by the cache.
+// The cost of an entry is computed by the Weigher or equals 1 when it is not set.
+// When the budget is exceeded, entries chosen by the eviction policy are evicted.
+func WithMaxCost(cost int64) Option {
+	return func(o *options) {
+		o.maxCost = cost
+	}
+}
+
+// WithWeigher sets the function used to compute the cost of an entry.
+// The Key and Value types must match the types of the cache.
+func WithWeigher[Key comparable, Value any](weigher Weigher[Key, Value]) Option {
+	return func(o *options) {
+		o.weigher = weigher
+	}
+}
+
+// WithOnEvict sets the function called when an entry leaves the cache:
+// it expires, is replaced, deleted or evicted. It is called outside the cache lock.
+// The Key and Value types must match the types of the cache.
+func WithOnEvict[Key comparable, Value any](onEvict func(key Key, value Value, reason RemovalReason)) Option {
+	return func(o *options) {
+		o.onEvict = onEvict
+	}
+}
+
+// WithOnSet sets the function called after a value is stored by Set, GetOrRefresh
+// or another write. It is called outside the cache locks, so it may mirror the writes
+// into another store. The Key and Value types must match the types of the cache.
+func WithOnSet[Key comparable, Value any](onSet func(key Key, value Value)) Option {
+	return func(o *options) {
+		o.onSet = onSet
+	}
+}
+
+// WithOnDelete sets the function called after an entry is deleted by Del or Pop.
+// It is called outside the cache locks. The Key type must match the key type of the cache.
+func WithOnDelete[Key comparable](onDelete func(key Key)) Option {
+	return func(o *options) {
+		o.onDelete = onDelete
+	}
+}
+
+// WithEventsBuffer sets the size of the channel returned by Cache.Events.
+func WithEventsBuffer(size int) Option {
+	return func(o *options) {
+		o.eventsBuffer = size
+	}
+}
+
+// WithLogger enables the logs of failed refreshes, purges, evictions and snapshots
+// with the levels set by opts.
+func WithLogger(logger *slog.Logger, opts LoggerOpts) Option {
+	return func(o *options) {
+		o.logger = logger
+		o.loggerOpts = opts
+	}
+}
+
+// WithName sets the name of the cache used in the pprof labels of purges and refresh calls.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithAudit enables the audit log which keeps the last size mutations, see Cache.AuditLog.
+func WithAudit(size int) Option {
+	return func(o *options) {
+		o.auditSize = size
+	}
+}
+
+// WithCodec sets the codec of the values used by the snapshots, GobCodec by default.
+// The Value type must match the value type of the cache.
+func WithCodec[Value any](codec Codec[Value]) Option {
+	return func(o *options) {
+		o.valueCodec = codec
+	}
+}
+
+// WithKeyCodec sets the codec of the keys used by the snapshots, GobCodec by default.
+// The Key type must match the key type of the cache.
+func WithKeyCodec[Key comparable](codec Codec[Key]) Option {
+	return func(o *options) {
+		o.keyCodec = codec
+	}
+}
+
+// WithSnapshotOnClose makes Close write the snapshot to the file before the entries
+// are removed (see Cache.SnapshotFile). A timeout > 0 limits the time of the snapshot.
+func WithSnapshotOnClose(path string, timeout time.Duration) Option {
+	return func(o *options) {
+		o.closeSnapshotPath = path
+		o.closeSnapshotTimeout = timeout
+	}
+}
+
+// WithSpill writes the entries evicted by the size limit (WithMaxEntries, WithMaxCost)
+// or by Shrink to the store instead of dropping them. Get and GetWithExpiry of a missing key
+// read the spilled entry, remove it from the store and store it back to the cache, the read
+// is not a write: it is not published and doesn't fire OnSet, the events and the audit.
+// The entries are encoded by the codecs (WithCodec, WithKeyCodec) and written after the cache
+// lock is released, one operation of the store at a time. The keys of the spilled entries
+// are kept in memory, Del and Clear remove the spilled entries as well.
+func WithSpill(store Store) Option {
+	return func(o *options) {
+		o.spill = store
+	}
+}
+
+// WithInvalidation publishes the keys stored by writes or deleted by Del and Pop to the bus
+// and deletes the keys published by other replicas, so the replicas don't serve stale values
+// after a write. The values stored by GetOrRefresh and Clear are not published. The messages
+// are published after the cache lock is released, the errors are reported to the logger (WithLogger).
+// The keys are encoded by the key codec (WithKeyCodec), it has to encode equal keys to equal bytes
+// in every process, e.g. JSONCodec.
+func WithInvalidation(bus Bus) Option {
+	return func(o *options) {
+		o.bus = bus
+	}
+}
+
+// WithTracer starts the spans of GetOrRefreshCtx and of its refresh function.
+// The calls without a context are not traced.
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithEvictionMode selects one of the built-in eviction policies.
+// It is ignored when a custom policy is set with WithEvictionPolicy.
+func WithEvictionMode(mode EvictionMode) Option {
+	return func(o *options) {
+		o.evictionMode = mode
+	}
+}
+
+// WithEvictionPolicy replaces the default LRU eviction policy.
+// The Key type must match the key type of the cache.
+func WithEvictionPolicy[Key comparable](policy EvictionPolicy[Key]) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithTinyLFU enables TinyLFU admission for caches with a size limit.
+// When storing a new entry requires an eviction, the entry is admitted only
+// if its key is accessed more frequently than the key of the victim.
+// The sampleSize should be about the expected number of entries:
+// it sizes the frequency sketch and defines how often the counters are aged.
+func WithTinyLFU(sampleSize int) Option {
+	return func(o *options) {
+		o.tinyLFUSamples = sampleSize
+	}
+}
+
+// WithDoorkeeper enables a bloom filter in front of the cache: a new key is stored
+// only when it is written for the second time within the window, so keys
+// requested once don't churn the cache. The filter is sized for expectedKeys
+// and is cleared every window, a zero window never clears it.
+func WithDoorkeeper(expectedKeys int, window time.Duration) Option {
+	return func(o *options) {
+		o.doorkeeperKeys = expectedKeys
+		o.doorkeeperWindow = window
+	}
+}
+
+// WithSlidingExpiration makes every hit of Get and GetOrRefresh
+// extend the expiration of the entry by its TTL.
+func WithSlidingExpiration() Option {
+	return func(o *options) {
+		o.sliding = true
+	}
+}
+
+// WithEarlyRefresh enables the probabilistic early expiration (XFetch) in GetOrRefresh:
+// a valid entry may be refreshed before its expiration with a probability growing
+// as the expiration approaches, based on the duration of its previous refresh.
+// The beta scales the eagerness, 1 is a good default, values above 1 refresh earlier.
+func WithEarlyRefresh(beta float64) Option {
+	return func(o *options) {
+		o.earlyRefreshBeta = beta
+	}
+}
+
+// WithMaxIdle expires entries which are not accessed by Get or GetOrRefresh
+// for the given duration, even if their TTL has not passed yet.
+func WithMaxIdle(d time.Duration) Option {
+	return func(o *options) {
+		o.maxIdle = d
+	}
+}
+
+// WithErrorTTL enables negative caching: a refresh error is stored for the errorTTL
+// and returned by GetOrRefresh without calling the refresh function until it expires.
+func WithErrorTTL(errorTTL time.Duration) Option {
+	return func(o *options) {
+		o.errorTTL = errorTTL
+	}
+}
+
+// WithRefreshTimeout bounds the duration of the refresh function. When the timeout
+// passes, GetOrRefresh returns ErrRefreshTimeout and releases the entry,
+// the context passed to the refresh function is cancelled.
+func WithRefreshTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.refreshTimeout = timeout
+	}
+}
+
+// WithRetryPolicy makes GetOrRefresh retry failed refresh calls within a single call,
+// so transient errors are not returned to every caller. The refresh timeout,
+// if set, applies to every attempt.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithRefreshWaitTimeout bounds how long GetOrRefresh waits for the refresh
+// of the same key running in another goroutine. When the timeout passes,
+// the stale value is returned if the entry has one, otherwise ErrRefreshWaitTimeout.
+func WithRefreshWaitTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.refreshWaitTimeout = timeout
+	}
+}
+
+// WithMaxConcurrentRefreshes limits the number of refresh functions
+// running at once across the whole cache, including the functions still running
+// after their callers stopped waiting on WithRefreshTimeout.
+func WithMaxConcurrentRefreshes(n int) Option {
+	return func(o *options) {
+		o.maxConcurrentRefreshes = n
+	}
+}
@@ -0,0 +1,186 @@
+package locache
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// PeerClient fetches the value of the encoded key from the peer which owns it.
+// HTTPPeerClient is provided, a gRPC client implements it the same way.
+type PeerClient interface {
+	Fetch(ctx context.Context, peer string, key []byte) ([]byte, error)
+}
+
+// HTTPPeerClient fetches the values from the PeerGroup handlers of the peers,
+// the peers are the base URLs of the handlers, e.g. http://10.0.0.1:8080/locache.
+type HTTPPeerClient struct {
+	Client *http.Client
+}
+
+// Fetch implements PeerClient.
+func (c HTTPPeerClient) Fetch(ctx context.Context, peer string, key []byte) ([]byte, error) {
+	target := peer + "?key=" + url.QueryEscape(base64.RawURLEncoding.EncodeToString(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer %s: %s: %s", peer, resp.Status, body)
+	}
+	return body, nil
+}
+
+// PeerGroup fills the cache from the peers like groupcache: the missing value is requested
+// from the peer owning the key on the consistent hash ring, only the owner calls the loader,
+// so each key is loaded once per cluster. The value is stored to the cache of the owner
+// and of the requester. When the peer fails the requester calls the loader itself.
+// The keys are encoded by the key codec (WithKeyCodec), it has to encode equal keys to equal
+// bytes in every process, e.g. JSONCodec.
+type PeerGroup[Key comparable, Value any] struct {
+	cache  *Cache[Key, Value]
+	loader Loader[Key, Value]
+	client PeerClient
+	self   string
+
+	mtx  sync.RWMutex
+	ring *HashRing
+}
+
+// NewPeerGroup creates the group of the peer self, the peers include self.
+// The nil client is HTTPPeerClient with the default HTTP client.
+func NewPeerGroup[Key comparable, Value any](
+	cache *Cache[Key, Value],
+	self string,
+	loader Loader[Key, Value],
+	client PeerClient,
+	peers ...string,
+) *PeerGroup[Key, Value] {
+	if client == nil {
+		client = HTTPPeerClient{}
+	}
+
+	return &PeerGroup[Key, Value]{
+		cache:  cache,
+		loader: loader,
+		client: client,
+		self:   self,
+		ring:   NewHashRing(0, peers...),
+	}
+}
+
+// SetPeers replaces the peers of the ring, e.g. after the service discovery update.
+func (g *PeerGroup[Key, Value]) SetPeers(peers ...string) {
+	ring := NewHashRing(0, peers...)
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.ring = ring
+}
+
+// Get returns the cached value or fetches it from the owning peer, the value of the key
+// owned by this peer is loaded by the loader.
+func (g *PeerGroup[Key, Value]) Get(ctx context.Context, key Key) (Value, error) {
+	return g.cache.GetOrRefreshCtx(ctx, key, func(ctx context.Context) (Value, error) {
+		val, fetched, err := g.fetch(ctx, key)
+		if err != nil {
+			g.cache.logger.storeFailed("fetch", key, err)
+		}
+		if fetched {
+			return val, nil
+		}
+		return g.loader(ctx, key)
+	})
+}
+
+// fetch requests the value from the owner of the key, it reports whether the value was fetched.
+func (g *PeerGroup[Key, Value]) fetch(ctx context.Context, key Key) (Value, bool, error) {
+	var emptyVal Value
+
+	encoded, err := g.cache.keyCodec.Encode(key)
+	if err != nil {
+		return emptyVal, false, fmt.Errorf("encode key: %w", err)
+	}
+
+	g.mtx.RLock()
+	owner := g.ring.Get(encoded)
+	g.mtx.RUnlock()
+
+	if owner == "" || owner == g.self {
+		return emptyVal, false, nil
+	}
+
+	data, err := g.client.Fetch(ctx, owner, encoded)
+	if err != nil {
+		return emptyVal, false, err
+	}
+
+	val, err := g.cache.valueCodec.Decode(data)
+	if err != nil {
+		return emptyVal, false, fmt.Errorf("decode value: %w", err)
+	}
+	return val, true, nil
+}
+
+// ServeHTTP serves the values requested by HTTPPeerClient of other peers. The owner
+// doesn't forward the requests, it loads the missing value even if its ring differs.
+func (g *PeerGroup[Key, Value]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	encoded, err := base64.RawURLEncoding.DecodeString(r.URL.Query().Get("key"))
+	if err != nil {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+	key, err := g.cache.keyCodec.Decode(encoded)
+	if err != nil {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+
+	val, err := g.cache.GetOrRefreshCtx(r.Context(), key, func(ctx context.Context) (Value, error) {
+		return g.loader(ctx, key)
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	data, err := g.cache.valueCodec.Encode(val)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data) //nolint:errcheck
+}
@@ -0,0 +1,98 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPeer struct {
+	group  *PeerGroup[string, string]
+	server *httptest.Server
+	loads  atomic.Int32
+}
+
+func newTestPeers(t *testing.T, n int) []*testPeer {
+	peers := make([]*testPeer, n)
+	urls := make([]string, n)
+	for i := range peers {
+		peer := &testPeer{}
+		peer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer.group.ServeHTTP(w, r)
+		}))
+		t.Cleanup(peer.server.Close)
+
+		peers[i] = peer
+		urls[i] = peer.server.URL
+	}
+
+	for i, peer := range peers {
+		peer := peer
+		cache := New[string, string](WithKeyCodec[string](JSONCodec[string]{}))
+		peer.group = NewPeerGroup[string, string](cache, urls[i], func(_ context.Context, key string) (string, error) {
+			peer.loads.Add(1)
+			return "val of " + key, nil
+		}, nil, urls...)
+	}
+	return peers
+}
+
+func TestPeerGroup(t *testing.T) {
+	ctx := context.Background()
+	peers := newTestPeers(t, 3)
+
+	for _, peer := range peers {
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("key%d", i)
+			val, err := peer.group.Get(ctx, key)
+			require.NoError(t, err)
+			require.Equal(t, "val of "+key, val)
+		}
+	}
+
+	// Each key is loaded once by its owner.
+	total := int32(0)
+	for _, peer := range peers {
+		total += peer.loads.Load()
+	}
+	require.Equal(t, int32(10), total)
+}
+
+func TestPeerGroup_PeerDown(t *testing.T) {
+	ctx := context.Background()
+	peers := newTestPeers(t, 2)
+	for _, peer := range peers {
+		peer.server.Close()
+	}
+
+	// The loader of the requester is called when the owner fails.
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key%d", i)
+		val, err := peers[0].group.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "val of "+key, val)
+	}
+	require.Equal(t, int32(10), peers[0].loads.Load())
+}
+
+func TestPeerGroup_ServeHTTP(t *testing.T) {
+	peers := newTestPeers(t, 1)
+
+	resp, err := http.Post(peers[0].server.URL, "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Get(peers[0].server.URL + "?key=%%%")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, err = HTTPPeerClient{}.Fetch(context.Background(), peers[0].server.URL, []byte("not json"))
+	require.ErrorContains(t, err, "400 Bad Request")
+}
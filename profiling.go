@@ -0,0 +1,34 @@
+package locache
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Keys of the pprof labels set on purges and refresh calls.
+const (
+	LabelCache  = "locache_cache"
+	LabelMethod = "locache_method"
+)
+
+// profilerLabels returns the labels of the cache method, the cache label is set
+// only for the named caches (WithName).
+func profilerLabels(name, method string) pprof.LabelSet {
+	if name == "" {
+		return pprof.Labels(LabelMethod, method)
+	}
+	return pprof.Labels(LabelCache, name, LabelMethod, method)
+}
+
+// labelRefresh wraps the refresh function to attribute its CPU time in profiles to the cache.
+func labelRefresh[Value any](
+	labels pprof.LabelSet,
+	refresh func(ctx context.Context) (Value, error),
+) func(ctx context.Context) (Value, error) {
+	return func(ctx context.Context) (val Value, err error) {
+		pprof.Do(ctx, labels, func(ctx context.Context) {
+			val, err = refresh(ctx)
+		})
+		return val, err
+	}
+}
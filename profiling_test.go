@@ -0,0 +1,33 @@
+package locache
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_RefreshProfilerLabels(t *testing.T) {
+	labels := func(cache *Cache[string, string]) map[string]string {
+		labels := map[string]string{}
+		_, err := cache.GetOrRefreshCtx(context.Background(), "key0", func(ctx context.Context) (string, error) {
+			pprof.ForLabels(ctx, func(key, value string) bool {
+				labels[key] = value
+				return true
+			})
+			return "value0", nil
+		})
+		require.NoError(t, err)
+		return labels
+	}
+
+	require.Equal(t, map[string]string{
+		LabelMethod: MethodGetOrRefresh,
+	}, labels(New[string, string]()))
+
+	require.Equal(t, map[string]string{
+		LabelCache:  "users",
+		LabelMethod: MethodGetOrRefresh,
+	}, labels(NewManaged[string, string](NewManager(0, nil), "users")))
+}
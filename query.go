@@ -0,0 +1,117 @@
+package locache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Querier runs the queries, it is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// QueryCache caches the results of the read-mostly queries with the TTL of the cache.
+// The rows are materialized by the scan function called for every row.
+type QueryCache[T any] struct {
+	cache *Cache[string, []T]
+	db    Querier
+	scan  func(rows *sql.Rows) (T, error)
+}
+
+// NewQueryCache creates the query cache, e.g.
+//
+//	users := locache.NewQueryCache(cache, db, func(rows *sql.Rows) (u User, err error) {
+//		return u, rows.Scan(&u.ID, &u.Name)
+//	})
+//	list, err := users.Query(ctx, "SELECT id, name FROM users WHERE team = ?", team)
+func NewQueryCache[T any](cache *Cache[string, []T], db Querier, scan func(rows *sql.Rows) (T, error)) *QueryCache[T] {
+	return &QueryCache[T]{cache: cache, db: db, scan: scan}
+}
+
+// Query returns the cached result of the query with the args or runs the query,
+// concurrent calls of the same query wait for one of them.
+func (q *QueryCache[T]) Query(ctx context.Context, query string, args ...any) ([]T, error) {
+	key, err := queryKey(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.cache.GetOrRefreshCtx(ctx, key, func(ctx context.Context) ([]T, error) {
+		return q.query(ctx, query, args...)
+	})
+}
+
+// Invalidate deletes the cached result of the query with the args.
+func (q *QueryCache[T]) Invalidate(query string, args ...any) error {
+	key, err := queryKey(query, args...)
+	if err != nil {
+		return err
+	}
+
+	q.cache.Del(key)
+	return nil
+}
+
+func (q *QueryCache[T]) query(ctx context.Context, query string, args ...any) ([]T, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []T
+	for rows.Next() {
+		row, err := q.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read rows: %w", err)
+	}
+	return result, nil
+}
+
+// queryKey returns the cache key of the query with the args. The args are converted
+// to driver values like database/sql does: driver.Valuer args are replaced by their values
+// and pointers are dereferenced. Every part of the key is prefixed by its length,
+// so different queries and args never produce the same key.
+func queryKey(query string, args ...any) (string, error) {
+	var key strings.Builder
+	writeKeyPart(&key, query)
+	for _, arg := range args {
+		name := ""
+		if named, ok := arg.(sql.NamedArg); ok {
+			name, arg = named.Name, named.Value
+		}
+
+		value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return "", fmt.Errorf("convert arg: %w", err)
+		}
+
+		writeKeyPart(&key, name)
+		writeKeyPart(&key, fmt.Sprintf("%T", value))
+		switch v := value.(type) {
+		case []byte:
+			writeKeyPart(&key, string(v))
+		case time.Time:
+			writeKeyPart(&key, v.Format(time.RFC3339Nano))
+		default:
+			writeKeyPart(&key, fmt.Sprint(v))
+		}
+	}
+	return key.String(), nil
+}
+
+func writeKeyPart(key *strings.Builder, part string) {
+	key.WriteString(strconv.Itoa(len(part)))
+	key.WriteByte(':')
+	key.WriteString(part)
+}
@@ -0,0 +1,186 @@
+package locache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// usersDriver is the database/sql driver of a single query over the users table:
+// "SELECT id, name FROM users WHERE id > ?".
+type usersDriver struct {
+	queries atomic.Int32
+	users   []snapshotUser
+}
+
+func (d *usersDriver) Open(string) (driver.Conn, error) {
+	return usersConn{d}, nil
+}
+
+type usersConn struct {
+	driver *usersDriver
+}
+
+func (c usersConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported")
+}
+
+func (c usersConn) Close() error {
+	return nil
+}
+
+func (c usersConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+func (c usersConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.queries.Add(1)
+	if query != "SELECT id, name FROM users WHERE id > ?" || len(args) != 1 {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+
+	rows := &usersRows{}
+	for _, user := range c.driver.users {
+		if user.ID > args[0].Value.(int64) {
+			rows.users = append(rows.users, user)
+		}
+	}
+	return rows, nil
+}
+
+type usersRows struct {
+	users []snapshotUser
+}
+
+func (r *usersRows) Columns() []string {
+	return []string{"id", "name"}
+}
+
+func (r *usersRows) Close() error {
+	return nil
+}
+
+func (r *usersRows) Next(dest []driver.Value) error {
+	if len(r.users) == 0 {
+		return io.EOF
+	}
+	dest[0], dest[1] = r.users[0].ID, r.users[0].Name
+	r.users = r.users[1:]
+	return nil
+}
+
+var usersDriverID atomic.Int32
+
+func openUsersDB(t *testing.T, users ...snapshotUser) (*sql.DB, *usersDriver) {
+	t.Helper()
+
+	d := &usersDriver{users: users}
+	name := fmt.Sprintf("locache-users-%d", usersDriverID.Add(1))
+	sql.Register(name, d)
+
+	db, err := sql.Open(name, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestQueryCache(t *testing.T) {
+	db, d := openUsersDB(t, snapshotUser{ID: 1, Name: "John"}, snapshotUser{ID: 2, Name: "Jane"})
+
+	clock := newFakeClock()
+	cache := New[string, []snapshotUser](WithTTL(time.Minute), WithClock(clock))
+	users := NewQueryCache(cache, db, func(rows *sql.Rows) (u snapshotUser, err error) {
+		return u, rows.Scan(&u.ID, &u.Name)
+	})
+
+	ctx := context.Background()
+	const query = "SELECT id, name FROM users WHERE id > ?"
+
+	result, err := users.Query(ctx, query, 0)
+	require.NoError(t, err)
+	require.Equal(t, []snapshotUser{{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}}, result)
+
+	result, err = users.Query(ctx, query, 0)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Equal(t, int32(1), d.queries.Load())
+
+	result, err = users.Query(ctx, query, 1)
+	require.NoError(t, err)
+	require.Equal(t, []snapshotUser{{ID: 2, Name: "Jane"}}, result)
+	require.Equal(t, int32(2), d.queries.Load())
+
+	require.NoError(t, users.Invalidate(query, 1))
+	_, err = users.Query(ctx, query, 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), d.queries.Load())
+
+	clock.Advance(time.Minute)
+	_, err = users.Query(ctx, query, 0)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), d.queries.Load())
+
+	_, err = users.Query(ctx, "SELECT 1", 0)
+	require.ErrorContains(t, err, "unexpected query")
+	require.Equal(t, 2, cache.Len())
+}
+
+func TestQueryKey(t *testing.T) {
+	key1, err := queryKey("SELECT ?", 1)
+	require.NoError(t, err)
+	key2, err := queryKey("SELECT ?", "1")
+	require.NoError(t, err)
+	require.NotEqual(t, key1, key2)
+
+	// The args are compared as the driver values.
+	key3, err := queryKey("SELECT ?", sql.NullInt64{Int64: 1, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, key1, key3)
+
+	key4, err := queryKey("SELECT ?", int64(1))
+	require.NoError(t, err)
+	require.Equal(t, key3, key4)
+
+	key5, err := queryKey("SELECT @id", sql.Named("id", 1))
+	require.NoError(t, err)
+	require.NotEqual(t, key1, key5)
+}
+
+func TestQueryKey_Unambiguous(t *testing.T) {
+	key1, err := queryKey("SELECT ?", "x\x00:string:y")
+	require.NoError(t, err)
+	key2, err := queryKey("SELECT ?", "x", "y")
+	require.NoError(t, err)
+	require.NotEqual(t, key1, key2)
+
+	key1, err = queryKey("SELECT ?1", "")
+	require.NoError(t, err)
+	key2, err = queryKey("SELECT ?", "1")
+	require.NoError(t, err)
+	require.NotEqual(t, key1, key2)
+
+	// The pointers are dereferenced, the equal values have the same key.
+	id1, id2 := 1, 1
+	key1, err = queryKey("SELECT ?", &id1)
+	require.NoError(t, err)
+	key2, err = queryKey("SELECT ?", &id2)
+	require.NoError(t, err)
+	require.Equal(t, key1, key2)
+
+	at := time.Now()
+	key1, err = queryKey("SELECT ?", at)
+	require.NoError(t, err)
+	key2, err = queryKey("SELECT ?", at.Round(0))
+	require.NoError(t, err)
+	require.Equal(t, key1, key2)
+
+	_, err = queryKey("SELECT ?", struct{}{})
+	require.ErrorContains(t, err, "convert arg")
+}
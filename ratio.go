@@ -0,0 +1,118 @@
+package locache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ratioWindow is the window of the rolling hit ratio.
+const ratioWindow = time.Minute
+
+type ratioSnapshot struct {
+	at    time.Time
+	hits  uint64
+	total uint64
+}
+
+// rollingRatio counts the hits and the misses with atomics. The ratio over the last
+// minute is computed on collection against the counters remembered by earlier collections,
+// so the requests don't lock or read the time.
+type rollingRatio struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+
+	mtx sync.Mutex
+	// snapshots of the counters, the oldest first.
+	snapshots []ratioSnapshot
+}
+
+func newRollingRatio(at time.Time) *rollingRatio {
+	return &rollingRatio{snapshots: []ratioSnapshot{{at: at}}}
+}
+
+func (r *rollingRatio) record(hit bool) {
+	if hit {
+		r.hits.Add(1)
+	} else {
+		r.misses.Add(1)
+	}
+}
+
+// ratio returns the ratio of hits since the latest snapshot taken at least the window ago,
+// or since the oldest snapshot, and remembers the current counters.
+func (r *rollingRatio) ratio(at time.Time) float64 {
+	misses := r.misses.Load()
+	hits := r.hits.Load()
+	current := ratioSnapshot{at: at, hits: hits, total: hits + misses}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	base := 0
+	for i := 1; i < len(r.snapshots) && !r.snapshots[i].at.After(at.Add(-ratioWindow)); i++ {
+		base = i
+	}
+	r.snapshots = append(r.snapshots[base:], current)
+
+	if current.total == r.snapshots[0].total {
+		return 0
+	}
+	return float64(current.hits-r.snapshots[0].hits) / float64(current.total-r.snapshots[0].total)
+}
+
+// hitRatios is the collector of the hit_ratio gauge of the caches labeled by the names.
+type hitRatios struct {
+	desc   *prometheus.Desc
+	labels []string
+	clock  Clock
+
+	mtx    sync.Mutex
+	ratios map[string]labeledRatio
+}
+
+type labeledRatio struct {
+	values []string
+	ratio  *rollingRatio
+}
+
+func newHitRatios(desc *prometheus.Desc, labels []string) *hitRatios {
+	return &hitRatios{desc: desc, labels: labels, clock: systemClock{}, ratios: make(map[string]labeledRatio)}
+}
+
+// get returns the ratio of the labels, the metrics of the same cache share it.
+func (h *hitRatios) get(labels prometheus.Labels) *rollingRatio {
+	values := make([]string, len(h.labels))
+	for i, name := range h.labels {
+		values[i] = labels[name]
+	}
+	key := strings.Join(values, "\xff")
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if labeled, found := h.ratios[key]; found {
+		return labeled.ratio
+	}
+
+	ratio := newRollingRatio(h.clock.Now())
+	h.ratios[key] = labeledRatio{values: values, ratio: ratio}
+	return ratio
+}
+
+func (h *hitRatios) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.desc
+}
+
+func (h *hitRatios) Collect(ch chan<- prometheus.Metric) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	at := h.clock.Now()
+	for _, labeled := range h.ratios {
+		ch <- prometheus.MustNewConstMetric(h.desc, prometheus.GaugeValue, labeled.ratio.ratio(at), labeled.values...)
+	}
+}
@@ -0,0 +1,33 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollingRatio(t *testing.T) {
+	at := time.Unix(0, 0)
+	ratio := newRollingRatio(at)
+	require.Equal(t, 0.0, ratio.ratio(at))
+
+	ratio.record(true)
+	ratio.record(false)
+	at = at.Add(30 * time.Second)
+	require.Equal(t, 0.5, ratio.ratio(at))
+
+	ratio.record(true)
+	at = at.Add(30 * time.Second)
+	require.InDelta(t, 2.0/3, ratio.ratio(at), 1e-9)
+
+	// The requests before the collection of a minute ago leave the window.
+	ratio.record(true)
+	at = at.Add(30 * time.Second)
+	require.Equal(t, 1.0, ratio.ratio(at))
+
+	ratio.record(false)
+	at = at.Add(time.Hour)
+	require.Equal(t, 0.0, ratio.ratio(at))
+	require.Len(t, ratio.snapshots, 2)
+}
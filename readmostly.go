@@ -0,0 +1,208 @@
+package locache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/atkhx/locache/flight"
+)
+
+type readMostlyEntry[Value any] struct {
+	val Value
+	exp time.Time
+}
+
+// ReadMostly is a cache for data which is written rarely and read constantly.
+// Get reads an immutable map through an atomic pointer without any lock,
+// every write copies the map, so writes cost O(n): Batch applies many writes
+// with one copy. Readers see either all writes of the batch or none. It supports WithTTL,
+// WithMetrics and WithClock, other options are ignored.
+type ReadMostly[Key comparable, Value any] struct {
+	ttl   time.Duration
+	mtr   Metrics
+	clock Clock
+	timed bool
+
+	requestClock Clock
+
+	entries atomic.Pointer[map[Key]readMostlyEntry[Value]]
+	mtx     sync.Mutex
+
+	calls flight.Group[Key, Value]
+}
+
+func NewReadMostly[Key comparable, Value any](opts ...Option) *ReadMostly[Key, Value] {
+	o := newOptions(opts)
+
+	r := &ReadMostly[Key, Value]{
+		ttl:   o.ttl,
+		mtr:   o.mtr,
+		clock: o.clock,
+		timed: !isNopMetrics(o.mtr),
+
+		requestClock: requestClock(o.clock),
+	}
+
+	entries := make(map[Key]readMostlyEntry[Value])
+	r.entries.Store(&entries)
+	return r
+}
+
+func (r *ReadMostly[Key, Value]) Get(key Key) (Value, bool) {
+	if r.timed {
+		defer r.mtr.ObserveRequest(MethodGet, r.requestClock.Now())
+	}
+
+	val, ok := r.get(key)
+	if !ok {
+		r.mtr.IncMisses(MethodGet)
+		return val, false
+	}
+
+	r.mtr.IncHits(MethodGet)
+	return val, true
+}
+
+func (r *ReadMostly[Key, Value]) Set(key Key, value Value) {
+	r.SetWithTTL(key, value, r.ttl)
+}
+
+// SetWithTTL stores the value with its own TTL, a ttl <= 0 keeps the entry
+// until it is deleted.
+func (r *ReadMostly[Key, Value]) SetWithTTL(key Key, value Value, ttl time.Duration) {
+	if r.timed {
+		defer r.mtr.ObserveRequest(MethodSet, r.requestClock.Now())
+	}
+
+	r.update(func(entries map[Key]readMostlyEntry[Value]) {
+		entries[key] = readMostlyEntry[Value]{val: value, exp: r.expiration(ttl)}
+	})
+}
+
+func (r *ReadMostly[Key, Value]) Del(key Key) {
+	if r.timed {
+		defer r.mtr.ObserveRequest(MethodDel, r.requestClock.Now())
+	}
+
+	r.update(func(entries map[Key]readMostlyEntry[Value]) {
+		delete(entries, key)
+	})
+}
+
+// GetOrRefresh works like Cache.GetOrRefresh: concurrent misses of the same key
+// wait for one refresh call.
+func (r *ReadMostly[Key, Value]) GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error) {
+	if r.timed {
+		defer r.mtr.ObserveRequest(MethodGetOrRefresh, r.requestClock.Now())
+	}
+
+	if val, ok := r.get(key); ok {
+		r.mtr.IncHits(MethodGetOrRefresh)
+		return val, nil
+	}
+
+	return r.calls.DoOnce(key, func() (Value, error) {
+		// The value could be stored while the call was registered.
+		if val, ok := r.get(key); ok {
+			r.mtr.IncHits(MethodGetOrRefresh)
+			return val, nil
+		}
+
+		val, err := refresh()
+		if err != nil {
+			r.mtr.IncErrors(MethodGetOrRefresh)
+			return val, fmt.Errorf("refresh val: %w", err)
+		}
+
+		r.mtr.IncMisses(MethodGetOrRefresh)
+		r.update(func(entries map[Key]readMostlyEntry[Value]) {
+			entries[key] = readMostlyEntry[Value]{val: val, exp: r.expiration(r.ttl)}
+		})
+		return val, nil
+	})
+}
+
+// ReadMostlyBatch collects the writes of Batch, it must not be used after Batch returns.
+type ReadMostlyBatch[Key comparable, Value any] struct {
+	r       *ReadMostly[Key, Value]
+	entries map[Key]readMostlyEntry[Value]
+}
+
+func (b *ReadMostlyBatch[Key, Value]) Set(key Key, value Value) {
+	b.SetWithTTL(key, value, b.r.ttl)
+}
+
+func (b *ReadMostlyBatch[Key, Value]) SetWithTTL(key Key, value Value, ttl time.Duration) {
+	b.entries[key] = readMostlyEntry[Value]{val: value, exp: b.r.expiration(ttl)}
+}
+
+func (b *ReadMostlyBatch[Key, Value]) Del(key Key) {
+	delete(b.entries, key)
+}
+
+// Batch applies the writes of fn to one copy of the map and publishes it atomically,
+// concurrent writes wait for the batch.
+func (r *ReadMostly[Key, Value]) Batch(fn func(b *ReadMostlyBatch[Key, Value])) {
+	if r.timed {
+		defer r.mtr.ObserveRequest(MethodBatch, r.requestClock.Now())
+	}
+
+	r.update(func(entries map[Key]readMostlyEntry[Value]) {
+		fn(&ReadMostlyBatch[Key, Value]{r: r, entries: entries})
+	})
+}
+
+// Purge removes expired entries with one copy of the map.
+func (r *ReadMostly[Key, Value]) Purge() {
+	if r.timed {
+		defer r.mtr.ObserveRequest(MethodPurge, r.requestClock.Now())
+	}
+
+	current := r.clock.Now()
+	r.update(func(entries map[Key]readMostlyEntry[Value]) {
+		for key, entry := range entries {
+			if !entry.exp.IsZero() && !current.Before(entry.exp) {
+				delete(entries, key)
+			}
+		}
+	})
+}
+
+// Len returns the number of entries, including expired entries which are not purged yet.
+func (r *ReadMostly[Key, Value]) Len() int {
+	return len(*r.entries.Load())
+}
+
+func (r *ReadMostly[Key, Value]) get(key Key) (Value, bool) {
+	entry, found := (*r.entries.Load())[key]
+	if !found || (!entry.exp.IsZero() && !r.clock.Now().Before(entry.exp)) {
+		var val Value
+		return val, false
+	}
+	return entry.val, true
+}
+
+// update applies fn to a copy of the entries and publishes the copy.
+func (r *ReadMostly[Key, Value]) update(fn func(entries map[Key]readMostlyEntry[Value])) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	current := *r.entries.Load()
+	entries := make(map[Key]readMostlyEntry[Value], len(current)+1)
+	for key, entry := range current {
+		entries[key] = entry
+	}
+
+	fn(entries)
+	r.mtr.SetItemsCount(len(entries))
+	r.entries.Store(&entries)
+}
+
+func (r *ReadMostly[Key, Value]) expiration(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return r.clock.Now().Add(ttl)
+}
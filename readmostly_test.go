@@ -0,0 +1,107 @@
+package locache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMostly_GetSetDel(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewReadMostly[string, string](WithTTL(time.Minute), WithClock(clock))
+
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	actual, ok := cache.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, "value0", actual)
+
+	cache.Del("key0")
+	_, ok = cache.Get("key0")
+	require.False(t, ok)
+
+	clock.Advance(time.Minute)
+	_, ok = cache.Get("key1")
+	require.False(t, ok)
+	require.Equal(t, 1, cache.Len())
+
+	cache.Purge()
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestReadMostly_GetOrRefresh(t *testing.T) {
+	cache := NewReadMostly[string, string](WithTTL(time.Minute))
+
+	calls := atomic.Int32{}
+	release := make(chan struct{})
+	refresh := func() (string, error) {
+		calls.Add(1)
+		<-release
+		return "value0", nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			actual, err := cache.GetOrRefresh("key0", refresh)
+			require.NoError(t, err)
+			require.Equal(t, "value0", actual)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+
+	errRefresh := errors.New("refresh failed")
+	_, err := cache.GetOrRefresh("key1", func() (string, error) {
+		return "", errRefresh
+	})
+	require.ErrorIs(t, err, errRefresh)
+
+	_, ok := cache.Get("key1")
+	require.False(t, ok)
+}
+
+func TestReadMostly_Batch(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewReadMostly[string, string](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+
+	before := cache.entries.Load()
+	cache.Batch(func(b *ReadMostlyBatch[string, string]) {
+		b.Set("key2", "value2")
+		b.SetWithTTL("key3", "value3", 0)
+		b.Del("key0")
+
+		// The batch is not visible until it is published.
+		_, ok := cache.Get("key2")
+		require.False(t, ok)
+	})
+
+	require.Len(t, *before, 2)
+	require.Equal(t, 3, cache.Len())
+
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+
+	clock.Advance(time.Minute)
+	_, ok = cache.Get("key2")
+	require.False(t, ok)
+
+	actual, ok := cache.Get("key3")
+	require.True(t, ok)
+	require.Equal(t, "value3", actual)
+}
@@ -0,0 +1,76 @@
+package locache
+
+import "sync"
+
+// Operation is a call recorded by RecordingCache. The Key is empty for Purge.
+type Operation[Key comparable] struct {
+	Method string
+	Key    Key
+}
+
+// RecordingCache records all operations and passes them to the underlying cache.
+type RecordingCache[Key comparable, Value any] struct {
+	cache Cacher[Key, Value]
+
+	mtx        sync.Mutex
+	operations []Operation[Key]
+}
+
+// NewRecordingCache wraps the cache, NopCache is used when the cache is nil.
+func NewRecordingCache[Key comparable, Value any](cache Cacher[Key, Value]) *RecordingCache[Key, Value] {
+	if cache == nil {
+		cache = NewNopCache[Key, Value]()
+	}
+	return &RecordingCache[Key, Value]{cache: cache}
+}
+
+func (r *RecordingCache[Key, Value]) Get(key Key) (Value, bool) {
+	r.record(MethodGet, key)
+	return r.cache.Get(key)
+}
+
+func (r *RecordingCache[Key, Value]) Set(key Key, value Value) {
+	r.record(MethodSet, key)
+	r.cache.Set(key, value)
+}
+
+func (r *RecordingCache[Key, Value]) Del(key Key) {
+	r.record(MethodDel, key)
+	r.cache.Del(key)
+}
+
+func (r *RecordingCache[Key, Value]) GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error) {
+	r.record(MethodGetOrRefresh, key)
+	return r.cache.GetOrRefresh(key, refresh)
+}
+
+func (r *RecordingCache[Key, Value]) Purge() {
+	var key Key
+	r.record(MethodPurge, key)
+	r.cache.Purge()
+}
+
+// Operations returns a copy of the recorded operations in the order of calls.
+func (r *RecordingCache[Key, Value]) Operations() []Operation[Key] {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	operations := make([]Operation[Key], len(r.operations))
+	copy(operations, r.operations)
+	return operations
+}
+
+// Reset forgets the recorded operations.
+func (r *RecordingCache[Key, Value]) Reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.operations = nil
+}
+
+func (r *RecordingCache[Key, Value]) record(method string, key Key) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.operations = append(r.operations, Operation[Key]{Method: method, Key: key})
+}
@@ -0,0 +1,64 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNopCache(t *testing.T) {
+	cache := NewNopCache[string, string]()
+	cache.Set("key0", "value0")
+
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+			calls++
+			return "value0", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "value0", actual)
+	}
+	require.Equal(t, 2, calls)
+}
+
+func TestRecordingCache(t *testing.T) {
+	cache := NewRecordingCache[string, string](New[string, string](WithTTL(time.Minute)))
+
+	cache.Set("key0", "value0")
+	actual, ok := cache.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, "value0", actual)
+
+	_, err := cache.GetOrRefresh("key1", func() (string, error) {
+		return "value1", nil
+	})
+	require.NoError(t, err)
+
+	cache.Del("key0")
+	cache.Purge()
+
+	require.Equal(t, []Operation[string]{
+		{Method: MethodSet, Key: "key0"},
+		{Method: MethodGet, Key: "key0"},
+		{Method: MethodGetOrRefresh, Key: "key1"},
+		{Method: MethodDel, Key: "key0"},
+		{Method: MethodPurge},
+	}, cache.Operations())
+
+	cache.Reset()
+	require.Empty(t, cache.Operations())
+}
+
+func TestRecordingCache_Nop(t *testing.T) {
+	cache := NewRecordingCache[string, string](nil)
+	cache.Set("key0", "value0")
+
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+	require.Len(t, cache.Operations(), 2)
+}
@@ -0,0 +1,404 @@
+package locache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrRefreshTimeout     = errors.New("refresh timeout")
+	ErrRefreshWaitTimeout = errors.New("refresh wait timeout")
+)
+
+func (c *Cache[Key, Value]) getOrCreateElement(key Key) *list.Element {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	element, found := c.index[key]
+	if !found {
+		item := &Item[Key, Value]{
+			mtx: newItemLock(),
+			key: key,
+			exp: c.expiration(c.ttl),
+		}
+		element = c.items.PushBack(item)
+		c.index[key] = element
+		c.expiries.update(item)
+	}
+
+	return element
+}
+
+// lockElement returns the locked item of the key creating a placeholder for a missing key.
+// The placeholder removed while waiting for its lock is replaced by a new one,
+// so the waiter refreshes the value into the cache. On ErrRefreshWaitTimeout
+// the returned item is not locked.
+func (c *Cache[Key, Value]) lockElement(ctx context.Context, key Key) (*list.Element, *Item[Key, Value], error) {
+	for {
+		element := c.getOrCreateElement(key)
+
+		item := c.getItem(element)
+		if err := c.lockItem(ctx, item); err != nil {
+			return element, item, err
+		}
+
+		c.mtx.RLock()
+		current := c.index[key] == element
+		c.mtx.RUnlock()
+
+		if current {
+			return element, item, nil
+		}
+		item.mtx.Unlock()
+	}
+}
+
+func (c *Cache[Key, Value]) GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error) {
+	return c.getOrRefresh(context.Background(), key, c.ttl, nil, refresh)
+}
+
+// GetOrRefreshCtx works like GetOrRefresh, but passes the context to the refresh function.
+// It stops waiting for the refresh of another goroutine and for its own refresh
+// when the context is done.
+func (c *Cache[Key, Value]) GetOrRefreshCtx(
+	ctx context.Context,
+	key Key,
+	refresh func(ctx context.Context) (Value, error),
+) (Value, error) {
+	return c.getOrRefresh(ctx, key, c.ttl, refresh, nil)
+}
+
+// GetOrRefreshWithTTL works like GetOrRefresh, but stores the refreshed value
+// with its own TTL instead of the cache default.
+func (c *Cache[Key, Value]) GetOrRefreshWithTTL(
+	key Key,
+	ttl time.Duration,
+	refresh func() (Value, error),
+) (Value, error) {
+	return c.getOrRefresh(context.Background(), key, ttl, nil, refresh)
+}
+
+// getOrRefresh takes either refreshCtx or refresh, the latter is wrapped
+// only on a miss to keep the hit path free of allocations.
+func (c *Cache[Key, Value]) getOrRefresh(
+	ctx context.Context,
+	key Key,
+	ttl time.Duration,
+	refreshCtx func(ctx context.Context) (Value, error),
+	refresh func() (Value, error),
+) (Value, error) {
+	if c.timed {
+		defer c.mtr.ObserveRequest(MethodGetOrRefresh, c.requestClock.Now())
+	}
+
+	trace := c.startTrace(&ctx, refreshCtx != nil)
+	defer trace.end()
+
+	if !c.beginWork() {
+		c.mtr.IncErrors(MethodGetOrRefresh)
+		trace.fail(ErrClosed)
+
+		var emptyVal Value
+		return emptyVal, ErrClosed
+	}
+	defer c.work.Done()
+
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+
+	element, item, err := c.lockElement(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrRefreshWaitTimeout) {
+			// Serve the stale value instead of waiting for the refresh.
+			c.mtx.RLock()
+			stale, set := item.val, item.set
+			c.mtx.RUnlock()
+
+			if set {
+				c.mtr.IncHits(MethodGetOrRefresh)
+				trace.setResult(ResultStale)
+				return stale, nil
+			}
+		}
+
+		c.mtr.IncErrors(MethodGetOrRefresh)
+		trace.fail(err)
+
+		var emptyVal Value
+		return emptyVal, fmt.Errorf("wait refresh: %w", err)
+	}
+
+	c.mtx.RLock()
+	current, exp, delta, valid := item.val, item.ExpiresAt(), item.delta, item.IsValid(c.clock.Now())
+	cachedErr := item.cachedError(c.clock.Now())
+	c.mtx.RUnlock()
+
+	if valid && !c.shouldRefreshEarly(exp, delta) {
+		item.mtx.Unlock()
+
+		c.onHit(key, element, exp)
+		c.mtr.IncHits(MethodGetOrRefresh)
+		trace.setResult(ResultHit)
+
+		return current, nil
+	}
+
+	if !valid && cachedErr != nil {
+		item.mtx.Unlock()
+		c.mtr.IncErrors(MethodGetOrRefresh)
+		trace.fail(cachedErr)
+
+		var emptyVal Value
+		return emptyVal, fmt.Errorf("refresh val: %w", cachedErr)
+	}
+
+	if refreshCtx == nil {
+		refreshCtx = withoutContext(refresh)
+	}
+	refreshCtx = labelRefresh(c.refreshLabels, traceRefresh(trace, refreshCtx))
+
+	refreshStart := c.clock.Now()
+	val, err := c.refreshItem(ctx, key, element, item, refreshCtx)
+	if err != nil {
+		c.mtr.IncErrors(MethodGetOrRefresh)
+		c.logger.refreshFailed(key, err, valid)
+
+		// The value is still valid when the early refresh fails.
+		if valid {
+			item.mtx.Unlock()
+			trace.setResult(ResultStale)
+			return current, nil
+		}
+		trace.fail(err)
+
+		// The error of the cancelled call says nothing about the backend.
+		if c.errorTTL > 0 && ctx.Err() == nil {
+			c.mtx.Lock()
+			// The entry deleted during the refresh is not returned to the expiry heap.
+			if c.index[key] == element {
+				item.err = err
+				item.errExp = c.clock.Now().Add(c.errorTTL)
+				if !item.set {
+					// The placeholder lives as long as the cached error.
+					item.exp = item.errExp
+					c.expiries.update(item)
+				}
+			}
+			c.mtx.Unlock()
+		} else {
+			c.removePlaceholder(key, element)
+		}
+		item.mtx.Unlock()
+
+		var emptyVal Value
+		return emptyVal, fmt.Errorf("refresh val: %w", err)
+	}
+	delta = c.clock.Now().Sub(refreshStart)
+
+	cost := c.weigh(key, val)
+
+	c.mtx.Lock()
+	item.delta = delta
+	if c.index[key] == element {
+		if item.set || (c.allowedByDoorkeeper(key) && c.admit(key, 0, cost)) {
+			c.setItemValue(MethodGetOrRefresh, element, val, cost, ttl)
+		} else {
+			c.removeElement(element, RemovalManual)
+		}
+	}
+	item.mtx.Unlock()
+	c.unlock()
+
+	trace.setResult(ResultMiss)
+	return val, nil
+}
+
+// refreshItem calls the refresh function of the locked item. When the refresh function
+// panics, the item is unlocked and its placeholder removed before the panic is repeated,
+// so the next caller refreshes the key again.
+func (c *Cache[Key, Value]) refreshItem(
+	ctx context.Context,
+	key Key,
+	element *list.Element,
+	item *Item[Key, Value],
+	refresh func(ctx context.Context) (Value, error),
+) (Value, error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			c.removePlaceholder(key, element)
+			item.mtx.Unlock()
+			panic(recovered)
+		}
+	}()
+
+	return c.callRefresh(ctx, refresh)
+}
+
+// shouldRefreshEarly implements the probabilistic early expiration (XFetch):
+// the closer the entry to its expiration and the longer its last refresh took,
+// the higher the chance to refresh it before the expiration.
+func (c *Cache[Key, Value]) shouldRefreshEarly(exp time.Time, delta time.Duration) bool {
+	if c.earlyRefreshBeta <= 0 || exp.IsZero() || delta <= 0 {
+		return false
+	}
+
+	gap := time.Duration(-float64(delta) * c.earlyRefreshBeta * math.Log(random()))
+	return !c.clock.Now().Add(gap).Before(exp)
+}
+
+func withoutContext[Value any](refresh func() (Value, error)) func(context.Context) (Value, error) {
+	return func(context.Context) (Value, error) {
+		return refresh()
+	}
+}
+
+// lockItem acquires the item lock waiting for the refresh of another goroutine
+// no longer than the refresh wait timeout, if any.
+func (c *Cache[Key, Value]) lockItem(ctx context.Context, item *Item[Key, Value]) error {
+	if c.refreshWaitTimeout <= 0 {
+		return item.mtx.LockContext(ctx)
+	}
+
+	if item.mtx.TryLock() {
+		return nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, c.refreshWaitTimeout)
+	defer cancel()
+
+	err := item.mtx.LockContext(waitCtx)
+	if err != nil && ctx.Err() == nil {
+		return ErrRefreshWaitTimeout
+	}
+	return err
+}
+
+// callRefresh calls the refresh function and retries it according to the retry policy.
+func (c *Cache[Key, Value]) callRefresh(
+	ctx context.Context,
+	refresh func(ctx context.Context) (Value, error),
+) (Value, error) {
+	for attempt := 1; ; attempt++ {
+		val, err := c.callRefreshOnce(ctx, refresh)
+		if err == nil || !c.retryPolicy.shouldRetry(attempt, err) {
+			return val, err
+		}
+
+		if sleepContext(ctx, c.retryPolicy.delay(attempt)) != nil {
+			return val, err
+		}
+	}
+}
+
+// callRefreshOnce calls the refresh function bounded by the refresh timeout, if any,
+// holding a slot of the concurrent refreshes limit.
+func (c *Cache[Key, Value]) callRefreshOnce(
+	ctx context.Context,
+	refresh func(ctx context.Context) (Value, error),
+) (val Value, err error) {
+	if c.refreshSem != nil {
+		select {
+		case c.refreshSem <- struct{}{}:
+		case <-ctx.Done():
+			var emptyVal Value
+			return emptyVal, ctx.Err()
+		}
+
+		// The slot is released when the refresh function returns, not when the caller
+		// stops waiting for it, so the abandoned refreshes count against the limit.
+		refresh = releaseAfter(refresh, func() { <-c.refreshSem })
+	}
+
+	if mtr, ok := c.mtr.(RefreshMetrics); ok {
+		// Like the slot, the refresh is observed when the function returns,
+		// so the abandoned refreshes stay in flight until they finish.
+		refresh = observeRefresh(refresh, mtr, c.requestClock)
+	}
+
+	if c.refreshTimeout <= 0 {
+		return callRefresh(ctx, refresh)
+	}
+
+	refreshCtx, cancel := context.WithTimeout(ctx, c.refreshTimeout)
+	defer cancel()
+
+	val, err = callRefresh(refreshCtx, refresh)
+	if err != nil && ctx.Err() == nil && errors.Is(refreshCtx.Err(), context.DeadlineExceeded) {
+		return val, ErrRefreshTimeout
+	}
+	return val, err
+}
+
+// releaseAfter wraps the refresh function to call release when it returns.
+func releaseAfter[Value any](
+	refresh func(ctx context.Context) (Value, error),
+	release func(),
+) func(ctx context.Context) (Value, error) {
+	return func(ctx context.Context) (Value, error) {
+		defer release()
+		return refresh(ctx)
+	}
+}
+
+// observeRefresh wraps the refresh function to observe its duration and error.
+func observeRefresh[Value any](
+	refresh func(ctx context.Context) (Value, error),
+	mtr RefreshMetrics,
+	clock Clock,
+) func(ctx context.Context) (Value, error) {
+	return func(ctx context.Context) (val Value, err error) {
+		mtr.StartRefresh()
+		startTime := clock.Now()
+		defer func() { mtr.ObserveRefresh(clock.Now().Sub(startTime), err) }()
+
+		return refresh(ctx)
+	}
+}
+
+type refreshResult[Value any] struct {
+	val       Value
+	err       error
+	recovered any
+}
+
+// callRefresh calls the refresh function and returns as soon as the context is done,
+// even if the refresh function ignores the context. A panic of the refresh function
+// is repeated in the caller as when it is called directly, the panic of an abandoned
+// refresh is dropped.
+func callRefresh[Value any](
+	ctx context.Context,
+	refresh func(ctx context.Context) (Value, error),
+) (Value, error) {
+	if ctx.Done() == nil {
+		return refresh(ctx)
+	}
+
+	result := make(chan refreshResult[Value], 1)
+	go func() {
+		var r refreshResult[Value]
+		defer func() {
+			if r.recovered = recover(); r.recovered != nil {
+				result <- r
+			}
+		}()
+
+		r.val, r.err = refresh(ctx)
+		result <- r
+	}()
+
+	select {
+	case r := <-result:
+		if r.recovered != nil {
+			panic(r.recovered)
+		}
+		return r.val, r.err
+	case <-ctx.Done():
+		var emptyVal Value
+		return emptyVal, ctx.Err()
+	}
+}
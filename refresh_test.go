@@ -0,0 +1,432 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetOrRefreshCtx(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	actual, err := cache.GetOrRefreshCtx(ctx, "key0", func(ctx context.Context) (string, error) {
+		_, ok := ctx.Deadline()
+		require.True(t, ok)
+		return "value0", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_GetOrRefreshCtx_RefreshCancelled(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	_, err := cache.GetOrRefreshCtx(ctx, "key0", func(context.Context) (string, error) {
+		// The refresh ignores the context.
+		<-release
+		return "value0", nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	requireKeyNotExists(t, cache, "key0")
+
+	// The item lock is released, so the next refresh is not blocked.
+	actual, err := cache.GetOrRefreshCtx(context.Background(), "key0", func(context.Context) (string, error) {
+		return "value1", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+}
+
+func TestCache_GetOrRefreshCtx_WaitCancelled(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := cache.GetOrRefreshCtx(ctx, "key0", func(context.Context) (string, error) {
+		panic("should never be called")
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	<-done
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_GetOrRefresh_ErrorTTL(t *testing.T) {
+	clock := newFakeClock()
+
+	originErr := fmt.Errorf("some error")
+	calls := atomic.Int32{}
+	cache := New[string, string](WithTTL(time.Minute), WithErrorTTL(time.Second), WithClock(clock))
+	refresh := func() (string, error) {
+		if calls.Add(1) == 1 {
+			return "", originErr
+		}
+		return "value0", nil
+	}
+
+	_, err := cache.GetOrRefresh("key0", refresh)
+	require.ErrorIs(t, err, originErr)
+
+	_, err = cache.GetOrRefresh("key0", refresh)
+	require.ErrorIs(t, err, originErr)
+	require.Equal(t, int32(1), calls.Load())
+
+	clock.Advance(time.Second)
+	actual, err := cache.GetOrRefresh("key0", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestCache_GetOrRefresh_ErrorTTL_ClearedBySet(t *testing.T) {
+	originErr := fmt.Errorf("some error")
+	cache := New[string, string](WithTTL(time.Minute), WithErrorTTL(time.Minute))
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		return "", originErr
+	})
+	require.ErrorIs(t, err, originErr)
+
+	cache.Set("key0", "value0")
+	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+		panic("should never be called")
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+}
+
+func TestCache_GetOrRefresh_RefreshTimeout(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute), WithRefreshTimeout(10*time.Millisecond))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		<-release
+		return "value0", nil
+	})
+	require.ErrorIs(t, err, ErrRefreshTimeout)
+
+	actual, err := cache.GetOrRefreshCtx(context.Background(), "key0", func(ctx context.Context) (string, error) {
+		_, ok := ctx.Deadline()
+		require.True(t, ok)
+		return "value1", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+}
+
+func TestCache_GetOrRefresh_RefreshPanic(t *testing.T) {
+	testCases := map[string][]Option{
+		"direct":          {WithTTL(time.Minute)},
+		"refresh timeout": {WithTTL(time.Minute), WithRefreshTimeout(time.Second)},
+	}
+
+	for name, opts := range testCases {
+		opts := opts
+		t.Run(name, func(t *testing.T) {
+			cache := New[string, string](opts...)
+
+			require.PanicsWithValue(t, "refresh failed", func() {
+				cache.GetOrRefresh("key0", func() (string, error) { //nolint:errcheck
+					panic("refresh failed")
+				})
+			})
+
+			actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+				return "value0", nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, "value0", actual)
+		})
+	}
+}
+
+func TestCache_GetOrRefreshCtx_RefreshTimeout_CallerDeadline(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute), WithRefreshTimeout(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := cache.GetOrRefreshCtx(ctx, "key0", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotErrorIs(t, err, ErrRefreshTimeout)
+}
+
+func TestCache_GetOrRefresh_RefreshWaitTimeout(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute), WithRefreshWaitTimeout(10*time.Millisecond))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+	}()
+	<-started
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		panic("should never be called")
+	})
+	require.ErrorIs(t, err, ErrRefreshWaitTimeout)
+
+	close(release)
+	<-done
+	requireKeyExists(t, cache, "key0", "value0")
+}
+
+func TestCache_GetOrRefresh_RefreshWaitTimeout_Stale(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Nanosecond), WithRefreshWaitTimeout(10*time.Millisecond))
+	cache.Set("key0", "stale")
+	time.Sleep(time.Nanosecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+	}()
+	<-started
+
+	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+		panic("should never be called")
+	})
+	require.NoError(t, err)
+	require.Equal(t, "stale", actual)
+
+	close(release)
+	<-done
+}
+
+func TestCache_GetOrRefresh_MaxConcurrentRefreshes(t *testing.T) {
+	const limit = 2
+
+	cache := New[int, int](WithTTL(time.Minute), WithMaxConcurrentRefreshes(limit))
+
+	running := atomic.Int32{}
+	maxRunning := atomic.Int32{}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			_, err := cache.GetOrRefresh(key, func() (int, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+
+				for {
+					observed := maxRunning.Load()
+					if current <= observed || maxRunning.CompareAndSwap(observed, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return key, nil
+			})
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, maxRunning.Load(), int32(limit))
+}
+
+func TestCache_GetOrRefresh_MaxConcurrentRefreshes_RefreshTimeout(t *testing.T) {
+	cache := New[int, int](
+		WithTTL(time.Minute),
+		WithMaxConcurrentRefreshes(1),
+		WithRefreshTimeout(10*time.Millisecond),
+	)
+
+	release := make(chan struct{})
+	_, err := cache.GetOrRefresh(0, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+	require.ErrorIs(t, err, ErrRefreshTimeout)
+
+	// The abandoned refresh still holds the slot.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	called := false
+	_, err = cache.GetOrRefreshCtx(ctx, 1, func(context.Context) (int, error) {
+		called = true
+		return 1, nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, called)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		val, err := cache.GetOrRefresh(1, func() (int, error) { return 1, nil })
+		return err == nil && val == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestCache_GetOrRefresh_ErrorTTL_PlaceholderLifetime(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithErrorTTL(time.Second), WithClock(clock))
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		return "", fmt.Errorf("some error")
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, cache.Len())
+
+	clock.Advance(time.Second)
+	cache.Purge()
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCache_GetOrRefresh_ErrorTTL_DeletedDuringRefresh(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Minute), WithErrorTTL(time.Second), WithClock(clock))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		<-started
+		cache.Del("key0")
+		close(release)
+	}()
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		close(started)
+		<-release
+		return "", fmt.Errorf("some error")
+	})
+	require.Error(t, err)
+	require.Equal(t, 0, cache.Len())
+
+	clock.Advance(time.Minute)
+	require.NotPanics(t, cache.Purge)
+}
+
+type refreshMetrics struct {
+	NopMetrics
+	mtx      sync.Mutex
+	inFlight int
+	results  []error
+}
+
+func (m *refreshMetrics) StartRefresh() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.inFlight++
+}
+
+func (m *refreshMetrics) ObserveRefresh(_ time.Duration, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.inFlight--
+	m.results = append(m.results, err)
+}
+
+func (m *refreshMetrics) state() (int, []error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.inFlight, append([]error(nil), m.results...)
+}
+
+func TestCache_GetOrRefresh_RefreshMetrics(t *testing.T) {
+	mtr := &refreshMetrics{}
+	cache := New[string, string](WithTTL(time.Minute), WithMetrics(mtr))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.GetOrRefresh("key0", func() (string, error) {
+			close(started)
+			<-release
+			return "value0", nil
+		})
+	}()
+
+	<-started
+	inFlight, results := mtr.state()
+	require.Equal(t, 1, inFlight)
+	require.Empty(t, results)
+
+	close(release)
+	<-done
+
+	originErr := fmt.Errorf("some error")
+	_, err := cache.GetOrRefresh("key1", func() (string, error) {
+		return "", originErr
+	})
+	require.ErrorIs(t, err, originErr)
+
+	inFlight, results = mtr.state()
+	require.Equal(t, 0, inFlight)
+	require.Equal(t, []error{nil, originErr}, results)
+}
+
+func TestCache_GetOrRefresh_RefreshMetrics_RefreshTimeout(t *testing.T) {
+	mtr := &refreshMetrics{}
+	cache := New[string, string](WithTTL(time.Minute), WithMetrics(mtr), WithRefreshTimeout(10*time.Millisecond))
+
+	release := make(chan struct{})
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		<-release
+		return "value0", nil
+	})
+	require.ErrorIs(t, err, ErrRefreshTimeout)
+
+	// The abandoned refresh is still in flight.
+	inFlight, results := mtr.state()
+	require.Equal(t, 1, inFlight)
+	require.Empty(t, results)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		inFlight, results = mtr.state()
+		return inFlight == 0 && len(results) == 1
+	}, time.Second, time.Millisecond)
+	require.Equal(t, []error{nil}, results)
+}
@@ -0,0 +1,57 @@
+package locache
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy describes how GetOrRefresh retries failed refresh calls.
+type RetryPolicy struct {
+	// Attempts is the total number of refresh calls, including the first one.
+	Attempts int
+	// Backoff is the delay before the first retry, it doubles with every next retry.
+	Backoff time.Duration
+	// MaxBackoff limits the delay between retries, zero means no limit.
+	MaxBackoff time.Duration
+	// Jitter is the share of the delay (from 0 to 1) randomized to spread retries.
+	Jitter float64
+	// Retryable reports whether the error is worth a retry, nil means all errors are.
+	Retryable func(err error) bool
+}
+
+func (p RetryPolicy) shouldRetry(attempt int, err error) bool {
+	return attempt < p.Attempts && (p.Retryable == nil || p.Retryable(err))
+}
+
+// delay returns the delay after the given failed attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delay -= time.Duration(float64(delay) * p.Jitter * random())
+	}
+	return delay
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
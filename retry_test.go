@@ -0,0 +1,92 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{Attempts: 10, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	require.Equal(t, time.Millisecond, policy.delay(1))
+	require.Equal(t, 2*time.Millisecond, policy.delay(2))
+	require.Equal(t, 4*time.Millisecond, policy.delay(3))
+	require.Equal(t, 5*time.Millisecond, policy.delay(4))
+	require.Equal(t, 5*time.Millisecond, policy.delay(100))
+}
+
+func TestRetryPolicy_Jitter(t *testing.T) {
+	defer func(origin func() float64) { random = origin }(random)
+	random = func() float64 { return 0.5 }
+
+	policy := RetryPolicy{Attempts: 2, Backoff: time.Second, Jitter: 0.2}
+	require.Equal(t, 900*time.Millisecond, policy.delay(1))
+}
+
+func TestCache_GetOrRefresh_Retry(t *testing.T) {
+	originErr := fmt.Errorf("some error")
+	calls := atomic.Int32{}
+	cache := New[string, string](WithTTL(time.Minute), WithRetryPolicy(RetryPolicy{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+	}))
+
+	actual, err := cache.GetOrRefresh("key0", func() (string, error) {
+		if calls.Add(1) < 3 {
+			return "", originErr
+		}
+		return "value0", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value0", actual)
+	require.Equal(t, int32(3), calls.Load())
+
+	calls.Store(0)
+	_, err = cache.GetOrRefresh("key1", func() (string, error) {
+		calls.Add(1)
+		return "", originErr
+	})
+	require.ErrorIs(t, err, originErr)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestCache_GetOrRefresh_Retry_NotRetryable(t *testing.T) {
+	permanentErr := errors.New("permanent")
+	calls := atomic.Int32{}
+	cache := New[string, string](WithTTL(time.Minute), WithRetryPolicy(RetryPolicy{
+		Attempts: 3,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, permanentErr)
+		},
+	}))
+
+	_, err := cache.GetOrRefresh("key0", func() (string, error) {
+		calls.Add(1)
+		return "", permanentErr
+	})
+	require.ErrorIs(t, err, permanentErr)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestCache_GetOrRefreshCtx_Retry_ContextDone(t *testing.T) {
+	calls := atomic.Int32{}
+	cache := New[string, string](WithTTL(time.Minute), WithRetryPolicy(RetryPolicy{
+		Attempts: 100,
+		Backoff:  time.Hour,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := cache.GetOrRefreshCtx(ctx, "key0", func(context.Context) (string, error) {
+		calls.Add(1)
+		return "", errors.New("some error")
+	})
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
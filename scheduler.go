@@ -0,0 +1,100 @@
+package locache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Purger is a cache which can be purged by PurgeScheduler.
+type Purger interface {
+	PurgeContext(ctx context.Context) error
+}
+
+type purgerEntry struct {
+	purger Purger
+}
+
+// PurgeScheduler purges many caches with one goroutine and one timer.
+// Every interval it sweeps the registered caches one by one, when the sweep
+// is stopped, the next one starts from the cache which was not purged.
+type PurgeScheduler struct {
+	mtx     sync.Mutex
+	entries []*purgerEntry
+	next    int
+
+	stop func()
+}
+
+// NewPurgeScheduler starts the scheduler. It uses the context and the clock set by
+// WithContext and WithClock, other options are ignored.
+func NewPurgeScheduler(interval time.Duration, opts ...Option) *PurgeScheduler {
+	o := newOptions(opts)
+	ctx, cancel := context.WithCancel(o.ctx)
+	done := make(chan struct{})
+
+	s := &PurgeScheduler{}
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.clock.After(interval):
+				s.sweep(ctx)
+			}
+		}
+	}()
+
+	s.stop = func() {
+		cancel()
+		<-done
+	}
+	return s
+}
+
+// Register adds the cache to the sweeps until the returned function is called.
+func (s *PurgeScheduler) Register(purger Purger) (unregister func()) {
+	entry := &purgerEntry{purger: purger}
+
+	s.mtx.Lock()
+	s.entries = append(s.entries, entry)
+	s.mtx.Unlock()
+
+	return func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		for i := range s.entries {
+			if s.entries[i] == entry {
+				s.entries = append(s.entries[:i], s.entries[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Stop stops the scheduler and waits for the running sweep to finish.
+func (s *PurgeScheduler) Stop() {
+	s.stop()
+}
+
+func (s *PurgeScheduler) sweep(ctx context.Context) {
+	s.mtx.Lock()
+	if len(s.entries) == 0 {
+		s.mtx.Unlock()
+		return
+	}
+	start := s.next % len(s.entries)
+	entries := append(s.entries[start:len(s.entries):len(s.entries)], s.entries[:start]...)
+	s.mtx.Unlock()
+
+	for i, entry := range entries {
+		if entry.purger.PurgeContext(ctx) != nil {
+			s.mtx.Lock()
+			s.next = start + i
+			s.mtx.Unlock()
+			return
+		}
+	}
+}
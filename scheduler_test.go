@@ -0,0 +1,65 @@
+package locache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type purgerFunc func(ctx context.Context) error
+
+func (f purgerFunc) PurgeContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestPurgeScheduler(t *testing.T) {
+	clock := newFakeClock()
+	scheduler := NewPurgeScheduler(time.Second, WithClock(clock))
+	defer scheduler.Stop()
+
+	cache1 := New[string, string](WithTTL(time.Minute), WithClock(clock), WithPurgeScheduler(scheduler))
+	cache2 := New[string, string](WithTTL(time.Minute), WithClock(clock), WithPurgeScheduler(scheduler))
+	cache1.Set("key0", "value0")
+	cache2.Set("key0", "value0")
+
+	require.NoError(t, cache2.Close())
+
+	require.Eventually(t, func() bool { return clock.waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return cache1.Len() == 0 }, time.Second, time.Millisecond)
+
+	scheduler.mtx.Lock()
+	defer scheduler.mtx.Unlock()
+	require.Len(t, scheduler.entries, 1)
+}
+
+func TestPurgeScheduler_Sweep_RoundRobin(t *testing.T) {
+	scheduler := &PurgeScheduler{}
+
+	var calls []int
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		i := i
+		scheduler.Register(purgerFunc(func(ctx context.Context) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			calls = append(calls, i)
+			if i == 1 {
+				cancel()
+			}
+			return nil
+		}))
+	}
+
+	scheduler.sweep(ctx)
+	require.Equal(t, []int{0, 1}, calls)
+
+	calls = nil
+	scheduler.sweep(context.Background())
+	require.Equal(t, []int{2, 0, 1}, calls)
+}
@@ -0,0 +1,338 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Sharded splits keys between several independent caches,
+// so operations on different shards don't contend for the same lock.
+// Capacity options (WithMaxEntries, WithMaxCost) are enforced per shard:
+// the total capacity is the number of shards multiplied by the limit.
+type Sharded[Key comparable, Value any] struct {
+	seed   maphash.Seed
+	shards []*Cache[Key, Value]
+}
+
+// NewSharded creates the sharded cache, a shardsCount below one selects
+// the default count based on GOMAXPROCS. The options are applied to every shard,
+// so it panics on the options which can't be shared between shards: WithEvictionPolicy,
+// WithSnapshotOnClose (every shard would replace the file of the others), WithSpill
+// and WithInvalidation (every shard would write the store or apply the messages of the others).
+func NewSharded[Key comparable, Value any](shardsCount int, opts ...Option) *Sharded[Key, Value] {
+	if shardsCount < 1 {
+		shardsCount = defaultShardsCount()
+	}
+
+	o := newOptions(opts)
+	switch {
+	case o.policy != nil:
+		panic("locache: eviction policy instance can't be shared between shards, use WithEvictionMode")
+	case o.closeSnapshotPath != "":
+		panic("locache: snapshot on close can't be shared between shards")
+	case o.spill != nil:
+		panic("locache: spill store can't be shared between shards")
+	case o.bus != nil:
+		panic("locache: invalidation bus can't be shared between shards")
+	}
+
+	counts := make([]atomic.Int64, shardsCount)
+	costs := make([]atomic.Int64, shardsCount)
+	shards := make([]*Cache[Key, Value], shardsCount)
+	for i := range shards {
+		mtr := &shardMetrics{Metrics: o.mtr, counts: counts, costs: costs, idx: i}
+		shards[i] = New[Key, Value](append(opts[:len(opts):len(opts)], WithMetrics(mtr))...)
+	}
+
+	return &Sharded[Key, Value]{
+		seed:   maphash.MakeSeed(),
+		shards: shards,
+	}
+}
+
+// defaultShardsCount returns several shards per processor to keep the chance
+// of two goroutines contending for the same shard low.
+func defaultShardsCount() int {
+	return int(nextPowerOfTwo(uint64(runtime.GOMAXPROCS(0) * 4)))
+}
+
+func (s *Sharded[Key, Value]) Get(key Key) (Value, bool) {
+	return s.shard(key).Get(key)
+}
+
+func (s *Sharded[Key, Value]) GetWithExpiry(key Key) (Value, time.Time, bool) {
+	return s.shard(key).GetWithExpiry(key)
+}
+
+func (s *Sharded[Key, Value]) GetStale(key Key) (Value, bool, bool) {
+	return s.shard(key).GetStale(key)
+}
+
+func (s *Sharded[Key, Value]) Peek(key Key) (Value, bool) {
+	return s.shard(key).Peek(key)
+}
+
+func (s *Sharded[Key, Value]) TTL(key Key) (time.Duration, bool) {
+	return s.shard(key).TTL(key)
+}
+
+func (s *Sharded[Key, Value]) Set(key Key, value Value) {
+	s.shard(key).Set(key, value)
+}
+
+func (s *Sharded[Key, Value]) SetWithTTL(key Key, value Value, ttl time.Duration) {
+	s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (s *Sharded[Key, Value]) SetForever(key Key, value Value) {
+	s.shard(key).SetForever(key, value)
+}
+
+func (s *Sharded[Key, Value]) GetOrSet(key Key, value Value) (Value, bool) {
+	return s.shard(key).GetOrSet(key, value)
+}
+
+func (s *Sharded[Key, Value]) Add(key Key, value Value) bool {
+	return s.shard(key).Add(key, value)
+}
+
+func (s *Sharded[Key, Value]) Replace(key Key, value Value) bool {
+	return s.shard(key).Replace(key, value)
+}
+
+func (s *Sharded[Key, Value]) Pop(key Key) (Value, bool) {
+	return s.shard(key).Pop(key)
+}
+
+func (s *Sharded[Key, Value]) Update(key Key, fn func(old Value, exists bool) (Value, bool)) (Value, bool) {
+	return s.shard(key).Update(key, fn)
+}
+
+func (s *Sharded[Key, Value]) GetWithVersion(key Key) (Value, uint64, bool) {
+	return s.shard(key).GetWithVersion(key)
+}
+
+func (s *Sharded[Key, Value]) CompareAndSwap(key Key, expectedVersion uint64, value Value) bool {
+	return s.shard(key).CompareAndSwap(key, expectedVersion, value)
+}
+
+func (s *Sharded[Key, Value]) Del(key Key) {
+	s.shard(key).Del(key)
+}
+
+func (s *Sharded[Key, Value]) Touch(key Key) bool {
+	return s.shard(key).Touch(key)
+}
+
+func (s *Sharded[Key, Value]) Expire(key Key, d time.Duration) bool {
+	return s.shard(key).Expire(key, d)
+}
+
+func (s *Sharded[Key, Value]) OnExpire(key Key, callback func(Key, Value)) bool {
+	return s.shard(key).OnExpire(key, callback)
+}
+
+func (s *Sharded[Key, Value]) GetOrRefresh(key Key, refresh func() (Value, error)) (Value, error) {
+	return s.shard(key).GetOrRefresh(key, refresh)
+}
+
+func (s *Sharded[Key, Value]) GetOrRefreshCtx(
+	ctx context.Context,
+	key Key,
+	refresh func(ctx context.Context) (Value, error),
+) (Value, error) {
+	return s.shard(key).GetOrRefreshCtx(ctx, key, refresh)
+}
+
+func (s *Sharded[Key, Value]) GetOrRefreshWithTTL(
+	key Key,
+	ttl time.Duration,
+	refresh func() (Value, error),
+) (Value, error) {
+	return s.shard(key).GetOrRefreshWithTTL(key, ttl, refresh)
+}
+
+func (s *Sharded[Key, Value]) SchedulePurge(ctx context.Context, purgeInterval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// The first shard owns the schedule, so closing the shards stops it.
+	done := s.shards[0].schedule(ctx, purgeInterval, s.Purge)
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Purge purges shards one by one, so only one shard is locked at a time.
+func (s *Sharded[Key, Value]) Purge() {
+	for _, shard := range s.shards {
+		shard.Purge()
+	}
+}
+
+// PurgeContext purges shards one by one until the context is done.
+func (s *Sharded[Key, Value]) PurgeContext(ctx context.Context) error {
+	for _, shard := range s.shards {
+		if err := shard.PurgeContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sharded[Key, Value]) Len() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.Len()
+	}
+	return total
+}
+
+func (s *Sharded[Key, Value]) Keys() []Key {
+	var keys []Key
+	for _, shard := range s.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// Range iterates shards one by one, so it is not a consistent snapshot across shards.
+func (s *Sharded[Key, Value]) Range(fn func(Key, Value) bool) {
+	for _, shard := range s.shards {
+		stopped := false
+		shard.Range(func(key Key, value Value) bool {
+			stopped = !fn(key, value)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// ScheduleMemoryWatch works like Cache.ScheduleMemoryWatch, shrinking all shards.
+func (s *Sharded[Key, Value]) ScheduleMemoryWatch(
+	ctx context.Context,
+	softLimit uint64,
+	interval time.Duration,
+) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// The first shard owns the schedule, so closing the shards stops it.
+	done := s.shards[0].schedule(ctx, interval, func() {
+		if readHeapAlloc() > softLimit {
+			s.Shrink(memoryPressureShrinkRatio)
+		}
+	})
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Shrink shrinks shards one by one and returns the total number of evicted entries.
+func (s *Sharded[Key, Value]) Shrink(ratio float64) int {
+	evicted := 0
+	for _, shard := range s.shards {
+		evicted += shard.Shrink(ratio)
+	}
+	return evicted
+}
+
+// Close closes shards one by one.
+func (s *Sharded[Key, Value]) Close() error {
+	errs := make([]error, 0, len(s.shards))
+	for _, shard := range s.shards {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Clear clears shards one by one, so it is not atomic across shards.
+func (s *Sharded[Key, Value]) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+func (s *Sharded[Key, Value]) shard(key Key) *Cache[Key, Value] {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+	return s.shards[hashKey(s.seed, key)%uint64(len(s.shards))]
+}
+
+// shardMetrics reports the total items count and cost of all shards instead of a single shard.
+type shardMetrics struct {
+	Metrics
+	counts []atomic.Int64
+	costs  []atomic.Int64
+	idx    int
+}
+
+func (m *shardMetrics) ObservePurge(scanned, removed int, duration time.Duration) {
+	if mtr, ok := m.Metrics.(PurgeMetrics); ok {
+		mtr.ObservePurge(scanned, removed, duration)
+	}
+}
+
+func (m *shardMetrics) IncExpired(count int) {
+	if mtr, ok := m.Metrics.(RemovalMetrics); ok {
+		mtr.IncExpired(count)
+	}
+}
+
+func (m *shardMetrics) IncRemoved(method string) {
+	if mtr, ok := m.Metrics.(RemovalMetrics); ok {
+		mtr.IncRemoved(method)
+	}
+}
+
+func (m *shardMetrics) StartRefresh() {
+	if mtr, ok := m.Metrics.(RefreshMetrics); ok {
+		mtr.StartRefresh()
+	}
+}
+
+func (m *shardMetrics) ObserveRefresh(duration time.Duration, err error) {
+	if mtr, ok := m.Metrics.(RefreshMetrics); ok {
+		mtr.ObserveRefresh(duration, err)
+	}
+}
+
+func (m *shardMetrics) ObserveSnapshot(size int64, duration time.Duration, err error) {
+	if mtr, ok := m.Metrics.(SnapshotMetrics); ok {
+		mtr.ObserveSnapshot(size, duration, err)
+	}
+}
+
+func (m *shardMetrics) SetItemsCount(count int) {
+	m.counts[m.idx].Store(int64(count))
+
+	total := int64(0)
+	for i := range m.counts {
+		total += m.counts[i].Load()
+	}
+	m.Metrics.SetItemsCount(int(total))
+}
+
+func (m *shardMetrics) SetCost(cost int64) {
+	mtr, ok := m.Metrics.(CostMetrics)
+	if !ok {
+		return
+	}
+
+	m.costs[m.idx].Store(cost)
+
+	total := int64(0)
+	for i := range m.costs {
+		total += m.costs[i].Load()
+	}
+	mtr.SetCost(total)
+}
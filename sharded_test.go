@@ -0,0 +1,137 @@
+package locache
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type itemsCountMetrics struct {
+	NopMetrics
+	mtx   sync.Mutex
+	count int
+}
+
+func (m *itemsCountMetrics) SetItemsCount(count int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.count = count
+}
+
+func TestSharded_GetSetDel(t *testing.T) {
+	cache := NewSharded[string, string](4, WithTTL(time.Second))
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	for i := 0; i < 100; i++ {
+		value, ok := cache.Get(fmt.Sprintf("key%d", i))
+		require.True(t, ok)
+		require.Equal(t, fmt.Sprintf("value%d", i), value)
+	}
+
+	cache.Del("key0")
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+
+	value, err := cache.GetOrRefresh("key0", func() (string, error) {
+		return "refreshed", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "refreshed", value)
+}
+
+func TestSharded_MaxEntriesPerShard(t *testing.T) {
+	cache := NewSharded[int, int](4, WithTTL(time.Second), WithMaxEntries(10))
+	for i := 0; i < 1000; i++ {
+		cache.Set(i, i)
+	}
+
+	for _, shard := range cache.shards {
+		require.Equal(t, 10, shard.items.Len())
+	}
+}
+
+func TestSharded_Purge_ReportsTotalItemsCount(t *testing.T) {
+	mtr := &itemsCountMetrics{}
+	cache := NewSharded[int, int](4, WithTTL(time.Second), WithMetrics(mtr))
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+
+	cache.Purge()
+	require.Equal(t, 100, mtr.count)
+}
+
+func TestNewSharded_EvictionPolicyInstance(t *testing.T) {
+	require.Panics(t, func() {
+		NewSharded[string, string](2, WithTTL(time.Second), WithEvictionPolicy[string](NewSLRUPolicy[string](2)))
+	})
+}
+
+func TestNewSharded_SharedOptions(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"snapshot on close": WithSnapshotOnClose(filepath.Join(t.TempDir(), "cache.snapshot"), time.Second),
+		"spill":             WithSpill(newMemoryStore()),
+		"invalidation":      WithInvalidation(newMemoryBus()),
+	} {
+		require.Panics(t, func() { NewSharded[string, string](2, opt) }, name)
+	}
+}
+
+func TestSharded_LenKeysClear(t *testing.T) {
+	cache := NewSharded[int, int](4, WithTTL(time.Second))
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+
+	require.Equal(t, 100, cache.Len())
+	require.Len(t, cache.Keys(), 100)
+
+	cache.Clear()
+	require.Equal(t, 0, cache.Len())
+	require.Empty(t, cache.Keys())
+}
+
+func TestSharded_Range(t *testing.T) {
+	cache := NewSharded[int, int](4, WithTTL(time.Second))
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i*2)
+	}
+
+	sum := 0
+	cache.Range(func(key, value int) bool {
+		require.Equal(t, key*2, value)
+		sum += key
+		return true
+	})
+	require.Equal(t, 4950, sum)
+
+	calls := 0
+	cache.Range(func(int, int) bool {
+		calls++
+		return calls < 10
+	})
+	require.Equal(t, 10, calls)
+}
+
+func TestSharded_DefaultShardsCount(t *testing.T) {
+	cache := NewSharded[int, int](0)
+	require.GreaterOrEqual(t, len(cache.shards), runtime.GOMAXPROCS(0))
+	require.Zero(t, len(cache.shards)&(len(cache.shards)-1))
+}
+
+func TestSharded_Shrink(t *testing.T) {
+	cache := NewSharded[int, int](4, WithTTL(time.Minute))
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+
+	require.Equal(t, 100, cache.Shrink(1))
+	require.Equal(t, 0, cache.Len())
+}
@@ -0,0 +1,342 @@
+package locache
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// slabHeaderSize is the size of the entry header in the slab:
+// the expiration in unix nanoseconds, the key length and the value length.
+const slabHeaderSize = 16
+
+// maxSlabBytes limits the shard size to keep offsets of the entries in uint32.
+const maxSlabBytes = math.MaxInt32
+
+// Slab is a cache of byte values for millions of entries. Entries are stored
+// in large byte slabs split into shards and indexed by maps of integers,
+// so the garbage collector doesn't scan the cached data.
+//
+// Overwritten and deleted entries leave garbage in the slab which is compacted
+// when it takes half of the slab. WithMaxCost limits the total size of the slabs
+// in bytes, the oldest entries are evicted when a shard is full. A shard takes
+// no more than 2GB regardless of the limit.
+// It supports WithTTL, WithMetrics, WithClock and WithMaxCost, other options are ignored.
+type Slab struct {
+	seed   maphash.Seed
+	shards []*slabShard
+
+	ttl   time.Duration
+	mtr   Metrics
+	clock Clock
+	timed bool
+
+	requestClock Clock
+
+	counts []atomic.Int64
+}
+
+type slabShard struct {
+	mtx      sync.RWMutex
+	index    map[uint64]uint32
+	slab     []byte
+	garbage  int
+	maxBytes int
+}
+
+// NewSlab creates the slab cache, a shardsCount below one selects
+// the default count based on GOMAXPROCS.
+func NewSlab(shardsCount int, opts ...Option) *Slab {
+	if shardsCount < 1 {
+		shardsCount = defaultShardsCount()
+	}
+
+	o := newOptions(opts)
+
+	maxBytes := maxSlabBytes
+	if o.maxCost > 0 && o.maxCost/int64(shardsCount) < maxSlabBytes {
+		maxBytes = int(o.maxCost / int64(shardsCount))
+	}
+
+	shards := make([]*slabShard, shardsCount)
+	for i := range shards {
+		shards[i] = &slabShard{
+			index:    make(map[uint64]uint32),
+			maxBytes: maxBytes,
+		}
+	}
+
+	return &Slab{
+		seed:   maphash.MakeSeed(),
+		shards: shards,
+
+		ttl:   o.ttl,
+		mtr:   o.mtr,
+		clock: o.clock,
+		timed: !isNopMetrics(o.mtr),
+
+		requestClock: requestClock(o.clock),
+
+		counts: make([]atomic.Int64, shardsCount),
+	}
+}
+
+// Get returns a copy of the value, the slab keeps no references to it.
+func (s *Slab) Get(key string) ([]byte, bool) {
+	if s.timed {
+		defer s.mtr.ObserveRequest(MethodGet, s.requestClock.Now())
+	}
+
+	hash := maphash.String(s.seed, key)
+	shard := s.shard(hash)
+
+	shard.mtx.RLock()
+	val, ok := shard.get(hash, key, s.clock.Now())
+	shard.mtx.RUnlock()
+
+	if !ok {
+		s.mtr.IncMisses(MethodGet)
+		return nil, false
+	}
+
+	s.mtr.IncHits(MethodGet)
+	return val, true
+}
+
+func (s *Slab) Set(key string, value []byte) {
+	s.SetWithTTL(key, value, s.ttl)
+}
+
+// SetWithTTL stores a copy of the value, a ttl <= 0 keeps the entry until
+// it is deleted or evicted. The value larger than the shard limit is not stored.
+func (s *Slab) SetWithTTL(key string, value []byte, ttl time.Duration) {
+	if s.timed {
+		defer s.mtr.ObserveRequest(MethodSet, s.requestClock.Now())
+	}
+
+	var exp int64
+	if ttl > 0 {
+		exp = s.clock.Now().Add(ttl).UnixNano()
+	}
+
+	hash := maphash.String(s.seed, key)
+	idx := s.shardIdx(hash)
+	shard := s.shards[idx]
+
+	shard.mtx.Lock()
+	stored, evicted := shard.set(s.seed, hash, key, value, exp, s.clock.Now())
+	count := len(shard.index)
+	shard.mtx.Unlock()
+
+	if !stored {
+		s.mtr.IncErrors(MethodSet)
+	}
+	for i := 0; i < evicted; i++ {
+		s.mtr.IncEvictions(MethodSet)
+	}
+	s.setItemsCount(idx, count)
+}
+
+func (s *Slab) Del(key string) {
+	if s.timed {
+		defer s.mtr.ObserveRequest(MethodDel, s.requestClock.Now())
+	}
+
+	hash := maphash.String(s.seed, key)
+	idx := s.shardIdx(hash)
+	shard := s.shards[idx]
+
+	shard.mtx.Lock()
+	shard.del(hash, key)
+	count := len(shard.index)
+	shard.mtx.Unlock()
+
+	s.setItemsCount(idx, count)
+}
+
+// Len returns the number of entries including expired ones which are not purged yet.
+func (s *Slab) Len() int {
+	total := 0
+	for _, shard := range s.shards {
+		shard.mtx.RLock()
+		total += len(shard.index)
+		shard.mtx.RUnlock()
+	}
+	return total
+}
+
+// Purge compacts the slabs dropping expired entries.
+func (s *Slab) Purge() {
+	if s.timed {
+		defer s.mtr.ObserveRequest(MethodPurge, s.requestClock.Now())
+	}
+
+	for idx, shard := range s.shards {
+		shard.mtx.Lock()
+		shard.compact(s.seed, s.clock.Now(), 0)
+		count := len(shard.index)
+		shard.mtx.Unlock()
+
+		s.setItemsCount(idx, count)
+	}
+}
+
+func (s *Slab) shardIdx(hash uint64) int {
+	return int(hash % uint64(len(s.shards)))
+}
+
+func (s *Slab) shard(hash uint64) *slabShard {
+	return s.shards[s.shardIdx(hash)]
+}
+
+func (s *Slab) setItemsCount(idx, count int) {
+	s.counts[idx].Store(int64(count))
+
+	total := int64(0)
+	for i := range s.counts {
+		total += s.counts[i].Load()
+	}
+	s.mtr.SetItemsCount(int(total))
+}
+
+// entry returns the key, the value, the expiration and the size of the entry at the offset.
+func (sh *slabShard) entry(offset int) (key, val []byte, exp int64, size int) {
+	header := sh.slab[offset : offset+slabHeaderSize]
+	exp = int64(binary.LittleEndian.Uint64(header[0:8]))
+	keyLen := int(binary.LittleEndian.Uint32(header[8:12]))
+	valLen := int(binary.LittleEndian.Uint32(header[12:16]))
+
+	keyStart := offset + slabHeaderSize
+	valStart := keyStart + keyLen
+	size = slabHeaderSize + keyLen + valLen
+
+	return sh.slab[keyStart:valStart], sh.slab[valStart : valStart+valLen], exp, size
+}
+
+func slabEntrySize(key string, value []byte) int {
+	return slabHeaderSize + len(key) + len(value)
+}
+
+func slabExpired(exp int64, at time.Time) bool {
+	return exp != 0 && at.UnixNano() >= exp
+}
+
+func (sh *slabShard) get(hash uint64, key string, at time.Time) ([]byte, bool) {
+	offset, found := sh.index[hash]
+	if !found {
+		return nil, false
+	}
+
+	entryKey, val, exp, _ := sh.entry(int(offset))
+	if string(entryKey) != key || slabExpired(exp, at) {
+		return nil, false
+	}
+
+	return append(make([]byte, 0, len(val)), val...), true
+}
+
+// set appends the entry to the slab, it returns false when the entry
+// doesn't fit into the shard, and the number of evicted entries.
+func (sh *slabShard) set(seed maphash.Seed, hash uint64, key string, value []byte, exp int64, at time.Time) (bool, int) {
+	size := slabEntrySize(key, value)
+	if size > sh.maxBytes {
+		return false, 0
+	}
+
+	// A colliding key is overwritten as well: the index keeps one entry per hash.
+	if offset, found := sh.index[hash]; found {
+		_, _, _, oldSize := sh.entry(int(offset))
+		sh.garbage += oldSize
+		delete(sh.index, hash)
+	}
+
+	evicted := 0
+	if len(sh.slab)+size > sh.maxBytes {
+		evicted = sh.compact(seed, at, len(sh.slab)+size-sh.maxBytes)
+	} else if sh.garbage > len(sh.slab)/2 {
+		sh.compact(seed, at, 0)
+	}
+
+	offset := len(sh.slab)
+	var header [slabHeaderSize]byte
+	binary.LittleEndian.PutUint64(header[0:8], uint64(exp))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[12:16], uint32(len(value)))
+
+	sh.slab = append(sh.slab, header[:]...)
+	sh.slab = append(sh.slab, key...)
+	sh.slab = append(sh.slab, value...)
+	sh.index[hash] = uint32(offset)
+
+	return true, evicted
+}
+
+func (sh *slabShard) del(hash uint64, key string) {
+	offset, found := sh.index[hash]
+	if !found {
+		return
+	}
+
+	entryKey, _, _, size := sh.entry(int(offset))
+	if string(entryKey) != key {
+		return
+	}
+
+	sh.garbage += size
+	delete(sh.index, hash)
+}
+
+// compact copies live entries into a new slab dropping garbage and expired entries.
+// The oldest live entries are evicted until at least evictBytes are freed,
+// it returns the number of evicted entries.
+func (sh *slabShard) compact(seed maphash.Seed, at time.Time, evictBytes int) int {
+	if sh.garbage == 0 && evictBytes == 0 && !sh.hasExpired(at) {
+		return 0
+	}
+
+	freed := 0
+	evicted := 0
+	slab := make([]byte, 0, len(sh.slab)-sh.garbage)
+
+	for offset := 0; offset < len(sh.slab); {
+		key, _, exp, size := sh.entry(offset)
+		hash := maphash.Bytes(seed, key)
+
+		indexed, found := sh.index[hash]
+		live := found && indexed == uint32(offset)
+
+		switch {
+		case !live:
+			freed += size
+		case slabExpired(exp, at) || freed < evictBytes:
+			if !slabExpired(exp, at) {
+				evicted++
+			}
+			freed += size
+			delete(sh.index, hash)
+		default:
+			sh.index[hash] = uint32(len(slab))
+			slab = append(slab, sh.slab[offset:offset+size]...)
+		}
+
+		offset += size
+	}
+
+	sh.slab = slab
+	sh.garbage = 0
+	return evicted
+}
+
+func (sh *slabShard) hasExpired(at time.Time) bool {
+	for offset := 0; offset < len(sh.slab); {
+		_, _, exp, size := sh.entry(offset)
+		if slabExpired(exp, at) {
+			return true
+		}
+		offset += size
+	}
+	return false
+}
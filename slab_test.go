@@ -0,0 +1,103 @@
+package locache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlab_GetSetDel(t *testing.T) {
+	slab := NewSlab(4, WithTTL(time.Minute))
+
+	_, ok := slab.Get("key0")
+	require.False(t, ok)
+
+	value := []byte("value0")
+	slab.Set("key0", value)
+	value[0] = 'V'
+
+	actual, ok := slab.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, []byte("value0"), actual)
+
+	actual[0] = 'V'
+	actual, ok = slab.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, []byte("value0"), actual)
+
+	slab.Set("key0", []byte("updated0"))
+	actual, ok = slab.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, []byte("updated0"), actual)
+	require.Equal(t, 1, slab.Len())
+
+	slab.Del("key0")
+	_, ok = slab.Get("key0")
+	require.False(t, ok)
+	require.Equal(t, 0, slab.Len())
+}
+
+func TestSlab_Expiration(t *testing.T) {
+	clock := newFakeClock()
+	slab := NewSlab(1, WithTTL(time.Minute), WithClock(clock))
+
+	slab.Set("key0", []byte("value0"))
+	slab.SetWithTTL("key1", []byte("value1"), 0)
+
+	clock.Advance(time.Minute)
+	_, ok := slab.Get("key0")
+	require.False(t, ok)
+	require.Equal(t, 2, slab.Len())
+
+	slab.Purge()
+	require.Equal(t, 1, slab.Len())
+
+	actual, ok := slab.Get("key1")
+	require.True(t, ok)
+	require.Equal(t, []byte("value1"), actual)
+}
+
+func TestSlab_Compaction(t *testing.T) {
+	slab := NewSlab(1)
+	shard := slab.shards[0]
+
+	for i := 0; i < 100; i++ {
+		slab.Set("key0", []byte(fmt.Sprintf("value%d", i)))
+	}
+
+	require.LessOrEqual(t, shard.garbage, len(shard.slab)/2)
+	require.Less(t, len(shard.slab), 100*slabEntrySize("key0", []byte("value00")))
+
+	actual, ok := slab.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, []byte("value99"), actual)
+}
+
+func TestSlab_MaxCost(t *testing.T) {
+	value := []byte("value0")
+	size := slabEntrySize("key0", value)
+	slab := NewSlab(1, WithMaxCost(int64(3*size)))
+
+	for i := 0; i < 5; i++ {
+		slab.Set(fmt.Sprintf("key%d", i), value)
+	}
+
+	require.Equal(t, 3, slab.Len())
+	require.LessOrEqual(t, len(slab.shards[0].slab), 3*size)
+
+	for i := 0; i < 2; i++ {
+		_, ok := slab.Get(fmt.Sprintf("key%d", i))
+		require.False(t, ok)
+	}
+	for i := 2; i < 5; i++ {
+		_, ok := slab.Get(fmt.Sprintf("key%d", i))
+		require.True(t, ok)
+	}
+
+	slab.Set("large", make([]byte, 3*size))
+	_, ok := slab.Get("large")
+	require.False(t, ok)
+	require.Equal(t, 3, slab.Len())
+}
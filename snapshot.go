@@ -0,0 +1,322 @@
+package locache
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The snapshot starts with the header: the magic, the version of the format and the
+// fingerprints of the key and the value types. The snapshots written before the header
+// was introduced have no magic, they are read as the entries of the first version.
+const (
+	snapshotMagic   = "LOCACHE\x00"
+	snapshotVersion = 1
+)
+
+// ErrSnapshotIncompatible is returned by Restore when the snapshot has an unknown version
+// or was written by a cache of other key or value types.
+var ErrSnapshotIncompatible = errors.New("incompatible snapshot")
+
+// snapshotEntry is the entry of the snapshot, the zero Exp means the entry never expires.
+type snapshotEntry[Key comparable, Value any] struct {
+	Key   Key
+	Value Value
+	Exp   time.Time
+}
+
+// Snapshot writes the valid entries with their expiration times to w. The keys and
+// the values are encoded by the codecs (WithCodec, WithKeyCodec), gob by default.
+// The header of the snapshot records the key and the value types, so Restore refuses
+// the snapshot after the types change.
+// The entries are copied under the read lock and encoded after it is released.
+func (c *Cache[Key, Value]) Snapshot(w io.Writer) error {
+	return c.SnapshotContext(context.Background(), w)
+}
+
+// SnapshotContext works like Snapshot, but stops writing when the context is done
+// and returns the context error.
+func (c *Cache[Key, Value]) SnapshotContext(ctx context.Context, w io.Writer) error {
+	entries := c.snapshotEntries()
+
+	buf := bufio.NewWriter(w)
+	if _, err := buf.Write(c.appendSnapshotHeader(nil)); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	for i := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.writeEntry(buf, entries[i]); err != nil {
+			return fmt.Errorf("encode entry: %w", err)
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Restore reads the snapshot written by Snapshot and stores the entries with
+// their remaining TTLs, the entries expired since the snapshot are skipped.
+// It returns ErrSnapshotIncompatible before storing any entry when the snapshot
+// has no header, an unknown version or other key or value types.
+func (c *Cache[Key, Value]) Restore(r io.Reader) error {
+	buf := bufio.NewReader(r)
+	if err := c.readSnapshotHeader(buf); err != nil {
+		return err
+	}
+	return c.restoreEntries(buf)
+}
+
+// RestoreLegacy works like Restore, but reads the snapshots written without the header
+// by the versions before the snapshots were versioned. The types of the entries are not
+// checked, the snapshot of other types fails to decode or is decoded into wrong values.
+func (c *Cache[Key, Value]) RestoreLegacy(r io.Reader) error {
+	return c.restoreEntries(bufio.NewReader(r))
+}
+
+func (c *Cache[Key, Value]) restoreEntries(r *bufio.Reader) error {
+	for {
+		entry, err := c.readEntry(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("decode entry: %w", err)
+		}
+
+		c.restoreEntry(entry)
+	}
+}
+
+func (c *Cache[Key, Value]) appendSnapshotHeader(buf []byte) []byte {
+	buf = append(buf, snapshotMagic...)
+	buf = binary.AppendUvarint(buf, snapshotVersion)
+	buf = binary.BigEndian.AppendUint64(buf, typeFingerprint[Key]())
+	return binary.BigEndian.AppendUint64(buf, typeFingerprint[Value]())
+}
+
+// readSnapshotHeader checks the header of the snapshot, the snapshot without the header
+// is either written by another program or by an old version, see RestoreLegacy.
+func (c *Cache[Key, Value]) readSnapshotHeader(r *bufio.Reader) error {
+	magic, err := r.Peek(len(snapshotMagic))
+	if err != nil || string(magic) != snapshotMagic {
+		return fmt.Errorf("%w: missing header", ErrSnapshotIncompatible)
+	}
+	if _, err := r.Discard(len(magic)); err != nil {
+		return err
+	}
+
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("read snapshot header: %w", noEOF(err))
+	}
+	if version != snapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrSnapshotIncompatible, version)
+	}
+
+	var fingerprints [16]byte
+	if _, err := io.ReadFull(r, fingerprints[:]); err != nil {
+		return fmt.Errorf("read snapshot header: %w", noEOF(err))
+	}
+	if binary.BigEndian.Uint64(fingerprints[:8]) != typeFingerprint[Key]() {
+		return fmt.Errorf("%w: key type %s differs", ErrSnapshotIncompatible, typeName[Key]())
+	}
+	if binary.BigEndian.Uint64(fingerprints[8:]) != typeFingerprint[Value]() {
+		return fmt.Errorf("%w: value type %s differs", ErrSnapshotIncompatible, typeName[Value]())
+	}
+	return nil
+}
+
+// writeEntry writes the frames of the key and the value followed by the expiration time.
+func (c *Cache[Key, Value]) writeEntry(w io.Writer, entry snapshotEntry[Key, Value]) error {
+	buf, err := c.appendEntry(nil, entry)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func (c *Cache[Key, Value]) appendEntry(buf []byte, entry snapshotEntry[Key, Value]) ([]byte, error) {
+	buf, err := c.appendKey(buf, entry.Key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := c.valueCodec.Encode(entry.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var exp int64
+	if !entry.Exp.IsZero() {
+		exp = entry.Exp.UnixNano()
+	}
+
+	buf = appendFrame(buf, val)
+	return binary.AppendVarint(buf, exp), nil
+}
+
+func (c *Cache[Key, Value]) appendKey(buf []byte, key Key) ([]byte, error) {
+	data, err := c.keyCodec.Encode(key)
+	if err != nil {
+		return nil, err
+	}
+	return appendFrame(buf, data), nil
+}
+
+// readEntry reads the entry written by writeEntry, it returns io.EOF
+// only when the reader ends before the entry.
+func (c *Cache[Key, Value]) readEntry(r *bufio.Reader) (snapshotEntry[Key, Value], error) {
+	var entry snapshotEntry[Key, Value]
+
+	var err error
+	if entry.Key, err = c.readKey(r); err != nil {
+		return entry, err
+	}
+
+	val, err := readFrame(r)
+	if err != nil {
+		return entry, noEOF(err)
+	}
+	exp, err := binary.ReadVarint(r)
+	if err != nil {
+		return entry, noEOF(err)
+	}
+
+	if entry.Value, err = c.valueCodec.Decode(val); err != nil {
+		return entry, err
+	}
+	if exp != 0 {
+		entry.Exp = time.Unix(0, exp)
+	}
+	return entry, nil
+}
+
+// ScheduleSnapshot writes the snapshot to the file every interval until ctx is done,
+// the returned stop function is called or the cache is closed. The errors are reported
+// to SnapshotMetrics and to the logger (WithLogger).
+// The stop function waits for the running snapshot to finish.
+func (c *Cache[Key, Value]) ScheduleSnapshot(ctx context.Context, interval time.Duration, path string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := c.schedule(ctx, interval, func() {
+		if err := c.SnapshotFile(path); err != nil {
+			c.logger.snapshotFailed(path, err)
+		}
+	})
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// SnapshotFile writes the snapshot to a temporary file in the directory of the path
+// and renames it to the path, so the file is either the previous snapshot or the new one.
+func (c *Cache[Key, Value]) SnapshotFile(path string) error {
+	return c.snapshotFile(context.Background(), path)
+}
+
+func (c *Cache[Key, Value]) snapshotFile(ctx context.Context, path string) (err error) {
+	var size int64
+	if mtr, ok := c.mtr.(SnapshotMetrics); ok {
+		startTime := c.requestClock.Now()
+		defer func() { mtr.ObserveSnapshot(size, c.requestClock.Now().Sub(startTime), err) }()
+	}
+
+	size, err = writeFileAtomic(path, func(w io.Writer) error {
+		return c.SnapshotContext(ctx, w)
+	})
+	return err
+}
+
+// writeFileAtomic writes the file with the write function through a temporary file,
+// it returns the size of the file.
+func writeFileAtomic(path string, write func(w io.Writer) error) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file: %w", err)
+	}
+	// The temp file doesn't exist after the rename, the error is ignored then.
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	size, err := writeFile(tmp, write)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close temp file: %w", closeErr)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("rename temp file: %w", err)
+	}
+	return size, nil
+}
+
+func writeFile(f *os.File, write func(w io.Writer) error) (int64, error) {
+	buf := bufio.NewWriter(f)
+	if err := write(buf); err != nil {
+		return 0, err
+	}
+	if err := buf.Flush(); err != nil {
+		return 0, fmt.Errorf("flush temp file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return 0, fmt.Errorf("sync temp file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat temp file: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// readKey reads the key written by appendKey.
+func (c *Cache[Key, Value]) readKey(r *bufio.Reader) (Key, error) {
+	data, err := readFrame(r)
+	if err != nil {
+		var emptyKey Key
+		return emptyKey, err
+	}
+	return c.keyCodec.Decode(data)
+}
+
+func (c *Cache[Key, Value]) snapshotEntries() []snapshotEntry[Key, Value] {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.snapshotEntriesLocked()
+}
+
+func (c *Cache[Key, Value]) snapshotEntriesLocked() []snapshotEntry[Key, Value] {
+	entries := make([]snapshotEntry[Key, Value], 0, c.items.Len())
+	for element := c.items.Front(); element != nil; element = element.Next() {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			entries = append(entries, snapshotEntry[Key, Value]{Key: item.key, Value: item.val, Exp: item.ExpiresAt()})
+		}
+	}
+	return entries
+}
+
+// restoreEntry stores the entry with the remaining TTL, other replicas are not invalidated.
+func (c *Cache[Key, Value]) restoreEntry(entry snapshotEntry[Key, Value]) {
+	if entry.Exp.IsZero() {
+		c.set(entry.Key, entry.Value, 0, "", false)
+		return
+	}
+
+	if ttl := entry.Exp.Sub(c.clock.Now()); ttl > 0 {
+		c.set(entry.Key, entry.Value, ttl, "", false)
+	}
+}
@@ -0,0 +1,247 @@
+package locache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotUser struct {
+	ID   int64
+	Name string
+}
+
+func TestCache_SnapshotRestore(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, *snapshotUser](WithTTL(time.Minute), WithClock(clock))
+	cache.Set("key0", &snapshotUser{ID: 1, Name: "John"})
+	cache.SetWithTTL("key1", &snapshotUser{ID: 2, Name: "Jane"}, 10*time.Second)
+	cache.SetForever("key2", &snapshotUser{ID: 3, Name: "Jack"})
+	cache.SetWithTTL("key3", &snapshotUser{ID: 4, Name: "Jill"}, time.Second)
+
+	clock.Advance(2 * time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+
+	// The entries expired since the snapshot are not restored.
+	clock.Advance(10 * time.Second)
+
+	restored := New[string, *snapshotUser](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, restored.Restore(&buf))
+
+	require.ElementsMatch(t, []string{"key0", "key2"}, restored.Keys())
+	user, ok := restored.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, &snapshotUser{ID: 1, Name: "John"}, user)
+
+	ttl, ok := restored.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 48*time.Second, ttl)
+
+	ttl, ok = restored.TTL("key2")
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), ttl)
+}
+
+func TestCache_Restore_Corrupted(t *testing.T) {
+	cache := New[string, string]()
+	snapshot := append(cache.appendSnapshotHeader(nil), "garbage"...)
+	require.ErrorContains(t, cache.Restore(bytes.NewReader(snapshot)), "decode entry")
+}
+
+func TestCache_Restore_Incompatible(t *testing.T) {
+	type snapshotUserV2 struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	cache := New[string, *snapshotUser]()
+	cache.Set("key0", &snapshotUser{ID: 1, Name: "John"})
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+	snapshot := buf.Bytes()
+
+	err := New[string, *snapshotUserV2]().Restore(bytes.NewReader(snapshot))
+	require.ErrorIs(t, err, ErrSnapshotIncompatible)
+	require.ErrorContains(t, err, "value type")
+
+	err = New[int, *snapshotUser]().Restore(bytes.NewReader(snapshot))
+	require.ErrorIs(t, err, ErrSnapshotIncompatible)
+	require.ErrorContains(t, err, "key type")
+
+	future := append([]byte(snapshotMagic), 2)
+	err = New[string, *snapshotUser]().Restore(bytes.NewReader(future))
+	require.ErrorIs(t, err, ErrSnapshotIncompatible)
+	require.ErrorContains(t, err, "unsupported version 2")
+}
+
+func TestCache_Restore_WithoutHeader(t *testing.T) {
+	cache := New[string, string]()
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.writeEntry(&buf, snapshotEntry[string, string]{Key: "key0", Value: "val0"}))
+
+	snapshot := buf.Bytes()
+
+	err := cache.Restore(bytes.NewReader(snapshot))
+	require.ErrorIs(t, err, ErrSnapshotIncompatible)
+	require.ErrorContains(t, err, "missing header")
+	require.Zero(t, cache.Len())
+
+	require.NoError(t, cache.RestoreLegacy(bytes.NewReader(snapshot)))
+	require.Equal(t, []string{"key0"}, cache.Keys())
+}
+
+func TestTypeFingerprint(t *testing.T) {
+	type node struct {
+		Value string
+		Next  *node
+	}
+
+	require.Equal(t, typeFingerprint[*snapshotUser](), typeFingerprint[*snapshotUser]())
+	require.NotEqual(t, typeFingerprint[snapshotUser](), typeFingerprint[*snapshotUser]())
+	require.NotEqual(t, typeFingerprint[string](), typeFingerprint[[]string]())
+	require.NotEqual(t, typeFingerprint[map[string]int](), typeFingerprint[map[string]int64]())
+	require.NotZero(t, typeFingerprint[node]())
+}
+
+// The local types of the functions have the same names, like the versions of a type
+// before and after a change.
+func namedTypesFingerprints(changed bool) []uint64 {
+	if changed {
+		type ID string
+		type Item struct {
+			ID   ID
+			Name string
+		}
+		type Items []Item
+		type ItemsByID map[ID]*Item
+		type ItemsArray [2]Item
+		type ItemPtr *Item
+		return []uint64{typeFingerprint[Items](), typeFingerprint[ItemsByID](), typeFingerprint[ItemsArray](), typeFingerprint[ItemPtr]()}
+	}
+
+	type ID int64
+	type Item struct {
+		ID ID
+	}
+	type Items []Item
+	type ItemsByID map[ID]*Item
+	type ItemsArray [2]Item
+	type ItemPtr *Item
+	return []uint64{typeFingerprint[Items](), typeFingerprint[ItemsByID](), typeFingerprint[ItemsArray](), typeFingerprint[ItemPtr]()}
+}
+
+func TestTypeFingerprint_NamedTypes(t *testing.T) {
+	before, after := namedTypesFingerprints(false), namedTypesFingerprints(true)
+	for i := range before {
+		require.NotEqual(t, before[i], after[i], i)
+	}
+	require.Equal(t, before, namedTypesFingerprints(false))
+}
+
+type snapshotMetrics struct {
+	NopMetrics
+
+	mtx   sync.Mutex
+	sizes []int64
+	errs  []error
+}
+
+func (m *snapshotMetrics) ObserveSnapshot(size int64, _ time.Duration, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.sizes = append(m.sizes, size)
+	m.errs = append(m.errs, err)
+}
+
+func (m *snapshotMetrics) observed() int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return len(m.sizes)
+}
+
+func TestCache_ScheduleSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	clock := newFakeClock()
+	mtr := &snapshotMetrics{}
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock), WithMetrics(mtr))
+	cache.Set("key0", "value0")
+
+	stop := cache.ScheduleSnapshot(context.Background(), time.Second, path)
+	defer stop()
+
+	require.Eventually(t, func() bool { return clock.waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return mtr.observed() == 1 }, time.Second, time.Millisecond)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, []int64{info.Size()}, mtr.sizes)
+	require.Equal(t, []error{nil}, mtr.errs)
+
+	// Only the snapshot is left in the directory.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	restored := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, restored.Restore(f))
+	requireKeyExists(t, restored, "key0", "value0")
+}
+
+func TestCache_SnapshotFile_Error(t *testing.T) {
+	mtr := &snapshotMetrics{}
+	cache := New[string, string](WithMetrics(mtr))
+
+	err := cache.SnapshotFile(filepath.Join(t.TempDir(), "missing", "cache.snapshot"))
+	require.ErrorContains(t, err, "create temp file")
+	require.Equal(t, []error{err}, mtr.errs)
+}
+
+func TestCache_Close_SnapshotOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	cache := New[string, string](WithTTL(time.Minute), WithSnapshotOnClose(path, time.Second))
+	cache.Set("key0", "value0")
+	require.NoError(t, cache.Close())
+	require.Equal(t, 0, cache.Len())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	restored := New[string, string](WithTTL(time.Minute))
+	require.NoError(t, restored.Restore(f))
+	requireKeyExists(t, restored, "key0", "value0")
+}
+
+func TestCache_Close_SnapshotOnCloseTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	cache := New[string, string](WithSnapshotOnClose(path, time.Nanosecond))
+	cache.Set("key0", "value0")
+
+	err := cache.Close()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, cache.Close(), ErrClosed)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
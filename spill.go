@@ -0,0 +1,208 @@
+package locache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"time"
+)
+
+// spillMarker is the generation of the spilled entry of the key,
+// written is set when the entry is in the store.
+type spillMarker struct {
+	gen     uint64
+	written bool
+}
+
+// spillItem schedules the write of the evicted entry to the spill store,
+// it must be called under the write lock.
+func (c *Cache[Key, Value]) spillItem(item *Item[Key, Value]) {
+	if c.spilled == nil {
+		c.spilled = make(map[Key]spillMarker)
+	}
+	c.spillGen++
+	gen := c.spillGen
+	c.spilled[item.key] = spillMarker{gen: gen}
+
+	entry := snapshotEntry[Key, Value]{Key: item.key, Value: item.val, Exp: item.ExpiresAt()}
+	c.callbacks = append(c.callbacks, func() {
+		c.writeSpilled(entry, gen)
+	})
+}
+
+// writeSpilled writes the entry of the generation unless the key is unspilled or spilled again.
+// The callbacks of different unlocks run concurrently, so every operation of the store
+// holds spillMtx and checks the marker of the key before it.
+func (c *Cache[Key, Value]) writeSpilled(entry snapshotEntry[Key, Value], gen uint64) {
+	c.spillMtx.Lock()
+	defer c.spillMtx.Unlock()
+
+	if marker, found := c.spilledMarker(entry.Key); !found || marker.gen != gen {
+		return
+	}
+	err := c.setSpilled(entry)
+	if err != nil {
+		c.logger.storeFailed("spill", entry.Key, err)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if marker, found := c.spilled[entry.Key]; found && marker.gen == gen {
+		if err != nil {
+			delete(c.spilled, entry.Key)
+		} else {
+			c.spilled[entry.Key] = spillMarker{gen: gen, written: true}
+		}
+	}
+}
+
+func (c *Cache[Key, Value]) setSpilled(entry snapshotEntry[Key, Value]) error {
+	key, err := c.keyCodec.Encode(entry.Key)
+	if err != nil {
+		return err
+	}
+	data, err := c.appendEntry(nil, entry)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if !entry.Exp.IsZero() {
+		if ttl = entry.Exp.Sub(c.clock.Now()); ttl <= 0 {
+			return nil
+		}
+	}
+	return c.spill.Set(context.Background(), string(key), data, ttl)
+}
+
+func (c *Cache[Key, Value]) spilledMarker(key Key) (spillMarker, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	marker, found := c.spilled[key]
+	return marker, found
+}
+
+// unspill forgets the spilled entry of the key and schedules its deletion from
+// the spill store, it must be called under the write lock.
+func (c *Cache[Key, Value]) unspill(key Key) {
+	if _, found := c.spilled[key]; !found {
+		return
+	}
+	delete(c.spilled, key)
+
+	c.callbacks = append(c.callbacks, func() {
+		c.deleteSpilled(key)
+	})
+}
+
+// deleteSpilled deletes the entry of the key from the store unless the key is spilled
+// again and written meanwhile, the write of the newer entry replaces the deleted one.
+func (c *Cache[Key, Value]) deleteSpilled(key Key) {
+	c.spillMtx.Lock()
+	defer c.spillMtx.Unlock()
+
+	if marker, found := c.spilledMarker(key); found && marker.written {
+		return
+	}
+	if err := c.delSpilled(key); err != nil {
+		c.logger.storeFailed("del", key, err)
+	}
+}
+
+func (c *Cache[Key, Value]) delSpilled(key Key) error {
+	data, err := c.keyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+	return c.spill.Del(context.Background(), string(data))
+}
+
+// clearSpilled schedules the deletion of all spilled entries, it must be called under the write lock.
+func (c *Cache[Key, Value]) clearSpilled() {
+	for key := range c.spilled {
+		c.unspill(key)
+	}
+}
+
+// promote reads the spilled entry of the key and stores it back to the cache.
+func (c *Cache[Key, Value]) promote(key Key) (Value, time.Time, bool) {
+	var emptyVal Value
+
+	if _, found := c.spilledMarker(key); !found {
+		return emptyVal, time.Time{}, false
+	}
+
+	marker, entry, found := c.readSpilled(key)
+	if !found {
+		return emptyVal, time.Time{}, false
+	}
+
+	cost := c.weigh(key, entry.Value)
+
+	c.mtx.Lock()
+	defer c.unlock()
+
+	// The key is deleted or stored by another goroutine while the entry was read.
+	if element, found := c.index[key]; found {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			return item.val, item.ExpiresAt(), true
+		}
+	}
+	if current, found := c.spilled[key]; !found || current.gen != marker.gen {
+		return emptyVal, time.Time{}, false
+	}
+	c.unspill(key)
+
+	var ttl time.Duration
+	if !entry.Exp.IsZero() {
+		if ttl = entry.Exp.Sub(c.clock.Now()); ttl <= 0 {
+			return emptyVal, time.Time{}, false
+		}
+	}
+	if !c.store(MethodGet, key, entry.Value, cost, ttl) {
+		return emptyVal, time.Time{}, false
+	}
+	return entry.Value, c.getItem(c.index[key]).ExpiresAt(), true
+}
+
+// readSpilled reads the written entry of the key under spillMtx,
+// so the entry in the store belongs to the returned marker.
+func (c *Cache[Key, Value]) readSpilled(key Key) (spillMarker, snapshotEntry[Key, Value], bool) {
+	var entry snapshotEntry[Key, Value]
+
+	c.spillMtx.Lock()
+	defer c.spillMtx.Unlock()
+
+	marker, found := c.spilledMarker(key)
+	if !found || !marker.written {
+		return marker, entry, false
+	}
+
+	entry, found, err := c.getSpilled(key)
+	if err != nil {
+		c.logger.storeFailed("promote", key, err)
+	}
+	return marker, entry, found
+}
+
+func (c *Cache[Key, Value]) getSpilled(key Key) (snapshotEntry[Key, Value], bool, error) {
+	var entry snapshotEntry[Key, Value]
+
+	data, err := c.keyCodec.Encode(key)
+	if err != nil {
+		return entry, false, err
+	}
+
+	data, found, err := c.spill.Get(context.Background(), string(data))
+	if err != nil || !found {
+		return entry, false, err
+	}
+
+	entry, err = c.readEntry(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return entry, false, err
+	}
+	return entry, true, nil
+}
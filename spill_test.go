@@ -0,0 +1,181 @@
+package locache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Spill(t *testing.T) {
+	store, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	clock := newFakeClock()
+	cache := New[string, *snapshotUser](WithTTL(time.Minute), WithClock(clock), WithMaxEntries(1), WithSpill(store))
+	cache.Set("key0", &snapshotUser{ID: 1, Name: "John"})
+	cache.Set("key1", &snapshotUser{ID: 2, Name: "Jane"})
+	require.Equal(t, []string{"key1"}, cache.Keys())
+
+	clock.Advance(10 * time.Second)
+
+	// The spilled entry is promoted with its remaining TTL and evicts the other one.
+	user, exp, ok := cache.GetWithExpiry("key0")
+	require.True(t, ok)
+	require.Equal(t, &snapshotUser{ID: 1, Name: "John"}, user)
+	require.Equal(t, clock.Now().Add(50*time.Second), exp)
+	require.Equal(t, []string{"key0"}, cache.Keys())
+
+	user, ok = cache.Get("key1")
+	require.True(t, ok)
+	require.Equal(t, &snapshotUser{ID: 2, Name: "Jane"}, user)
+
+	_, ok = cache.Get("key2")
+	require.False(t, ok)
+}
+
+func TestCache_Spill_Del(t *testing.T) {
+	store, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	cache := New[string, string](WithMaxEntries(1), WithSpill(store))
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+
+	// The spilled entry is deleted with the key.
+	cache.Del("key0")
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+
+	key, err := GobCodec[string]{}.Encode("key0")
+	require.NoError(t, err)
+	_, found, err := store.Get(context.Background(), string(key))
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestCache_Spill_SetReplaces(t *testing.T) {
+	store, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	cache := New[string, string](WithMaxEntries(1), WithSpill(store))
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+	cache.Set("key0", "val2")
+
+	// The spilled value is not promoted after the new one is deleted.
+	cache.Del("key0")
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+
+	val, ok := cache.Get("key1")
+	require.True(t, ok)
+	require.Equal(t, "val1", val)
+}
+
+func TestCache_Spill_Clear(t *testing.T) {
+	store, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	cache := New[string, string](WithMaxEntries(1), WithSpill(store))
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+	cache.Clear()
+
+	_, ok := cache.Get("key0")
+	require.False(t, ok)
+}
+
+// gatedStore blocks the first write until the release and fails it with the error.
+type gatedStore struct {
+	Store
+	started chan struct{}
+	release chan struct{}
+	err     error
+	calls   atomic.Int32
+}
+
+func newGatedStore(store Store, err error) *gatedStore {
+	return &gatedStore{Store: store, started: make(chan struct{}), release: make(chan struct{}), err: err}
+}
+
+func (s *gatedStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if s.calls.Add(1) == 1 {
+		close(s.started)
+		<-s.release
+		if s.err != nil {
+			return s.err
+		}
+	}
+	return s.Store.Set(ctx, key, value, ttl)
+}
+
+func TestCache_Spill_DelWhileWriting(t *testing.T) {
+	disk, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	store := newGatedStore(disk, nil)
+	cache := New[string, string](WithMaxEntries(1), WithSpill(store))
+	cache.Set("key0", "val0")
+
+	spilled := make(chan struct{})
+	go func() {
+		defer close(spilled)
+		cache.Set("key1", "val1")
+	}()
+	<-store.started
+
+	deleted := make(chan struct{})
+	go func() {
+		defer close(deleted)
+		cache.Del("key0")
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(store.release)
+	<-spilled
+	<-deleted
+
+	// The deletion isn't overtaken by the write of the entry.
+	key, err := GobCodec[string]{}.Encode("key0")
+	require.NoError(t, err)
+	_, found, err := disk.Get(context.Background(), string(key))
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestCache_Spill_FailedWriteOfReplacedEntry(t *testing.T) {
+	disk, err := NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	store := newGatedStore(disk, errors.New("store failed"))
+	cache := New[string, string](WithMaxEntries(1), WithSpill(store))
+	cache.Set("key0", "val0")
+
+	spilled := make(chan struct{})
+	go func() {
+		defer close(spilled)
+		cache.Set("key1", "val1")
+	}()
+	<-store.started
+
+	respilled := make(chan struct{})
+	go func() {
+		defer close(respilled)
+		cache.Set("key0", "val2")
+		cache.Set("key1", "val3")
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(store.release)
+	<-spilled
+	<-respilled
+
+	// The failed write of the replaced entry keeps the marker of the newer one.
+	val, ok := cache.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, "val2", val)
+}
@@ -0,0 +1,134 @@
+package locache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats is a snapshot of the cache statistics collected regardless of the metrics.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Errors    uint64
+	Evictions uint64
+	Expired   uint64
+	Entries   int
+	// LastPurge is the time of the last purge, zero if there was none.
+	LastPurge time.Time
+}
+
+// statsMetrics counts the statistics of the cache and forwards the calls
+// to the configured metrics, including the optional extensions.
+type statsMetrics struct {
+	Metrics
+	clock Clock
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	errors    atomic.Uint64
+	evictions atomic.Uint64
+	expired   atomic.Uint64
+	lastPurge atomic.Int64
+}
+
+func (m *statsMetrics) IncHits(method string) {
+	m.hits.Add(1)
+	m.Metrics.IncHits(method)
+}
+
+func (m *statsMetrics) IncMisses(method string) {
+	m.misses.Add(1)
+	m.Metrics.IncMisses(method)
+}
+
+func (m *statsMetrics) IncErrors(method string) {
+	m.errors.Add(1)
+	m.Metrics.IncErrors(method)
+}
+
+func (m *statsMetrics) IncEvictions(method string) {
+	m.evictions.Add(1)
+	m.Metrics.IncEvictions(method)
+}
+
+func (m *statsMetrics) IncExpired(count int) {
+	m.expired.Add(uint64(count))
+	if mtr, ok := m.Metrics.(RemovalMetrics); ok {
+		mtr.IncExpired(count)
+	}
+}
+
+func (m *statsMetrics) IncRemoved(method string) {
+	if mtr, ok := m.Metrics.(RemovalMetrics); ok {
+		mtr.IncRemoved(method)
+	}
+}
+
+func (m *statsMetrics) ObservePurge(scanned, removed int, duration time.Duration) {
+	m.lastPurge.Store(m.clock.Now().UnixNano())
+	if mtr, ok := m.Metrics.(PurgeMetrics); ok {
+		mtr.ObservePurge(scanned, removed, duration)
+	}
+}
+
+func (m *statsMetrics) StartRefresh() {
+	if mtr, ok := m.Metrics.(RefreshMetrics); ok {
+		mtr.StartRefresh()
+	}
+}
+
+func (m *statsMetrics) ObserveRefresh(duration time.Duration, err error) {
+	if mtr, ok := m.Metrics.(RefreshMetrics); ok {
+		mtr.ObserveRefresh(duration, err)
+	}
+}
+
+func (m *statsMetrics) ObserveSnapshot(size int64, duration time.Duration, err error) {
+	if mtr, ok := m.Metrics.(SnapshotMetrics); ok {
+		mtr.ObserveSnapshot(size, duration, err)
+	}
+}
+
+func (m *statsMetrics) SetCost(cost int64) {
+	if mtr, ok := m.Metrics.(CostMetrics); ok {
+		mtr.SetCost(cost)
+	}
+}
+
+func (m *statsMetrics) snapshot(entries int) CacheStats {
+	stats := CacheStats{
+		Hits:      m.hits.Load(),
+		Misses:    m.misses.Load(),
+		Errors:    m.errors.Load(),
+		Evictions: m.evictions.Load(),
+		Expired:   m.expired.Load(),
+		Entries:   entries,
+	}
+	if lastPurge := m.lastPurge.Load(); lastPurge != 0 {
+		stats.LastPurge = time.Unix(0, lastPurge)
+	}
+	return stats
+}
+
+// Stats returns the statistics of the cache. Entries include expired entries which are not purged yet.
+func (c *Cache[Key, Value]) Stats() CacheStats {
+	return c.stats.snapshot(c.Len())
+}
+
+// Stats returns the statistics summed over the shards, LastPurge is the latest purge of any shard.
+func (s *Sharded[Key, Value]) Stats() CacheStats {
+	var total CacheStats
+	for _, shard := range s.shards {
+		stats := shard.Stats()
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Errors += stats.Errors
+		total.Evictions += stats.Evictions
+		total.Expired += stats.Expired
+		total.Entries += stats.Entries
+		if stats.LastPurge.After(total.LastPurge) {
+			total.LastPurge = stats.LastPurge
+		}
+	}
+	return total
+}
@@ -0,0 +1,58 @@
+package locache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Stats(t *testing.T) {
+	clock := newFakeClock()
+	cache := New[string, string](WithTTL(time.Second), WithClock(clock), WithMaxEntries(2))
+	require.Equal(t, CacheStats{}, cache.Stats())
+
+	cache.Set("key0", "value0")
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Get("key2")
+	cache.Get("key0")
+	_, err := cache.GetOrRefresh("key3", func() (string, error) {
+		return "", fmt.Errorf("some error")
+	})
+	require.Error(t, err)
+
+	clock.Advance(time.Second)
+	cache.Purge()
+
+	require.Equal(t, CacheStats{
+		Hits:      1,
+		Misses:    1,
+		Errors:    1,
+		Evictions: 1,
+		Expired:   2,
+		Entries:   0,
+		LastPurge: time.Unix(0, clock.Now().UnixNano()),
+	}, cache.Stats())
+}
+
+func TestSharded_Stats(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewSharded[string, string](4, WithTTL(time.Second), WithClock(clock))
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), "value")
+		cache.Get(fmt.Sprintf("key%d", i))
+	}
+	cache.Get("unknown")
+
+	stats := cache.Stats()
+	require.Equal(t, uint64(10), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, 10, stats.Entries)
+	require.True(t, stats.LastPurge.IsZero())
+
+	cache.Purge()
+	require.Equal(t, time.Unix(0, clock.Now().UnixNano()), cache.Stats().LastPurge)
+}
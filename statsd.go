@@ -0,0 +1,153 @@
+package locache
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatsDOpts configure StatsDMetrics.
+type StatsDOpts struct {
+	// Prefix is prepended to the metric names: prefix.requests and so on.
+	Prefix string
+	// SampleRate from 0 to 1 of the counters and timers, 1 by default.
+	// Gauges are never sampled.
+	SampleRate float64
+	// DogStatsD passes the method and the status as tags. Otherwise they are
+	// appended to the metric name (prefix.requests.get.hits) and Tags are ignored.
+	DogStatsD bool
+	// Tags are added to every metric in the DogStatsD format, e.g. "service:users".
+	Tags []string
+	// Clock measures the request time, the system clock by default.
+	// It should be the clock of the cache passed to WithClock, e.g. CoarseClock.
+	Clock Clock
+}
+
+// StatsDMetrics sends the metrics in the StatsD line protocol, one metric per write.
+// The writer is usually a UDP connection to the agent:
+//
+//	conn, err := net.Dial("udp", "127.0.0.1:8125")
+//
+// Write errors are ignored, as with any fire-and-forget UDP metrics.
+type StatsDMetrics struct {
+	w    io.Writer
+	opts StatsDOpts
+
+	mtx sync.Mutex
+	buf []byte
+}
+
+func NewStatsDMetrics(w io.Writer, opts StatsDOpts) *StatsDMetrics {
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		opts.SampleRate = 1
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	return &StatsDMetrics{w: w, opts: opts}
+}
+
+func (m *StatsDMetrics) IncHits(method string) {
+	m.send("requests", method, "hits", 1, "c", true)
+}
+
+func (m *StatsDMetrics) IncMisses(method string) {
+	m.send("requests", method, "misses", 1, "c", true)
+}
+
+func (m *StatsDMetrics) IncErrors(method string) {
+	m.send("requests", method, "error", 1, "c", true)
+}
+
+func (m *StatsDMetrics) IncEvictions(method string) {
+	m.send("evictions", method, "", 1, "c", true)
+}
+
+func (m *StatsDMetrics) ObserveRequest(method string, timeStart time.Time) {
+	m.send("requests_time", method, "", milliseconds(m.opts.Clock.Now().Sub(timeStart)), "ms", true)
+}
+
+func (m *StatsDMetrics) SetItemsCount(count int) {
+	m.send("items", "", "", float64(count), "g", false)
+}
+
+func (m *StatsDMetrics) ObservePurge(scanned, removed int, duration time.Duration) {
+	m.send("purge_scanned", "", "", float64(scanned), "c", true)
+	m.send("purge_removed", "", "", float64(removed), "c", true)
+	m.send("purge_time", "", "", milliseconds(duration), "ms", true)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// send writes the metric line: name:value|type|@rate|#tags.
+func (m *StatsDMetrics) send(name, method, status string, value float64, metricType string, sampled bool) {
+	sampled = sampled && m.opts.SampleRate < 1
+	if sampled && random() >= m.opts.SampleRate {
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	buf := m.buf[:0]
+	if m.opts.Prefix != "" {
+		buf = append(buf, m.opts.Prefix...)
+		buf = append(buf, '.')
+	}
+	buf = append(buf, name...)
+
+	if !m.opts.DogStatsD {
+		for _, part := range []string{method, status} {
+			if part != "" {
+				buf = append(buf, '.')
+				buf = append(buf, part...)
+			}
+		}
+	}
+
+	buf = append(buf, ':')
+	buf = strconv.AppendFloat(buf, value, 'f', -1, 64)
+	buf = append(buf, '|')
+	buf = append(buf, metricType...)
+
+	if sampled {
+		buf = append(buf, "|@"...)
+		buf = strconv.AppendFloat(buf, m.opts.SampleRate, 'f', -1, 64)
+	}
+
+	if m.opts.DogStatsD {
+		buf = m.appendTags(buf, method, status)
+	}
+
+	m.buf = buf
+	_, _ = m.w.Write(buf)
+}
+
+func (m *StatsDMetrics) appendTags(buf []byte, method, status string) []byte {
+	first := true
+	appendTag := func(parts ...string) {
+		if first {
+			buf = append(buf, "|#"...)
+			first = false
+		} else {
+			buf = append(buf, ',')
+		}
+		for _, part := range parts {
+			buf = append(buf, part...)
+		}
+	}
+
+	if method != "" {
+		appendTag("method:", method)
+	}
+	if status != "" {
+		appendTag("status:", status)
+	}
+	for _, tag := range m.opts.Tags {
+		appendTag(tag)
+	}
+	return buf
+}
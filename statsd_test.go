@@ -0,0 +1,71 @@
+package locache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type linesWriter struct {
+	lines []string
+}
+
+func (w *linesWriter) Write(p []byte) (int, error) {
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func TestStatsDMetrics(t *testing.T) {
+	w := &linesWriter{}
+	mtr := NewStatsDMetrics(w, StatsDOpts{Prefix: "users"})
+
+	mtr.IncHits(MethodGet)
+	mtr.IncEvictions(MethodSet)
+	mtr.SetItemsCount(3)
+	mtr.ObservePurge(2, 1, 1500*time.Microsecond)
+
+	require.Equal(t, []string{
+		"users.requests.get.hits:1|c",
+		"users.evictions.set:1|c",
+		"users.items:3|g",
+		"users.purge_scanned:2|c",
+		"users.purge_removed:1|c",
+		"users.purge_time:1.5|ms",
+	}, w.lines)
+}
+
+func TestStatsDMetrics_DogStatsD(t *testing.T) {
+	defer func(origin func() float64) { random = origin }(random)
+
+	w := &linesWriter{}
+	mtr := NewStatsDMetrics(w, StatsDOpts{
+		Prefix:     "users",
+		SampleRate: 0.5,
+		DogStatsD:  true,
+		Tags:       []string{"service:api"},
+	})
+
+	random = func() float64 { return 0.7 }
+	mtr.IncMisses(MethodGet)
+	require.Empty(t, w.lines)
+
+	random = func() float64 { return 0.2 }
+	mtr.IncMisses(MethodGet)
+	mtr.SetItemsCount(3)
+
+	require.Equal(t, []string{
+		"users.requests:1|c|@0.5|#method:get,status:misses,service:api",
+		"users.items:3|g|#service:api",
+	}, w.lines)
+}
+
+func TestStatsDMetrics_ObserveRequest(t *testing.T) {
+	clock := newFakeClock()
+
+	w := &linesWriter{}
+	mtr := NewStatsDMetrics(w, StatsDOpts{Clock: clock})
+	mtr.ObserveRequest(MethodGet, clock.Now().Add(-2*time.Millisecond))
+
+	require.Equal(t, []string{"requests_time.get:2|ms"}, w.lines)
+}
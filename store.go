@@ -0,0 +1,96 @@
+package locache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store keeps encoded entries outside the cache memory: on disk (DiskStore)
+// or in a remote storage. The implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the value of the key, the second result reports whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value of the key, a ttl <= 0 means the value never expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Del deletes the value of the key, the missing key is not an error.
+	Del(ctx context.Context, key string) error
+}
+
+// DiskStore keeps each value in a file of the directory named by the hash of the key.
+// The files are replaced atomically, the expired values are removed when they are read.
+type DiskStore struct {
+	dir   string
+	clock Clock
+}
+
+// NewDiskStore creates the directory if it doesn't exist.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	return &DiskStore{dir: dir, clock: systemClock{}}, nil
+}
+
+// Get implements Store.
+func (s *DiskStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path := s.path(key)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	exp, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, false, fmt.Errorf("read %s: corrupted expiration", path)
+	}
+	if exp != 0 && s.clock.Now().UnixNano() >= exp {
+		return nil, false, s.remove(path)
+	}
+	return data[n:], true, nil
+}
+
+// Set implements Store.
+func (s *DiskStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	var exp int64
+	if ttl > 0 {
+		exp = s.clock.Now().Add(ttl).UnixNano()
+	}
+
+	_, err := writeFileAtomic(s.path(key), func(w io.Writer) error {
+		if _, err := w.Write(binary.AppendVarint(nil, exp)); err != nil {
+			return err
+		}
+		_, err := w.Write(value)
+		return err
+	})
+	return err
+}
+
+// Del implements Store.
+func (s *DiskStore) Del(_ context.Context, key string) error {
+	return s.remove(s.path(key))
+}
+
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *DiskStore) remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
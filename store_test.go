@@ -0,0 +1,60 @@
+package locache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "store")
+
+	store, err := NewDiskStore(dir)
+	require.NoError(t, err)
+
+	_, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val0"), 0))
+	require.NoError(t, store.Set(ctx, "key", []byte("val1"), time.Minute))
+
+	val, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("val1"), val)
+
+	require.NoError(t, store.Del(ctx, "key"))
+	require.NoError(t, store.Del(ctx, "key"))
+
+	_, found, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestDiskStore_Expired(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewDiskStore(dir)
+	require.NoError(t, err)
+
+	clock := newFakeClock()
+	store.clock = clock
+
+	require.NoError(t, store.Set(ctx, "key", []byte("val"), time.Second))
+	clock.Advance(time.Second)
+
+	_, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// The expired file is removed.
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,125 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Tiered composes the in-memory cache (L1) with a Store (L2), e.g. a disk or a remote
+// storage shared by replicas. Reads fall through L1, L2 and the refresh function,
+// the values found in L2 or refreshed are stored to L1 with its TTL and the refreshed
+// values are stored to L2 with the L2 TTL. The keys and the values are encoded
+// for L2 by the codecs of L1 (WithCodec, WithKeyCodec). With L2 shared by processes
+// the key codec has to encode equal keys to equal bytes in every process, e.g. JSONCodec,
+// gob encodes the struct types with process-specific ids.
+type Tiered[Key comparable, Value any] struct {
+	l1    *Cache[Key, Value]
+	l2    Store
+	l2TTL time.Duration
+}
+
+// NewTiered creates the tiered cache, an l2TTL <= 0 means the L2 values never expire.
+func NewTiered[Key comparable, Value any](l1 *Cache[Key, Value], l2 Store, l2TTL time.Duration) *Tiered[Key, Value] {
+	return &Tiered[Key, Value]{l1: l1, l2: l2, l2TTL: l2TTL}
+}
+
+// Get returns the value from L1 or from L2, the value found in L2 is stored to L1
+// without publishing it to the invalidation bus and firing OnSet, the events and the audit of L1.
+// The second result reports whether the value was found, the error is the L2 error.
+func (t *Tiered[Key, Value]) Get(ctx context.Context, key Key) (Value, bool, error) {
+	if val, ok := t.l1.Get(key); ok {
+		return val, true, nil
+	}
+
+	val, found, err := t.getL2(ctx, key)
+	if err != nil || !found {
+		return val, false, err
+	}
+
+	t.l1.fill(key, val)
+	return val, true, nil
+}
+
+// GetOrRefreshCtx works like Cache.GetOrRefreshCtx, but the missing value is read
+// from L2 before the refresh function is called, one goroutine per key does it.
+// The refreshed value is stored to both levels. The L2 errors are reported
+// to the logger of L1 (WithLogger) and don't fail the call: the failed read
+// falls through to the refresh function.
+func (t *Tiered[Key, Value]) GetOrRefreshCtx(
+	ctx context.Context,
+	key Key,
+	refresh func(ctx context.Context) (Value, error),
+) (Value, error) {
+	return t.l1.GetOrRefreshCtx(ctx, key, func(ctx context.Context) (Value, error) {
+		val, found, err := t.getL2(ctx, key)
+		if err != nil {
+			t.l1.logger.storeFailed("get", key, err)
+		}
+		if found {
+			return val, nil
+		}
+
+		if val, err = refresh(ctx); err != nil {
+			return val, err
+		}
+		if err := t.setL2(ctx, key, val); err != nil {
+			t.l1.logger.storeFailed("set", key, err)
+		}
+		return val, nil
+	})
+}
+
+// Set stores the value to both levels, it returns the L2 error.
+func (t *Tiered[Key, Value]) Set(ctx context.Context, key Key, value Value) error {
+	t.l1.Set(key, value)
+	return t.setL2(ctx, key, value)
+}
+
+// Del deletes the value from both levels, it returns the L2 error.
+func (t *Tiered[Key, Value]) Del(ctx context.Context, key Key) error {
+	t.l1.Del(key)
+
+	data, err := t.l1.keyCodec.Encode(key)
+	if err != nil {
+		return fmt.Errorf("encode key: %w", err)
+	}
+	return t.l2.Del(ctx, string(data))
+}
+
+// L1 returns the in-memory cache, e.g. to close it.
+func (t *Tiered[Key, Value]) L1() *Cache[Key, Value] {
+	return t.l1
+}
+
+func (t *Tiered[Key, Value]) getL2(ctx context.Context, key Key) (Value, bool, error) {
+	var emptyVal Value
+
+	data, err := t.l1.keyCodec.Encode(key)
+	if err != nil {
+		return emptyVal, false, fmt.Errorf("encode key: %w", err)
+	}
+
+	data, found, err := t.l2.Get(ctx, string(data))
+	if err != nil || !found {
+		return emptyVal, false, err
+	}
+
+	val, err := t.l1.valueCodec.Decode(data)
+	if err != nil {
+		return emptyVal, false, fmt.Errorf("decode value: %w", err)
+	}
+	return val, true, nil
+}
+
+func (t *Tiered[Key, Value]) setL2(ctx context.Context, key Key, value Value) error {
+	data, err := t.l1.keyCodec.Encode(key)
+	if err != nil {
+		return fmt.Errorf("encode key: %w", err)
+	}
+	val, err := t.l1.valueCodec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("encode value: %w", err)
+	}
+	return t.l2.Set(ctx, string(data), val, t.l2TTL)
+}
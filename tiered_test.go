@@ -0,0 +1,160 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is the Store of the tests, it records the TTLs and fails with err.
+type memoryStore struct {
+	mtx    sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	val, found := s.values[key]
+	return val, found, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryStore) Del(_ context.Context, key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.values, key)
+	return nil
+}
+
+func TestTiered_GetOrRefreshCtx(t *testing.T) {
+	ctx := context.Background()
+	l2 := newMemoryStore()
+
+	clock := newFakeClock()
+	tiered := NewTiered[string, string](New[string, string](WithTTL(time.Minute), WithClock(clock)), l2, time.Hour)
+
+	calls := 0
+	refresh := func(context.Context) (string, error) {
+		calls++
+		return "val", nil
+	}
+
+	val, err := tiered.GetOrRefreshCtx(ctx, "key", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "val", val)
+	require.Equal(t, 1, calls)
+	require.Len(t, l2.values, 1)
+	for _, ttl := range l2.ttls {
+		require.Equal(t, time.Hour, ttl)
+	}
+
+	// Another replica finds the value in L2.
+	replica := NewTiered[string, string](New[string, string](WithTTL(time.Minute), WithClock(clock)), l2, time.Hour)
+	val, err = replica.GetOrRefreshCtx(ctx, "key", refresh)
+	require.NoError(t, err)
+	require.Equal(t, "val", val)
+	require.Equal(t, 1, calls)
+
+	ttl, ok := replica.L1().TTL("key")
+	require.True(t, ok)
+	require.Equal(t, time.Minute, ttl)
+}
+
+func TestTiered_GetSetDel(t *testing.T) {
+	ctx := context.Background()
+	l2 := newMemoryStore()
+	tiered := NewTiered[string, string](New[string, string](), l2, 0)
+
+	_, found, err := tiered.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, tiered.Set(ctx, "key", "val"))
+	tiered.L1().Del("key")
+
+	val, found, err := tiered.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "val", val)
+	require.Equal(t, []string{"key"}, tiered.L1().Keys())
+
+	require.NoError(t, tiered.Del(ctx, "key"))
+	_, found, err = tiered.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Empty(t, l2.values)
+}
+
+func TestTiered_StoreError(t *testing.T) {
+	ctx := context.Background()
+	l2 := newMemoryStore()
+	l2.err = fmt.Errorf("some error")
+	tiered := NewTiered[string, string](New[string, string](), l2, 0)
+
+	// The refresh function is called when L2 fails.
+	val, err := tiered.GetOrRefreshCtx(ctx, "key", func(context.Context) (string, error) {
+		return "val", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "val", val)
+
+	_, _, err = tiered.Get(ctx, "other")
+	require.EqualError(t, err, "some error")
+	require.EqualError(t, tiered.Set(ctx, "key", "val"), "some error")
+	require.EqualError(t, tiered.Del(ctx, "key"), "some error")
+}
+
+func TestTiered_Get_Fill(t *testing.T) {
+	ctx := context.Background()
+	l2 := newMemoryStore()
+	bus := newMemoryBus()
+
+	var sets atomic.Int32
+	l1 := New[string, string](WithInvalidation(bus), WithOnSet(func(string, string) { sets.Add(1) }))
+	defer l1.Close()
+	tiered := NewTiered[string, string](l1, l2, 0)
+
+	require.NoError(t, tiered.Set(ctx, "key", "val"))
+	l1.Del("key")
+	published := bus.publishes()
+
+	// The value read from L2 is not a write of L1.
+	val, found, err := tiered.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "val", val)
+	require.Equal(t, []string{"key"}, l1.Keys())
+	require.Equal(t, published, bus.publishes())
+	require.Equal(t, int32(1), sets.Load())
+}
@@ -0,0 +1,109 @@
+package locache
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+const (
+	sketchDepth    = 4
+	sketchMaxCount = 15
+)
+
+// tinyLFU estimates access frequency of keys with a count-min sketch.
+// A doorkeeper bloom filter absorbs the first access of every key,
+// so one-hit wonders never reach the sketch. All counters are halved
+// after sampleSize increments to let the estimation follow the workload.
+type tinyLFU[Key comparable] struct {
+	mtx  sync.Mutex
+	seed maphash.Seed
+
+	doorkeeper *bloomFilter
+	counters   [sketchDepth][]uint8
+	mask       uint64
+
+	additions  int
+	sampleSize int
+}
+
+func newTinyLFU[Key comparable](sampleSize int) *tinyLFU[Key] {
+	if sampleSize < 1 {
+		sampleSize = 1
+	}
+
+	width := nextPowerOfTwo(uint64(sampleSize))
+	lfu := &tinyLFU[Key]{
+		seed:       maphash.MakeSeed(),
+		doorkeeper: newBloomFilter(sampleSize, 0.01),
+		mask:       width - 1,
+		sampleSize: sampleSize * 10,
+	}
+	for i := range lfu.counters {
+		lfu.counters[i] = make([]uint8, width)
+	}
+	return lfu
+}
+
+// Record registers an access to the key.
+func (f *tinyLFU[Key]) Record(key Key) {
+	hash := hashKey(f.seed, key)
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if !f.doorkeeper.Add(hash) {
+		return
+	}
+
+	for i := range f.counters {
+		idx := f.index(hash, i)
+		if f.counters[i][idx] < sketchMaxCount {
+			f.counters[i][idx]++
+		}
+	}
+
+	f.additions++
+	if f.additions >= f.sampleSize {
+		f.reset()
+	}
+}
+
+// Admit reports whether the candidate is accessed more frequently than the victim.
+func (f *tinyLFU[Key]) Admit(candidate, victim Key) bool {
+	candidateHash := hashKey(f.seed, candidate)
+	victimHash := hashKey(f.seed, victim)
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return f.estimate(candidateHash) > f.estimate(victimHash)
+}
+
+func (f *tinyLFU[Key]) estimate(hash uint64) int {
+	estimate := sketchMaxCount
+	for i := range f.counters {
+		if count := int(f.counters[i][f.index(hash, i)]); count < estimate {
+			estimate = count
+		}
+	}
+
+	if f.doorkeeper.Contains(hash) {
+		estimate++
+	}
+	return estimate
+}
+
+func (f *tinyLFU[Key]) index(hash uint64, row int) uint64 {
+	h1, h2 := hash, hash>>32|hash<<32
+	return (h1 + uint64(row+1)*h2) & f.mask
+}
+
+func (f *tinyLFU[Key]) reset() {
+	f.additions = 0
+	f.doorkeeper.Reset()
+	for i := range f.counters {
+		for j := range f.counters[i] {
+			f.counters[i][j] >>= 1
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package locache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTinyLFU_Admit(t *testing.T) {
+	lfu := newTinyLFU[string](100)
+
+	for i := 0; i < 5; i++ {
+		lfu.Record("hot")
+	}
+	lfu.Record("cold")
+
+	require.True(t, lfu.Admit("hot", "cold"))
+	require.False(t, lfu.Admit("cold", "hot"))
+	require.False(t, lfu.Admit("unknown", "cold"))
+}
+
+func TestTinyLFU_Reset(t *testing.T) {
+	lfu := newTinyLFU[int](10)
+
+	for i := 0; i < 10; i++ {
+		lfu.Record(1)
+	}
+	before := lfu.estimate(hashKey(lfu.seed, 1))
+
+	// The counters of the key saturate and are halved after sampleSize increments.
+	for lfu.additions > 0 {
+		lfu.Record(1)
+	}
+	require.Less(t, lfu.estimate(hashKey(lfu.seed, 1)), before)
+}
+
+func TestCache_Set_TinyLFU_ProtectsHotEntries(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithMaxEntries(2), WithTinyLFU(100))
+	cache.Set("hot0", "value0")
+	cache.Set("hot1", "value1")
+	for i := 0; i < 3; i++ {
+		requireKeyExists(t, cache, "hot0", "value0")
+		requireKeyExists(t, cache, "hot1", "value1")
+	}
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprintf("scan%d", i), "scan")
+	}
+
+	requireKeyExists(t, cache, "hot0", "value0")
+	requireKeyExists(t, cache, "hot1", "value1")
+	requireCacheItems(t, cache, []string{"value0", "value1"})
+}
+
+func TestCache_GetOrRefresh_TinyLFU_RejectsColdEntry(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Second), WithMaxEntries(1), WithTinyLFU(100))
+	cache.Set("hot", "value0")
+	requireKeyExists(t, cache, "hot", "value0")
+	requireKeyExists(t, cache, "hot", "value0")
+
+	actual, err := cache.GetOrRefresh("cold", func() (string, error) {
+		return "value1", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value1", actual)
+
+	requireKeyNotExists(t, cache, "cold")
+	requireCacheItems(t, cache, []string{"value0"})
+}
@@ -0,0 +1,99 @@
+package locache
+
+import "context"
+
+// Span names and attributes of the traces.
+const (
+	SpanGetOrRefresh = "locache.GetOrRefresh"
+	SpanRefresh      = "locache.refresh"
+
+	// AttributeResult is one of the results: hit, miss, stale or error.
+	AttributeResult = "locache.result"
+
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultStale = "stale"
+	ResultError = "error"
+)
+
+// Tracer starts the spans of the cache operations. The library doesn't depend on
+// a tracing SDK, a small adapter of OpenTelemetry or another tracer implements it:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, locache.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the span started by the Tracer.
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// refreshTrace is the span of GetOrRefreshCtx, the nil trace records nothing.
+type refreshTrace struct {
+	tracer Tracer
+	span   Span
+	result string
+}
+
+// startTrace starts the span of GetOrRefreshCtx and replaces the context with the span context.
+// The calls without a context are not traced.
+func (c *Cache[Key, Value]) startTrace(ctx *context.Context, withContext bool) *refreshTrace {
+	if c.tracer == nil || !withContext {
+		return nil
+	}
+
+	trace := &refreshTrace{tracer: c.tracer}
+	*ctx, trace.span = c.tracer.Start(*ctx, SpanGetOrRefresh)
+	return trace
+}
+
+func (t *refreshTrace) setResult(result string) {
+	if t != nil {
+		t.result = result
+	}
+}
+
+// fail records the error returned by GetOrRefreshCtx.
+func (t *refreshTrace) fail(err error) {
+	if t != nil {
+		t.result = ResultError
+		t.span.RecordError(err)
+	}
+}
+
+func (t *refreshTrace) end() {
+	if t == nil {
+		return
+	}
+	if t.result != "" {
+		t.span.SetAttribute(AttributeResult, t.result)
+	}
+	t.span.End()
+}
+
+// traceRefresh wraps the refresh function to call it in the child span.
+func traceRefresh[Value any](
+	t *refreshTrace,
+	refresh func(ctx context.Context) (Value, error),
+) func(ctx context.Context) (Value, error) {
+	if t == nil {
+		return refresh
+	}
+
+	return func(ctx context.Context) (Value, error) {
+		ctx, span := t.tracer.Start(ctx, SpanRefresh)
+		defer span.End()
+
+		val, err := refresh(ctx)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return val, err
+	}
+}
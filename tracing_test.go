@@ -0,0 +1,85 @@
+package locache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type spanKey struct{}
+
+type testSpan struct {
+	name       string
+	parent     *testSpan
+	attributes map[string]any
+	errs       []error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value any) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)              { s.errs = append(s.errs, err) }
+func (s *testSpan) End()                               { s.ended = true }
+
+type testTracer struct {
+	mtx   sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	parent, _ := ctx.Value(spanKey{}).(*testSpan)
+	span := &testSpan{name: name, parent: parent, attributes: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestCache_WithTracer(t *testing.T) {
+	tracer := &testTracer{}
+	cache := New[string, string](WithTTL(time.Minute), WithTracer(tracer))
+
+	refresh := func(ctx context.Context) (string, error) {
+		span, _ := ctx.Value(spanKey{}).(*testSpan)
+		require.Equal(t, SpanRefresh, span.name)
+		return "value0", nil
+	}
+
+	_, err := cache.GetOrRefreshCtx(context.Background(), "key0", refresh)
+	require.NoError(t, err)
+	_, err = cache.GetOrRefreshCtx(context.Background(), "key0", refresh)
+	require.NoError(t, err)
+
+	someErr := fmt.Errorf("some error")
+	_, err = cache.GetOrRefreshCtx(context.Background(), "key1", func(context.Context) (string, error) {
+		return "", someErr
+	})
+	require.ErrorIs(t, err, someErr)
+
+	// The calls without a context are not traced.
+	_, err = cache.GetOrRefresh("key0", func() (string, error) { return "value0", nil })
+	require.NoError(t, err)
+
+	require.Len(t, tracer.spans, 5)
+	for _, span := range tracer.spans {
+		require.True(t, span.ended)
+	}
+
+	miss, refreshed, hit, failed, failedRefresh := tracer.spans[0], tracer.spans[1], tracer.spans[2], tracer.spans[3], tracer.spans[4]
+
+	require.Equal(t, SpanGetOrRefresh, miss.name)
+	require.Equal(t, map[string]any{AttributeResult: ResultMiss}, miss.attributes)
+	require.Same(t, miss, refreshed.parent)
+
+	require.Equal(t, SpanGetOrRefresh, hit.name)
+	require.Equal(t, map[string]any{AttributeResult: ResultHit}, hit.attributes)
+
+	require.Equal(t, map[string]any{AttributeResult: ResultError}, failed.attributes)
+	require.Equal(t, []error{someErr}, failed.errs)
+	require.Equal(t, SpanRefresh, failedRefresh.name)
+	require.Equal(t, []error{someErr}, failedRefresh.errs)
+}
@@ -0,0 +1,98 @@
+package locache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ConfigError describes an invalid option, it matches ErrInvalidConfig.
+type ConfigError struct {
+	Option string
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("locache: invalid %s: %s", e.Option, e.Reason)
+}
+
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Validate checks the options of the cache with the given types
+// and returns all found ConfigErrors joined.
+func Validate[Key comparable, Value any](opts ...Option) error {
+	o := newOptions(opts)
+
+	var errs []error
+	check := func(invalid bool, option, reason string) {
+		if invalid {
+			errs = append(errs, &ConfigError{Option: option, Reason: reason})
+		}
+	}
+
+	check(o.ttl < 0, "WithTTL", "negative ttl")
+	check(o.purgeInterval < 0, "WithPurgeInterval", "negative interval")
+	check(o.lazyExpiration && o.purgeInterval > 0, "WithLazyExpiration", "conflicts with WithPurgeInterval")
+	check(o.expiryPerWrite < 0, "WithIncrementalExpiry", "negative entries count")
+	check(o.purgeBatchSize < 0, "WithPurgeBatchSize", "negative batch size")
+	check(o.purgeTimeBudget < 0, "WithPurgeTimeBudget", "negative budget")
+	check(o.maxEntries < 0, "WithMaxEntries", "negative entries count")
+	check(o.maxCost < 0, "WithMaxCost", "negative cost")
+	check(o.tinyLFUSamples < 0, "WithTinyLFU", "negative sample size")
+	check(o.doorkeeperKeys < 0, "WithDoorkeeper", "negative expected keys")
+	check(o.doorkeeperWindow < 0, "WithDoorkeeper", "negative window")
+	check(o.maxIdle < 0, "WithMaxIdle", "negative idle timeout")
+	check(o.earlyRefreshBeta < 0, "WithEarlyRefresh", "negative beta")
+	check(o.errorTTL < 0, "WithErrorTTL", "negative ttl")
+	check(o.refreshTimeout < 0, "WithRefreshTimeout", "negative timeout")
+	check(o.refreshWaitTimeout < 0, "WithRefreshWaitTimeout", "negative timeout")
+	check(o.eventsBuffer < 0, "WithEventsBuffer", "negative size")
+	check(o.auditSize < 0, "WithAudit", "negative size")
+	check(o.closeSnapshotTimeout < 0, "WithSnapshotOnClose", "negative timeout")
+	check(o.maxConcurrentRefreshes < 0, "WithMaxConcurrentRefreshes", "negative limit")
+	check(o.retryPolicy.Attempts < 0, "WithRetryPolicy", "negative attempts")
+	check(o.retryPolicy.Backoff < 0 || o.retryPolicy.MaxBackoff < 0, "WithRetryPolicy", "negative backoff")
+	check(o.retryPolicy.Jitter < 0 || o.retryPolicy.Jitter > 1, "WithRetryPolicy", "jitter out of [0, 1]")
+
+	if o.weigher != nil {
+		_, ok := o.weigher.(Weigher[Key, Value])
+		check(!ok, "WithWeigher", fmt.Sprintf("type %T does not match cache types", o.weigher))
+	}
+	if o.onEvict != nil {
+		_, ok := o.onEvict.(func(Key, Value, RemovalReason))
+		check(!ok, "WithOnEvict", fmt.Sprintf("type %T does not match cache types", o.onEvict))
+	}
+	if o.onSet != nil {
+		_, ok := o.onSet.(func(Key, Value))
+		check(!ok, "WithOnSet", fmt.Sprintf("type %T does not match cache types", o.onSet))
+	}
+	if o.onDelete != nil {
+		_, ok := o.onDelete.(func(Key))
+		check(!ok, "WithOnDelete", fmt.Sprintf("type %T does not match cache key type", o.onDelete))
+	}
+	if o.keyCodec != nil {
+		_, ok := o.keyCodec.(Codec[Key])
+		check(!ok, "WithKeyCodec", fmt.Sprintf("type %T does not match cache key type", o.keyCodec))
+	}
+	if o.valueCodec != nil {
+		_, ok := o.valueCodec.(Codec[Value])
+		check(!ok, "WithCodec", fmt.Sprintf("type %T does not match cache value type", o.valueCodec))
+	}
+	if o.policy != nil {
+		_, ok := o.policy.(EvictionPolicy[Key])
+		check(!ok, "WithEvictionPolicy", fmt.Sprintf("type %T does not match cache key type", o.policy))
+	}
+
+	return errors.Join(errs...)
+}
+
+// MustNew works like New, but panics when the options are invalid.
+func MustNew[Key comparable, Value any](opts ...Option) *Cache[Key, Value] {
+	if err := Validate[Key, Value](opts...); err != nil {
+		panic(err)
+	}
+	return New[Key, Value](opts...)
+}
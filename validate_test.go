@@ -0,0 +1,39 @@
+package locache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate[string, string](WithTTL(time.Minute), WithMaxEntries(10)))
+
+	err := Validate[string, string](
+		WithTTL(-time.Second),
+		WithMaxEntries(-1),
+		WithWeigher(func(_ int, _ string) int64 { return 1 }),
+		WithOnEvict(func(_ string, _ int, _ RemovalReason) {}),
+	)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	var configErr *ConfigError
+	require.True(t, errors.As(err, &configErr))
+	require.Equal(t, "WithTTL", configErr.Option)
+	require.EqualError(t, err, "locache: invalid WithTTL: negative ttl\n"+
+		"locache: invalid WithMaxEntries: negative entries count\n"+
+		"locache: invalid WithWeigher: type locache.Weigher[int,string] does not match cache types\n"+
+		"locache: invalid WithOnEvict: type func(string, int, locache.RemovalReason) does not match cache types")
+}
+
+func TestMustNew(t *testing.T) {
+	require.Panics(t, func() {
+		MustNew[string, string](WithTTL(-time.Second))
+	})
+
+	cache := MustNew[string, string](WithTTL(time.Minute), WithMetrics(nil))
+	cache.Set("key0", "value0")
+	requireKeyExists(t, cache, "key0", "value0")
+}
@@ -0,0 +1,213 @@
+package locache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	errWALOpened    = errors.New("wal is already open")
+	errWALNotOpened = errors.New("wal is not open")
+)
+
+// Operations of the write-ahead log records.
+const (
+	walSet byte = iota + 1
+	walDel
+	walClear
+)
+
+// OpenWAL replays the write-ahead log at path into the cache, compacts it and appends
+// the following writes, deletions, Clear calls and the expirations set by Touch and Expire
+// to it. The file is created when it does not exist. The records are encoded by the codecs
+// like the snapshots and are written under the cache lock without fsync, so they survive
+// a crash of the process but not of the machine, and the writes become slower.
+// Expired entries are skipped on replay, evictions and sliding expirations are not recorded.
+// A torn record at the end of the log, left by a crash in the middle of a write, ends the replay
+// and is dropped by the compaction. The log is closed by Close, its write errors are reported
+// to the logger (WithLogger).
+func (c *Cache[Key, Value]) OpenWAL(path string) error {
+	c.mtx.RLock()
+	opened := c.wal != nil
+	c.mtx.RUnlock()
+
+	if opened {
+		return errWALOpened
+	}
+
+	if err := c.replayWAL(path); err != nil {
+		return fmt.Errorf("replay wal: %w", err)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.wal != nil {
+		return errWALOpened
+	}
+
+	c.walPath = path
+	return c.compactWAL()
+}
+
+// CompactWAL rewrites the write-ahead log with the valid entries only.
+// The writes are blocked while the log is rewritten.
+func (c *Cache[Key, Value]) CompactWAL() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.wal == nil {
+		return errWALNotOpened
+	}
+	return c.compactWAL()
+}
+
+// compactWAL writes the valid entries to the new log and reopens it, it must be called under the write lock.
+func (c *Cache[Key, Value]) compactWAL() error {
+	entries := c.snapshotEntriesLocked()
+
+	_, err := writeFileAtomic(c.walPath, func(w io.Writer) error {
+		for i := range entries {
+			record, err := c.appendEntry([]byte{walSet}, entries[i])
+			if err != nil {
+				return fmt.Errorf("encode entry: %w", err)
+			}
+			if _, err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("compact wal: %w", err)
+	}
+
+	f, err := os.OpenFile(c.walPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+
+	if c.wal != nil {
+		// The old file is replaced by the rename, its descriptor only has to be released.
+		_ = c.wal.Close()
+	}
+	c.wal = f
+	return nil
+}
+
+func (c *Cache[Key, Value]) replayWAL(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err := c.replayRecord(op, r); err != nil {
+			// The record is applied only when it is read completely.
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("decode record: %w", err)
+		}
+	}
+}
+
+func (c *Cache[Key, Value]) replayRecord(op byte, r *bufio.Reader) error {
+	switch op {
+	case walSet:
+		entry, err := c.readEntry(r)
+		if err != nil {
+			return err
+		}
+		// The record of Expire removes the value of the earlier records.
+		if !entry.Exp.IsZero() && !entry.Exp.After(c.clock.Now()) {
+			c.del(entry.Key, "", false)
+			return nil
+		}
+		c.restoreEntry(entry)
+	case walDel:
+		key, err := c.readKey(r)
+		if err != nil {
+			return err
+		}
+		c.del(key, "", false)
+	case walClear:
+		c.Clear()
+	default:
+		return fmt.Errorf("unknown operation %d", op)
+	}
+	return nil
+}
+
+// logSet appends the stored value to the log, it must be called under the write lock.
+func (c *Cache[Key, Value]) logSet(item *Item[Key, Value]) {
+	if c.wal == nil {
+		return
+	}
+
+	record, err := c.appendEntry([]byte{walSet}, snapshotEntry[Key, Value]{
+		Key:   item.key,
+		Value: item.val,
+		Exp:   item.ExpiresAt(),
+	})
+	c.writeWAL(record, err)
+}
+
+// logDel appends the deletion to the log, it must be called under the write lock.
+func (c *Cache[Key, Value]) logDel(key Key) {
+	if c.wal == nil {
+		return
+	}
+
+	record, err := c.appendKey([]byte{walDel}, key)
+	c.writeWAL(record, err)
+}
+
+// logClear appends Clear to the log, it must be called under the write lock.
+func (c *Cache[Key, Value]) logClear() {
+	if c.wal != nil {
+		c.writeWAL([]byte{walClear}, nil)
+	}
+}
+
+func (c *Cache[Key, Value]) writeWAL(record []byte, err error) {
+	if err == nil {
+		_, err = c.wal.Write(record)
+	}
+	if err != nil {
+		c.logger.walFailed(c.walPath, err)
+	}
+}
+
+// closeWAL closes the log before Close removes the entries.
+func (c *Cache[Key, Value]) closeWAL() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.wal == nil {
+		return nil
+	}
+
+	err := c.wal.Close()
+	c.wal = nil
+	if err != nil {
+		return fmt.Errorf("close wal: %w", err)
+	}
+	return nil
+}
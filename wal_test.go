@@ -0,0 +1,159 @@
+package locache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_WAL_Replay(t *testing.T) {
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	cache := New[string, *snapshotUser](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, cache.OpenWAL(path))
+
+	cache.Set("key0", &snapshotUser{ID: 1, Name: "John"})
+	cache.SetForever("key1", &snapshotUser{ID: 2, Name: "Jane"})
+	cache.Set("key2", &snapshotUser{ID: 3, Name: "Jack"})
+	cache.SetWithTTL("key3", &snapshotUser{ID: 4, Name: "Jill"}, time.Second)
+	cache.Set("key0", &snapshotUser{ID: 5, Name: "Joe"})
+	cache.Del("key2")
+
+	// The process crashes, the log is not compacted.
+	clock.Advance(2 * time.Second)
+
+	restored := New[string, *snapshotUser](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, restored.OpenWAL(path))
+	defer restored.Close()
+
+	require.ElementsMatch(t, []string{"key0", "key1"}, restored.Keys())
+	user, ok := restored.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, &snapshotUser{ID: 5, Name: "Joe"}, user)
+
+	ttl, ok := restored.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 58*time.Second, ttl)
+
+	ttl, ok = restored.TTL("key1")
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), ttl)
+}
+
+func TestCache_WAL_Clear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	cache := New[string, string]()
+	require.NoError(t, cache.OpenWAL(path))
+
+	cache.Set("key0", "val0")
+	cache.Clear()
+	cache.Set("key1", "val1")
+
+	restored := New[string, string]()
+	require.NoError(t, restored.OpenWAL(path))
+	require.Equal(t, []string{"key1"}, restored.Keys())
+}
+
+func TestCache_WAL_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	cache := New[string, string]()
+	require.ErrorIs(t, cache.CompactWAL(), errWALNotOpened)
+	require.NoError(t, cache.OpenWAL(path))
+	require.ErrorIs(t, cache.OpenWAL(path), errWALOpened)
+
+	for i := 0; i < 100; i++ {
+		cache.Set("key", "val")
+	}
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	size := stat.Size()
+
+	require.NoError(t, cache.CompactWAL())
+	stat, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Less(t, stat.Size(), size)
+
+	// The writes after the compaction are appended to the new file.
+	cache.Set("key1", "val1")
+
+	restored := New[string, string]()
+	require.NoError(t, restored.OpenWAL(path))
+	require.ElementsMatch(t, []string{"key", "key1"}, restored.Keys())
+}
+
+func TestCache_WAL_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	cache := New[string, string]()
+	require.NoError(t, cache.OpenWAL(path))
+	cache.Set("key0", "val0")
+
+	// Close removes the entries but doesn't log Clear.
+	require.NoError(t, cache.Close())
+
+	restored := New[string, string]()
+	require.NoError(t, restored.OpenWAL(path))
+	require.Equal(t, []string{"key0"}, restored.Keys())
+}
+
+func TestCache_WAL_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	require.NoError(t, os.WriteFile(path, []byte{42}, 0o600))
+
+	cache := New[string, string]()
+	require.ErrorContains(t, cache.OpenWAL(path), "replay wal")
+}
+
+func TestCache_WAL_TornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	cache := New[string, string]()
+	require.NoError(t, cache.OpenWAL(path))
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+
+	// The process crashes in the middle of the last record.
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, stat.Size()-3))
+
+	restored := New[string, string]()
+	require.NoError(t, restored.OpenWAL(path))
+	require.Equal(t, []string{"key0"}, restored.Keys())
+	require.NoError(t, restored.Close())
+
+	// The compaction drops the torn record, the log is appended again.
+	restored = New[string, string]()
+	require.NoError(t, restored.OpenWAL(path))
+	restored.Set("key2", "val2")
+
+	reopened := New[string, string]()
+	require.NoError(t, reopened.OpenWAL(path))
+	require.ElementsMatch(t, []string{"key0", "key2"}, reopened.Keys())
+}
+
+func TestCache_WAL_Expire(t *testing.T) {
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "cache.wal")
+
+	cache := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, cache.OpenWAL(path))
+	cache.Set("key0", "val0")
+	cache.Set("key1", "val1")
+	require.True(t, cache.Expire("key0", 10*time.Second))
+	require.True(t, cache.Expire("key1", 0))
+
+	restored := New[string, string](WithTTL(time.Minute), WithClock(clock))
+	require.NoError(t, restored.OpenWAL(path))
+	require.Equal(t, []string{"key0"}, restored.Keys())
+
+	ttl, ok := restored.TTL("key0")
+	require.True(t, ok)
+	require.Equal(t, 10*time.Second, ttl)
+}
@@ -0,0 +1,132 @@
+package locache
+
+import (
+	"context"
+	"sync"
+)
+
+// keyWatcher keeps the latest notification of the key, the previous one is replaced
+// if the subscriber hasn't received it yet.
+type keyWatcher[Value any] struct {
+	ch chan Value
+	// setsOnly skips the notifications of removed entries.
+	setsOnly bool
+}
+
+// notify is called under the cache write lock, so there is one sender at a time.
+func (w *keyWatcher[Value]) notify(value Value, removed bool) {
+	if removed && w.setsOnly {
+		return
+	}
+
+	select {
+	case <-w.ch:
+	default:
+	}
+	w.ch <- value
+}
+
+// Watch subscribes to the changes of the key: the channel receives the new value
+// when the key is stored and the zero value when the entry is removed.
+// Notifications are not queued, a subscriber which falls behind receives the latest one.
+// The cancel function unsubscribes and closes the channel, Close closes the channels of all watchers.
+func (c *Cache[Key, Value]) Watch(key Key) (<-chan Value, func()) {
+	w := &keyWatcher[Value]{ch: make(chan Value, 1)}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.closed.Load() {
+		close(w.ch)
+		return w.ch, func() {}
+	}
+
+	c.watch(key, w)
+
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() { c.unwatch(key, w) })
+	}
+}
+
+// WaitFor returns the value of a valid entry or blocks until the value of the key
+// is stored by another goroutine. It returns the context error when the context is done
+// and ErrClosed when the cache is closed.
+func (c *Cache[Key, Value]) WaitFor(ctx context.Context, key Key) (Value, error) {
+	var emptyVal Value
+	w := &keyWatcher[Value]{ch: make(chan Value, 1), setsOnly: true}
+
+	c.mtx.Lock()
+	if c.closed.Load() {
+		c.mtx.Unlock()
+		return emptyVal, ErrClosed
+	}
+	if element, found := c.index[key]; found {
+		if item := c.getItem(element); item.IsValid(c.clock.Now()) {
+			val := item.val
+			c.mtx.Unlock()
+			return val, nil
+		}
+	}
+	c.watch(key, w)
+	c.mtx.Unlock()
+
+	defer c.unwatch(key, w)
+
+	select {
+	case val, ok := <-w.ch:
+		if !ok {
+			return emptyVal, ErrClosed
+		}
+		return val, nil
+	case <-ctx.Done():
+		return emptyVal, ctx.Err()
+	}
+}
+
+// watch registers the watcher of the key, it must be called under the write lock.
+func (c *Cache[Key, Value]) watch(key Key, w *keyWatcher[Value]) {
+	if c.watchers == nil {
+		c.watchers = make(map[Key]map[*keyWatcher[Value]]struct{})
+	}
+	if c.watchers[key] == nil {
+		c.watchers[key] = make(map[*keyWatcher[Value]]struct{})
+	}
+	c.watchers[key][w] = struct{}{}
+}
+
+func (c *Cache[Key, Value]) unwatch(key Key, w *keyWatcher[Value]) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// The channel is already closed by Close.
+	if _, found := c.watchers[key][w]; !found {
+		return
+	}
+
+	delete(c.watchers[key], w)
+	if len(c.watchers[key]) == 0 {
+		delete(c.watchers, key)
+	}
+	close(w.ch)
+}
+
+// notifyWatchers sends the value to the watchers of the key, it must be called under the write lock.
+func (c *Cache[Key, Value]) notifyWatchers(key Key, value Value, removed bool) {
+	for w := range c.watchers[key] {
+		w.notify(value, removed)
+	}
+}
+
+// closeWatchers closes the channels of all watchers.
+func (c *Cache[Key, Value]) closeWatchers() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, watchers := range c.watchers {
+		for w := range watchers {
+			close(w.ch)
+		}
+	}
+	c.watchers = nil
+}
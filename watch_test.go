@@ -0,0 +1,111 @@
+package locache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Watch(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+
+	values, cancel := cache.Watch("key0")
+	other, cancelOther := cache.Watch("key1")
+	defer cancelOther()
+
+	cache.Set("key0", "value1")
+	require.Equal(t, "value1", <-values)
+
+	// A subscriber which falls behind receives the latest value.
+	cache.Set("key0", "value2")
+	cache.Set("key0", "value3")
+	require.Equal(t, "value3", <-values)
+
+	cache.Del("key0")
+	require.Equal(t, "", <-values)
+
+	cache.Set("key0", "value4")
+	cache.Clear()
+	require.Equal(t, "", <-values)
+
+	require.Empty(t, other)
+
+	cancel()
+	cancel()
+	_, open := <-values
+	require.False(t, open)
+
+	cache.Set("key0", "value5")
+}
+
+func TestCache_Watch_Close(t *testing.T) {
+	cache := New[string, string]()
+	values, cancel := cache.Watch("key0")
+
+	require.NoError(t, cache.Close())
+	_, open := <-values
+	require.False(t, open)
+	cancel()
+
+	values, cancel = cache.Watch("key0")
+	_, open = <-values
+	require.False(t, open)
+	cancel()
+}
+
+func TestCache_WaitFor(t *testing.T) {
+	cache := New[string, string](WithTTL(time.Minute))
+	cache.Set("key0", "value0")
+
+	val, err := cache.WaitFor(context.Background(), "key0")
+	require.NoError(t, err)
+	require.Equal(t, "value0", val)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		val, err := cache.WaitFor(context.Background(), "key1")
+		require.NoError(t, err)
+		require.Equal(t, "value1", val)
+	}()
+
+	require.Eventually(t, func() bool {
+		cache.mtx.RLock()
+		defer cache.mtx.RUnlock()
+		return len(cache.watchers["key1"]) == 1
+	}, time.Second, time.Millisecond)
+
+	cache.Set("key1", "value1")
+	<-done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = cache.WaitFor(ctx, "key2")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Empty(t, cache.watchers)
+}
+
+func TestCache_WaitFor_Close(t *testing.T) {
+	cache := New[string, string]()
+
+	done := make(chan error)
+	go func() {
+		_, err := cache.WaitFor(context.Background(), "key0")
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		cache.mtx.RLock()
+		defer cache.mtx.RUnlock()
+		return len(cache.watchers) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, cache.Close())
+	require.ErrorIs(t, <-done, ErrClosed)
+
+	_, err := cache.WaitFor(context.Background(), "key0")
+	require.ErrorIs(t, err, ErrClosed)
+}